@@ -0,0 +1,176 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// AttrTransformer rewrites a single attribute of an allowed element.
+// It receives the lower-cased tag name and the attribute key and value,
+// and returns the value to keep. Returning false as the second result
+// drops the attribute.
+type AttrTransformer func(tag, key, val string) (string, bool)
+
+// transformAttrs runs the policy's AttrTransformers over attrs in place
+// and returns the attributes that survive.
+func (c *compiledPolicy) transformAttrs(tag string, attrs []html.Attribute) []html.Attribute {
+	if len(c.p.AttrTransformers) == 0 {
+		return attrs
+	}
+	out := attrs[:0]
+next:
+	for _, a := range attrs {
+		for _, t := range c.p.AttrTransformers {
+			val, keep := t(tag, a.Key, a.Val)
+			if !keep {
+				continue next
+			}
+			a.Val = val
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// tokenListAttrs are the attributes, besides those in idRefAttrs, whose
+// values are unordered sets of space-separated tokens.
+var tokenListAttrs = map[string]bool{
+	"class": true, "rel": true, "rev": true, "sandbox": true,
+	"itemprop": true, "itemref": true, "accesskey": true, "ping": true,
+}
+
+// keepEmptyAttrs are the attributes for which an empty value means
+// something, besides the boolean attributes.
+var keepEmptyAttrs = map[string]bool{"alt": true, "value": true}
+
+// normalizeAttrs implements Policy.NormalizeAttributes on attrs in
+// place and returns the attributes that survive.
+func (c *compiledPolicy) normalizeAttrs(attrs []html.Attribute) []html.Attribute {
+	if !c.p.NormalizeAttributes {
+		return attrs
+	}
+	out := attrs[:0]
+	for _, a := range attrs {
+		key := strings.ToLower(a.Key)
+		switch {
+		case booleanAttrs[key]:
+		case tokenListAttrs[key] || idRefAttrs[key]:
+			a.Val = uniqueTokens(a.Val)
+		case key != "value":
+			a.Val = strings.TrimSpace(a.Val)
+		}
+		if a.Val == "" && !booleanAttrs[key] && !keepEmptyAttrs[key] {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// uniqueTokens returns the space-separated tokens of s without
+// duplicates, in order of first appearance, joined by single spaces.
+func uniqueTokens(s string) string {
+	tokens := strings.Fields(s)
+	if len(tokens) < 2 {
+		return strings.Join(tokens, " ")
+	}
+	kept := tokens[:0]
+	var seen map[string]bool // for long lists, which scanning kept would make quadratic
+	if len(tokens) > 8 {
+		seen = make(map[string]bool, len(tokens))
+	}
+	for _, t := range tokens {
+		switch {
+		case seen != nil && seen[t], seen == nil && slices.Contains(kept, t):
+			continue
+		case seen != nil:
+			seen[t] = true
+		}
+		kept = append(kept, t)
+	}
+	return strings.Join(kept, " ")
+}
+
+// capAttrs cuts attribute values short as Policy.MaxAttributeLengths
+// asks.
+func (c *compiledPolicy) capAttrs(attrs []html.Attribute) []html.Attribute {
+	if len(c.maxAttrLen) == 0 {
+		return attrs
+	}
+	for i, a := range attrs {
+		key := strings.ToLower(a.Key)
+		if isURLAttr(key) {
+			continue
+		}
+		max, ok := c.maxAttrLen[key]
+		if !ok {
+			max, ok = c.maxAttrLen["*"]
+		}
+		if ok && max > 0 && len(a.Val) > max {
+			attrs[i].Val = truncateRunes(a.Val, max)
+		}
+	}
+	return attrs
+}
+
+// truncateRunes returns the first max characters of s.
+func truncateRunes(s string, max int) string {
+	n := 0
+	for i := range s {
+		if n == max {
+			return s[:i]
+		}
+		n++
+	}
+	return s
+}
+
+// booleanAttrs are the attributes whose presence alone carries meaning.
+var booleanAttrs = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true, "autoplay": true,
+	"checked": true, "controls": true, "default": true, "defer": true,
+	"disabled": true, "formnovalidate": true, "hidden": true, "inert": true,
+	"ismap": true, "itemscope": true, "loop": true, "multiple": true,
+	"muted": true, "nomodule": true, "novalidate": true, "open": true,
+	"playsinline": true, "readonly": true, "required": true, "reversed": true,
+	"selected": true,
+}
+
+// validBoolean reports whether the boolean attribute a has one of the
+// two valid values: the empty string or its own name, or for hidden
+// also until-found. Any other value is text smuggled into an attribute
+// that has no use for it.
+func validBoolean(a html.Attribute) bool {
+	return a.Val == "" || strings.EqualFold(a.Val, a.Key) ||
+		a.Key == "hidden" && strings.EqualFold(a.Val, "until-found")
+}
+
+// maxSpan is the largest span HTML allows on col and colgroup.
+const maxSpan = 1000
+
+// canonicalSpan returns the span attribute value val as a plain
+// decimal, reporting false unless it is an integer from 1 to maxSpan.
+func canonicalSpan(val string) (string, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n < 1 || n > maxSpan {
+		return "", false
+	}
+	return strconv.Itoa(n), true
+}
+
+// writeAttr writes a as ` key="value"`. Boolean attributes without a
+// value are written as a bare name.
+func writeAttr(buf *bytes.Buffer, a html.Attribute) {
+	buf.WriteByte(' ')
+	buf.WriteString(a.Key)
+	if a.Val == "" && booleanAttrs[a.Key] {
+		return
+	}
+	buf.WriteString(`="`)
+	escapeAttr(buf, a.Val)
+	buf.WriteByte('"')
+}
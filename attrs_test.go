@@ -0,0 +1,171 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"golang.org/x/net/html"
+)
+
+func TestSanitize_AttrTransformers(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AttrTransformers = []htmlsanitizer.AttrTransformer{
+		func(tag, key, val string) (string, bool) {
+			if key == "class" {
+				val = strings.Join(strings.Fields(val), " ")
+				return val, val != ""
+			}
+			return val, true
+		},
+		func(tag, key, val string) (string, bool) {
+			if tag == "a" && key == "title" {
+				return strings.TrimSpace(val), true
+			}
+			return val, true
+		},
+	}
+	input := `<a class="  x   y " title=" t " href="/a">a</a><p class="   ">p</p><p onclick="x">q</p>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a class="x y" title="t" href="/a">a</a><p>p</p><p>q</p>`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_BooleanAttributes(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedAttributes["details"] = []string{"open"}
+	p.AllowedAttributes["ol"] = []string{"reversed", "start"}
+	p.AllowedAttributes["div"] = []string{"hidden"}
+	tests := []struct{ in, want string }{
+		{`<details open><summary>s</summary></details>`, `<details open><summary>s</summary></details>`},
+		{`<details open="OPEN">x</details>`, `<details open>x</details>`},
+		{`<details open="x onmouseover=alert(1)">x</details>`, `<details>x</details>`},
+		{`<ol reversed="" start="3"><li>a</li></ol>`, `<ol reversed start="3"><li>a</li></ol>`},
+		{`<div hidden="Until-Found">x</div>`, `<div hidden="until-found">x</div>`},
+		{`<div hidden="hidden">x</div>`, `<div hidden>x</div>`},
+		{`<div hidden="until-lost">x</div>`, `<div>x</div>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+		if got := sanitizeTokens(t, tt.in, p); got != tt.want {
+			t.Errorf("tokens %s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize_AttributeCase(t *testing.T) {
+	p := &htmlsanitizer.Policy{
+		AllowedTags:       []string{"a", "svg"},
+		AllowedAttributes: map[string][]string{"A": {"HREF", "Title"}, "svg": {"viewbox"}},
+		AllowedSchemes:    []string{"https"},
+		StripDisallowed:   true,
+	}
+	in := `<a href="https://example.com/" TITLE="t" onclick="x()">x</a><svg viewBox="0 0 1 1"></svg>`
+	got, err := htmlsanitizer.Sanitize(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a href="https://example.com/" title="t">x</a><svg viewBox="0 0 1 1"></svg>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	q := &htmlsanitizer.Policy{
+		AllowedTags:       p.AllowedTags,
+		AllowedAttributes: map[string][]string{"a": {"href", "title"}, "svg": {"viewBox"}},
+		AllowedSchemes:    p.AllowedSchemes,
+		StripDisallowed:   true,
+	}
+	if p.Fingerprint() != q.Fingerprint() {
+		t.Error("policies differing only in case have different fingerprints")
+	}
+}
+
+func TestSanitize_PreserveAttributeCase(t *testing.T) {
+	p := &htmlsanitizer.Policy{
+		AllowedTags:       []string{"p", "a", "td"},
+		AllowedAttributes: map[string][]string{"a": {"href"}, "td": {"colspan"}},
+		AllowedSchemes:    []string{"https"},
+		StripDisallowed:   true,
+		XHTML:             true,
+	}
+	in := `<p><a HREF="javascript:x()">x</a><td colSpan="2">y</td></p>`
+	for _, tt := range []struct {
+		preserve bool
+		want     string
+	}{
+		{false, `<p><a>x</a><td colspan="2">y</td></p>`},
+		{true, `<p><a>x</a><td colSpan="2">y</td></p>`},
+	} {
+		p.PreserveAttributeCase = tt.preserve
+		got, err := htmlsanitizer.Sanitize(in, p.Clone())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("PreserveAttributeCase=%v: got %q, want %q", tt.preserve, got, tt.want)
+		}
+	}
+}
+
+func TestGetAttr_CaseInsensitive(t *testing.T) {
+	n := &html.Node{Type: html.ElementNode, Data: "td", Attr: []html.Attribute{{Key: "colSpan", Val: "2"}}}
+	if got := htmlsanitizer.GetAttr(n, "colspan"); got != "2" {
+		t.Errorf("GetAttr = %q", got)
+	}
+	htmlsanitizer.SetAttr(n, "COLSPAN", "3")
+	htmlsanitizer.RemoveAttr(n, "ColSpan")
+	if len(n.Attr) != 0 {
+		t.Errorf("attributes left: %v", n.Attr)
+	}
+}
+
+func TestSanitize_NormalizeAttributes(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.NormalizeAttributes = true
+	p.AllowedAttributes["img"] = []string{"src", "alt", "title"}
+	p.AllowedAttributes["a"] = append(p.AllowedAttributes["a"], "rel")
+	p.AllowedAttributes["details"] = []string{"open"}
+	p.AllowedTags = append(p.AllowedTags, "details")
+	in := `<p class="  b  a
+	b "><a href=" /x " title="  hi " rel="nofollow nofollow">x</a><img src="/i.png" alt="" title=" "><span class="">y</span></p><details open></details>`
+	want := `<p class="b a"><a href="/x" title="hi" rel="nofollow">x</a><img src="/i.png" alt="" /><span>y</span></p><details open></details>`
+	got, err := htmlsanitizer.Sanitize(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	long := strings.Repeat("x y ", 1000) + "z"
+	if got, _ := htmlsanitizer.Sanitize(`<p class="`+long+`">t</p>`, p); got != `<p class="x y z">t</p>` {
+		t.Errorf("long class list: got %q", got)
+	}
+}
+
+func TestSanitize_MaxAttributeLengths(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedAttributes["img"] = []string{"src", "alt", "title"}
+	p.MaxAttributeLengths = map[string]int{"Alt": 5, "*": 3, "src": 2}
+	in := `<img src="/cheap-pills.png" alt="héllo world" title="buy buy buy"><p id="a1">x</p>`
+	want := `<img src="/cheap-pills.png" alt="héllo" title="buy" /><p id="a1">x</p>`
+	got, err := htmlsanitizer.Sanitize(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package htmlsanitizer
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// orderAttrs puts the attributes of n into canonical order in place
+// when the policy asks for it. See Policy.SortAttributes.
+func (c *compiledPolicy) orderAttrs(n *html.Node) {
+	if c.attrRank == nil {
+		return
+	}
+	attrs := n.Attr
+	if n.Namespace == "" && !c.p.PreserveAttributeCase {
+		for i := range attrs {
+			attrs[i].Key = strings.ToLower(attrs[i].Key) // as set by transformers
+		}
+	}
+	rank := func(k string) int {
+		if r, ok := c.attrRank[k]; ok {
+			return r
+		}
+		return len(c.attrRank)
+	}
+	sort.SliceStable(attrs, func(i, j int) bool {
+		ki, kj := strings.ToLower(attrs[i].Key), strings.ToLower(attrs[j].Key)
+		if ri, rj := rank(ki), rank(kj); ri != rj {
+			return ri < rj
+		}
+		return ki < kj
+	})
+}
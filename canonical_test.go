@@ -0,0 +1,45 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"golang.org/x/net/html"
+)
+
+func TestSanitize_SortAttributes(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.SortAttributes = true
+	input := `<a title="t" rel="r" href="/x" id="i">x</a><IMG ALT="a" SRC="/a.png">`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a href="/x" id="i" rel="r" title="t">x</a><img alt="a" src="/a.png" />`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_AttributeOrder(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.SortAttributes = true
+	p.AttributeOrder = []string{"id", "class", "href"}
+	p.Transformers = []htmlsanitizer.Transformer{
+		func(n *html.Node) *html.Node {
+			if n.Data == "a" {
+				htmlsanitizer.SetAttr(n, "Target", "_blank")
+			}
+			return n
+		},
+	}
+	input := `<a title="t" href="/x" class="c" id="i">x</a>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a id="i" class="c" href="/x" target="_blank" title="t">x</a>`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
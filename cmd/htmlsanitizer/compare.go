@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+// runCompare implements the compare command: it sanitizes every record
+// with an old and a new policy and reports the elements and attributes
+// the new policy would strip or keep, with sample documents.
+func runCompare(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	loadOld := policyFlags(fs, "old-")
+	loadNew := policyFlags(fs, "new-")
+	openSource := sourceFlags(fs)
+	samples := fs.Int("samples", htmlsanitizer.DefaultComparisonSamples, "sample `documents` to name per difference")
+	asJSON := fs.Bool("json", false, "write the comparison as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	oldPolicy, err := loadOld()
+	if err != nil {
+		return err
+	}
+	newPolicy, err := loadNew()
+	if err != nil {
+		return err
+	}
+	src, closeSource, err := openSource()
+	if err != nil {
+		return fmt.Errorf("compare: %w", err)
+	}
+	defer closeSource()
+
+	cmp := htmlsanitizer.NewPolicyComparison(oldPolicy, newPolicy)
+	cmp.MaxSamples = *samples
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rec, err := src.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		cmp.Add(rec.ID, rec.HTML)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cmp)
+	}
+	fmt.Fprintf(stdout, "%d documents: %d changed, %d newly rejected, %d newly accepted\n",
+		cmp.Documents, cmp.Changed, cmp.Rejected, cmp.Accepted)
+	for _, section := range []struct {
+		title string
+		keys  []string
+		diffs map[string]*htmlsanitizer.PolicyDifference
+	}{
+		{"newly stripped", cmp.StrippedKeys(), cmp.Stripped},
+		{"newly kept", cmp.KeptKeys(), cmp.Kept},
+	} {
+		if len(section.keys) == 0 {
+			continue
+		}
+		fmt.Fprintf(stdout, "\n%s:\n", section.title)
+		for _, key := range section.keys {
+			d := section.diffs[key]
+			fmt.Fprintf(stdout, "  %-24s %6d in %d documents, e.g. %s\n", key, d.Occurrences, d.Documents, strings.Join(d.Samples, ", "))
+		}
+	}
+	return nil
+}
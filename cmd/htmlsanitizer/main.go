@@ -0,0 +1,163 @@
+// Command htmlsanitizer applies htmlsanitizer policies to content in
+// bulk.
+//
+// Usage:
+//
+//	htmlsanitizer <command> [flags]
+//
+// The commands are:
+//
+//	migrate   sanitize the records of a CSV or NDJSON export, resumably
+//	compare   report how a new policy would change the output for a corpus
+//
+// Run "htmlsanitizer <command> -h" for the flags of a command. Policies
+// are given by -policy, the name of a registered policy such as
+// "default" or "editorial", or by -policy-file, a PolicyConfig in JSON;
+// compare takes -old-policy and -new-policy, and their -policy-file
+// forms, instead. Input is read with -in from a CSV file with a header
+// row, an NDJSON file, or a directory of HTML files.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"github.com/njchilds90/htmlsanitizer/migrate"
+)
+
+// commands maps the name of each command to the function that runs it
+// with the arguments that follow the name.
+var commands = map[string]func(ctx context.Context, args []string, stdout, stderr io.Writer) error{
+	"migrate": runMigrate,
+	"compare": runCompare,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "htmlsanitizer:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || commands[args[0]] == nil {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(stderr, "usage: htmlsanitizer <command> [flags]\ncommands: %v\n", names)
+		return flag.ErrHelp
+	}
+	return commands[args[0]](ctx, args[1:], stdout, stderr)
+}
+
+// policyFlags registers the -<prefix>policy and -<prefix>policy-file
+// flags on fs and returns a function that loads the policy they select.
+func policyFlags(fs *flag.FlagSet, prefix string) func() (*htmlsanitizer.Policy, error) {
+	name := fs.String(prefix+"policy", "default", "name of a registered `policy`: "+fmt.Sprint(htmlsanitizer.PolicyNames()))
+	file := fs.String(prefix+"policy-file", "", "JSON PolicyConfig `file` to use instead of -"+prefix+"policy")
+	return func() (*htmlsanitizer.Policy, error) {
+		if *file != "" {
+			data, err := os.ReadFile(*file)
+			if err != nil {
+				return nil, err
+			}
+			return htmlsanitizer.ParsePolicy(data)
+		}
+		p, ok := htmlsanitizer.PolicyByName(*name)
+		if !ok {
+			return nil, fmt.Errorf("%w %q", htmlsanitizer.ErrUnknownPolicy, *name)
+		}
+		return p, nil
+	}
+}
+
+// sourceFlags registers the flags that select the records a command
+// reads on fs and returns a function that opens them, returning the
+// source and a function that closes it. Directories are read as one
+// record per *.html or *.htm file below them, with the file's path as
+// its ID.
+func sourceFlags(fs *flag.FlagSet) func() (migrate.Source, func(), error) {
+	in := fs.String("in", "", "input `file`, CSV with a header row or NDJSON, or a directory of HTML files; - for standard input")
+	format := fs.String("format", "", "input `format`, csv or ndjson; by default taken from the -in extension")
+	idField := fs.String("id", "id", "`name` of the ID column or field")
+	htmlField := fs.String("html", "html", "`name` of the HTML column or field")
+	return func() (migrate.Source, func(), error) {
+		if *in == "" {
+			return nil, nil, errors.New("-in is required")
+		}
+		if info, err := os.Stat(*in); err == nil && info.IsDir() {
+			src, err := newDirSource(*in)
+			return src, func() {}, err
+		}
+		if *format == "" {
+			*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*in)), ".")
+		}
+		f := os.Stdin
+		if *in != "-" {
+			var err error
+			if f, err = os.Open(*in); err != nil {
+				return nil, nil, err
+			}
+		}
+		switch *format {
+		case "csv":
+			src, err := migrate.NewCSVSource(f, *idField, *htmlField)
+			if err != nil {
+				f.Close()
+				return nil, nil, err
+			}
+			return src, func() { f.Close() }, nil
+		case "ndjson", "jsonl":
+			return migrate.NewNDJSONSource(f, *idField, *htmlField), func() { f.Close() }, nil
+		}
+		f.Close()
+		return nil, nil, fmt.Errorf("unknown input format %q", *format)
+	}
+}
+
+// dirSource reads the HTML files below a directory, in lexical order.
+type dirSource struct {
+	files []string
+}
+
+func newDirSource(dir string) (*dirSource, error) {
+	s := &dirSource{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); !d.IsDir() && (ext == ".html" || ext == ".htm") {
+			s.files = append(s.files, path)
+		}
+		return nil
+	})
+	return s, err
+}
+
+func (s *dirSource) Next(ctx context.Context) (migrate.Record, error) {
+	if len(s.files) == 0 {
+		return migrate.Record{}, io.EOF
+	}
+	path := s.files[0]
+	s.files = s.files[1:]
+	data, err := os.ReadFile(path)
+	return migrate.Record{ID: path, HTML: string(data)}, err
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Usage(t *testing.T) {
+	var stderr bytes.Buffer
+	if err := run(context.Background(), []string{"frobnicate"}, &bytes.Buffer{}, &stderr); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("err = %v, want flag.ErrHelp", err)
+	}
+	if !strings.Contains(stderr.String(), "migrate") {
+		t.Errorf("usage does not list migrate: %q", stderr.String())
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "posts.csv")
+	if err := os.WriteFile(in, []byte("id,body\n1,<p>a</p><script>x</script>\n2,<p>b</p>\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.ndjson")
+	checkpoint := filepath.Join(dir, "checkpoint")
+	args := []string{"migrate", "-in", in, "-html", "body", "-out", out, "-out-format", "ndjson",
+		"-checkpoint", checkpoint, "-policy", "strict", "-q"}
+
+	if err := run(context.Background(), args, &bytes.Buffer{}, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "\n"); got != 2 {
+		t.Fatalf("got %d results:\n%s", got, data)
+	}
+	if !strings.Contains(string(data), `"html":"<p>a</p>","changed":true,"removed_elements":1`) {
+		t.Errorf("first result not sanitized:\n%s", data)
+	}
+
+	// Running again resumes after the last record and writes nothing.
+	if err := run(context.Background(), args, &bytes.Buffer{}, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("resumed run changed the output:\n%s", again)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"a.html":     `<p><a href="/x" title="X">x</a></p>`,
+		"sub/b.html": `<ul><li>b</li></ul>`,
+		"notes.txt":  `<p>ignored</p>`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout bytes.Buffer
+	args := []string{"compare", "-in", dir, "-old-policy", "default", "-new-policy", "strict"}
+	if err := run(context.Background(), args, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	got := stdout.String()
+	for _, want := range []string{"2 documents: 1 changed", "newly stripped:", "a[href]", filepath.Join(dir, "a.html")} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+}
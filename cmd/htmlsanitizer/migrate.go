@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/njchilds90/htmlsanitizer/migrate"
+)
+
+// runMigrate implements the migrate command: it sanitizes the records
+// of a CSV or NDJSON file, or of a directory of HTML files, and writes
+// the results with a report per record, checkpointing its progress if
+// -checkpoint is set, so that running the same command again after an
+// interruption resumes it.
+func runMigrate(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	loadPolicy := policyFlags(fs, "")
+	openSource := sourceFlags(fs)
+	out := fs.String("out", "", "output `file`; standard output if empty")
+	outFormat := fs.String("out-format", "ndjson", "output `format`, csv or ndjson")
+	checkpoint := fs.String("checkpoint", "", "checkpoint `file` to resume from and save progress to")
+	every := fs.Int("every", migrate.DefaultCheckpointEvery, "`records` between checkpoints")
+	quiet := fs.Bool("q", false, "do not report progress on standard error")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	src, closeSource, err := openSource()
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	defer closeSource()
+
+	opts := migrate.Options{Policy: p, CheckpointEvery: *every}
+	resuming := false
+	if *checkpoint != "" {
+		opts.Checkpoint = migrate.FileCheckpoint(*checkpoint)
+		progress, err := opts.Checkpoint.Load()
+		if err != nil {
+			return err
+		}
+		resuming = progress.Processed > 0
+	}
+	if !*quiet {
+		opts.Progress = func(pr migrate.Progress) {
+			fmt.Fprintf(stderr, "migrate: %d records, %d changed, %d failed\n", pr.Processed, pr.Changed, pr.Failed)
+		}
+	}
+
+	w := stdout
+	if *out != "" {
+		// A resumed run appends to the output of the runs before it.
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if resuming {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(*out, flags, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	var sink migrate.Sink
+	switch *outFormat {
+	case "csv":
+		sink = migrate.NewCSVSink(w, !resuming)
+	case "ndjson", "jsonl":
+		sink = migrate.NewNDJSONSink(w)
+	default:
+		return fmt.Errorf("migrate: unknown output format %q", *outFormat)
+	}
+
+	_, err = migrate.Run(ctx, src, sink, opts)
+	return err
+}
@@ -0,0 +1,176 @@
+package htmlsanitizer
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultComparisonSamples is the number of sample documents a
+// PolicyComparison keeps per difference when MaxSamples is zero.
+const DefaultComparisonSamples = 5
+
+// PolicyComparison runs two policies over the same corpus and collects
+// how the output of the new one differs from that of the old one, so
+// that a policy change can be evaluated before it is rolled out:
+//
+//	cmp := htmlsanitizer.NewPolicyComparison(current, proposed)
+//	for _, post := range posts {
+//		cmp.Add(post.ID, post.Body)
+//	}
+//	for _, key := range cmp.StrippedKeys() {
+//		d := cmp.Stripped[key]
+//		fmt.Printf("%s: %d times in %d documents, e.g. %v\n", key, d.Occurrences, d.Documents, d.Samples)
+//	}
+//
+// Elements are keyed by tag name, as in "img", and attributes by tag
+// and attribute name, as in "a[target]". A PolicyComparison is not safe
+// for concurrent use.
+type PolicyComparison struct {
+	// Documents counts the documents added, and Changed those whose
+	// output differs between the policies.
+	Documents int
+	Changed   int
+
+	// Rejected counts the documents that the new policy fails on, such
+	// as for exceeding one of its limits, but the old one does not;
+	// Accepted counts the reverse. Their outputs are not compared.
+	Rejected int
+	Accepted int
+
+	// Stripped holds the elements and attributes that occur fewer
+	// times in the output of the new policy than in that of the old
+	// one, and Kept those that occur more often.
+	Stripped map[string]*PolicyDifference
+	Kept     map[string]*PolicyDifference
+
+	// Samples names the first changed, rejected, or accepted documents.
+	Samples []string
+
+	// MaxSamples bounds Samples and the samples of every difference.
+	// Zero means DefaultComparisonSamples.
+	MaxSamples int
+
+	old, new *Sanitizer
+}
+
+// PolicyDifference describes how often an element or attribute is
+// stripped or kept by the new policy of a PolicyComparison only.
+type PolicyDifference struct {
+	// Occurrences counts the element or attribute across documents,
+	// and Documents the documents it occurs in.
+	Occurrences int
+	Documents   int
+
+	// Samples names the first documents it occurs in.
+	Samples []string
+}
+
+// NewPolicyComparison returns an empty PolicyComparison of the policies
+// oldPolicy and newPolicy. A nil policy means the default policy.
+func NewPolicyComparison(oldPolicy, newPolicy *Policy) *PolicyComparison {
+	return &PolicyComparison{
+		Stripped: make(map[string]*PolicyDifference),
+		Kept:     make(map[string]*PolicyDifference),
+		old:      NewSanitizer(oldPolicy),
+		new:      NewSanitizer(newPolicy),
+	}
+}
+
+// Add sanitizes the document htmlStr, called name in samples, with both
+// policies and records the differences.
+func (c *PolicyComparison) Add(name, htmlStr string) {
+	c.Documents++
+	oldOut, oldErr := c.old.Sanitize(htmlStr)
+	newOut, newErr := c.new.Sanitize(htmlStr)
+	switch {
+	case oldErr == nil && newErr != nil:
+		c.Rejected++
+		c.sample(&c.Samples, name)
+		return
+	case oldErr != nil && newErr == nil:
+		c.Accepted++
+		c.sample(&c.Samples, name)
+		return
+	case oldErr != nil || oldOut == newOut:
+		return
+	}
+	c.Changed++
+	c.sample(&c.Samples, name)
+
+	oldCounts, newCounts := outputCensus(oldOut), outputCensus(newOut)
+	for key, n := range oldCounts {
+		if d := n - newCounts[key]; d > 0 {
+			c.record(c.Stripped, key, d, name)
+		}
+	}
+	for key, n := range newCounts {
+		if d := n - oldCounts[key]; d > 0 {
+			c.record(c.Kept, key, d, name)
+		}
+	}
+}
+
+// record adds n occurrences of key in the document name to diffs.
+func (c *PolicyComparison) record(diffs map[string]*PolicyDifference, key censusKey, n int, name string) {
+	k := key.tag
+	if key.attr != "" {
+		k += "[" + key.attr + "]"
+	}
+	d := diffs[k]
+	if d == nil {
+		d = &PolicyDifference{}
+		diffs[k] = d
+	}
+	d.Occurrences += n
+	d.Documents++
+	c.sample(&d.Samples, name)
+}
+
+// sample appends name to samples unless they are full.
+func (c *PolicyComparison) sample(samples *[]string, name string) {
+	limit := c.MaxSamples
+	if limit <= 0 {
+		limit = DefaultComparisonSamples
+	}
+	if len(*samples) < limit {
+		*samples = append(*samples, name)
+	}
+}
+
+// StrippedKeys and KeptKeys return the keys of Stripped and Kept, most
+// frequent first.
+func (c *PolicyComparison) StrippedKeys() []string { return differenceKeys(c.Stripped) }
+
+func (c *PolicyComparison) KeptKeys() []string { return differenceKeys(c.Kept) }
+
+func differenceKeys(diffs map[string]*PolicyDifference) []string {
+	keys := make([]string, 0, len(diffs))
+	for k := range diffs {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if n := diffs[b].Occurrences - diffs[a].Occurrences; n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+	return keys
+}
+
+// outputCensus counts the elements and attributes of out, sanitized
+// output, parsed as the body of a document.
+func outputCensus(out string) map[censusKey]int {
+	counts := make(map[censusKey]int)
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(out), body)
+	if err != nil {
+		return counts
+	}
+	for _, n := range nodes {
+		census(n, counts)
+	}
+	return counts
+}
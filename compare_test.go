@@ -0,0 +1,40 @@
+package htmlsanitizer_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestPolicyComparison(t *testing.T) {
+	proposed := htmlsanitizer.DefaultPolicy()
+	proposed.AllowedAttributes["a"] = []string{"href"}
+	proposed.AllowedTags = slices.DeleteFunc(proposed.AllowedTags, func(tag string) bool { return tag == "img" })
+	proposed.AllowedTags = append(proposed.AllowedTags, "mark")
+	proposed.MaxInputSize = 100
+
+	cmp := htmlsanitizer.NewPolicyComparison(htmlsanitizer.DefaultPolicy(), proposed)
+	cmp.MaxSamples = 1
+	cmp.Add("plain", `<p>unchanged</p>`)
+	cmp.Add("links", `<a href="/a" title="A">a</a> <a href="/b" title="B">b</a> <mark>m</mark>`)
+	cmp.Add("image", `<p><img src="/x.png" alt=""><a href="/c" title="C">c</a></p>`)
+	cmp.Add("long", `<p>`+string(make([]byte, 200))+`</p>`)
+
+	if cmp.Documents != 4 || cmp.Changed != 2 || cmp.Rejected != 1 || cmp.Accepted != 0 {
+		t.Errorf("counts = %d documents, %d changed, %d rejected, %d accepted",
+			cmp.Documents, cmp.Changed, cmp.Rejected, cmp.Accepted)
+	}
+	if want := []string{"a[title]", "img", "img[alt]", "img[src]"}; !slices.Equal(cmp.StrippedKeys(), want) {
+		t.Errorf("StrippedKeys() = %v, want %v", cmp.StrippedKeys(), want)
+	}
+	if d := cmp.Stripped["a[title]"]; d.Occurrences != 3 || d.Documents != 2 || !slices.Equal(d.Samples, []string{"links"}) {
+		t.Errorf("a[title] = %+v", d)
+	}
+	if want := []string{"mark"}; !slices.Equal(cmp.KeptKeys(), want) {
+		t.Errorf("KeptKeys() = %v, want %v", cmp.KeptKeys(), want)
+	}
+	if !slices.Equal(cmp.Samples, []string{"links"}) {
+		t.Errorf("Samples = %v", cmp.Samples)
+	}
+}
@@ -0,0 +1,118 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Sanitizer applies a Policy that was compiled once, so that services
+// sanitizing many documents with the same configuration do not rebuild
+// the tag, attribute, and scheme sets on every call. It is safe for
+// concurrent use.
+//
+// NewSanitizer copies the policy's slices and maps, and the Sanitizer
+// never modifies its copy: changing the original policy afterwards has
+// no effect. Functions and values held by reference, such as
+// Transformers, URLPolicy, ContentFilter, LinkifyPattern, Logger, and
+// Tracer, are shared rather than copied and must themselves be safe for
+// concurrent use. To change the configuration, derive a new Sanitizer with With.
+type Sanitizer struct {
+	c *compiledPolicy
+}
+
+// NewSanitizer compiles a copy of p. If p is nil, the default policy is used.
+func NewSanitizer(p *Policy) *Sanitizer {
+	if p == nil {
+		p = nilPolicy()
+	}
+	return &Sanitizer{c: compilePolicy(clonePolicy(p))}
+}
+
+// Sanitize sanitizes htmlStr.
+func (s *Sanitizer) Sanitize(htmlStr string) (string, error) {
+	return sanitizeString(context.Background(), htmlStr, s.c, nil)
+}
+
+// SanitizeReader reads HTML from r and sanitizes it.
+func (s *Sanitizer) SanitizeReader(r io.Reader) (string, error) {
+	return sanitize(context.Background(), r, s.c, nil)
+}
+
+// SanitizeContext sanitizes htmlStr, passing ctx to the policy's
+// Logger and Tracer.
+func (s *Sanitizer) SanitizeContext(ctx context.Context, htmlStr string) (string, error) {
+	return sanitizeString(ctx, htmlStr, s.c, nil)
+}
+
+// SanitizeWithReport sanitizes htmlStr and reports what was removed.
+func (s *Sanitizer) SanitizeWithReport(htmlStr string) (string, *Report, error) {
+	rep := &Report{}
+	out, err := sanitize(context.Background(), strings.NewReader(htmlStr), s.c, rep)
+	return out, rep, err
+}
+
+// Policy returns a copy of the policy s applies. Changing it does not
+// affect s.
+func (s *Sanitizer) Policy() *Policy {
+	return clonePolicy(s.c.p)
+}
+
+// With returns a new Sanitizer whose policy is a copy of the one s
+// applies, changed by configure. s itself is unaffected and may keep
+// serving calls while the new Sanitizer is built.
+func (s *Sanitizer) With(configure func(p *Policy)) *Sanitizer {
+	p := clonePolicy(s.c.p)
+	configure(p)
+	return &Sanitizer{c: compilePolicy(p)}
+}
+
+// clonePolicy returns a copy of p that shares no slices or maps with
+// it.
+func clonePolicy(p *Policy) *Policy {
+	q := *p
+	q.AllowedTags = slices.Clone(p.AllowedTags)
+	if p.AllowedAttributes != nil {
+		q.AllowedAttributes = make(map[string][]string, len(p.AllowedAttributes))
+		for k, v := range p.AllowedAttributes {
+			q.AllowedAttributes[k] = slices.Clone(v)
+		}
+	}
+	q.AllowedSchemes = slices.Clone(p.AllowedSchemes)
+	q.AllowedURLPorts = slices.Clone(p.AllowedURLPorts)
+	q.DisallowedActions = maps.Clone(p.DisallowedActions)
+	q.DropContentTags = slices.Clone(p.DropContentTags)
+	q.RawTextActions = maps.Clone(p.RawTextActions)
+	q.Transformers = slices.Clone(p.Transformers)
+	q.PreFilterTransformers = slices.Clone(p.PreFilterTransformers)
+	q.AttrTransformers = slices.Clone(p.AttrTransformers)
+	q.TextTransformers = slices.Clone(p.TextTransformers)
+	q.AttrDefaults = slices.Clone(p.AttrDefaults)
+	q.LinkifyTLDs = slices.Clone(p.LinkifyTLDs)
+	q.LinkifyExclude = slices.Clone(p.LinkifyExclude)
+	q.LinkifyImageExtensions = slices.Clone(p.LinkifyImageExtensions)
+	q.LinkifyVideoExtensions = slices.Clone(p.LinkifyVideoExtensions)
+	q.LinkifyMediaHosts = slices.Clone(p.LinkifyMediaHosts)
+	q.VoidElements = slices.Clone(p.VoidElements)
+	if p.ContextRules != nil {
+		q.ContextRules = make(map[string]ContextRule, len(p.ContextRules))
+		for k, v := range p.ContextRules {
+			q.ContextRules[k] = ContextRule{Children: slices.Clone(v.Children), Deny: slices.Clone(v.Deny)}
+		}
+	}
+	if p.AllowedParents != nil {
+		q.AllowedParents = make(map[string][]string, len(p.AllowedParents))
+		for k, v := range p.AllowedParents {
+			q.AllowedParents[k] = slices.Clone(v)
+		}
+	}
+	q.MaxAttributeLengths = maps.Clone(p.MaxAttributeLengths)
+	q.LegacyClasses = maps.Clone(p.LegacyClasses)
+	q.Emoji = maps.Clone(p.Emoji)
+	q.CustomEmoji = maps.Clone(p.CustomEmoji)
+	q.AttributeOrder = slices.Clone(p.AttributeOrder)
+	q.PostTransformers = slices.Clone(p.PostTransformers)
+	return &q
+}
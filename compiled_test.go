@@ -0,0 +1,113 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+const compiledInput = `<p class="x" onclick="bad()">Hi <b>there</b> <a href="javascript:alert(1)">x</a> <a href="https://go.dev" title="Go">go</a><script>bad()</script></p>`
+
+func TestSanitizer(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	want, err := htmlsanitizer.Sanitize(compiledInput, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := htmlsanitizer.NewSanitizer(p)
+	got, err := s.Sanitize(compiledInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got, err = s.SanitizeReader(strings.NewReader(compiledInput))
+	if err != nil || got != want {
+		t.Errorf("SanitizeReader: got %q, %v; want %q", got, err, want)
+	}
+	_, rep, err := s.SanitizeWithReport(compiledInput)
+	if err != nil || rep.RemovedElements != 1 {
+		t.Errorf("SanitizeWithReport: %+v, %v", rep, err)
+	}
+}
+
+func TestSanitizer_CopiesPolicy(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	s := htmlsanitizer.NewSanitizer(p)
+	want, _ := s.Sanitize(compiledInput)
+	fp := s.Policy().Fingerprint()
+
+	p.AllowedTags = append(p.AllowedTags[:0], "script")
+	p.AllowedAttributes["*"] = append(p.AllowedAttributes["*"], "onclick")
+	p.AllowedSchemes[0] = "javascript"
+	p.StripDisallowed = true
+
+	if got, _ := s.Sanitize(compiledInput); got != want {
+		t.Errorf("Sanitizer changed with its source policy:\n got  %q\n want %q", got, want)
+	}
+	if got := s.Policy().Fingerprint(); got != fp {
+		t.Error("Policy fingerprint changed with the source policy")
+	}
+
+	q := s.Policy()
+	q.AllowedTags = nil
+	if got, _ := s.Sanitize(compiledInput); got != want {
+		t.Error("Sanitizer changed with the policy returned by Policy")
+	}
+}
+
+func TestSanitizer_With(t *testing.T) {
+	s := htmlsanitizer.NewSanitizer(nil)
+	before, _ := s.Sanitize(compiledInput)
+	strict := s.With(func(p *htmlsanitizer.Policy) {
+		p.AllowedTags = []string{"p"}
+		p.StripDisallowed = true
+	})
+	if got, want := mustSanitize(t, strict, compiledInput), `<p class="x">Hi   </p>`; got != want {
+		t.Errorf("derived: got %q, want %q", got, want)
+	}
+	if got := mustSanitize(t, s, compiledInput); got != before {
+		t.Errorf("original changed: got %q, want %q", got, before)
+	}
+}
+
+func TestSanitizer_Concurrent(t *testing.T) {
+	s := htmlsanitizer.NewSanitizer(nil)
+	want := mustSanitize(t, s, compiledInput)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if got, err := s.Sanitize(compiledInput); err != nil || got != want {
+					t.Errorf("got %q, %v", got, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func mustSanitize(t *testing.T, s *htmlsanitizer.Sanitizer, in string) string {
+	t.Helper()
+	out, err := s.Sanitize(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func BenchmarkSanitizer(b *testing.B) {
+	input := strings.Repeat(compiledInput, 20)
+	s := htmlsanitizer.NewSanitizer(nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = s.Sanitize(input)
+	}
+}
@@ -0,0 +1,233 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PolicyConfig is the JSON form of the declarative parts of a Policy,
+// for policies kept in files or databases, such as those loaded by a
+// PolicyStore. Fields left out keep the value of the Base policy;
+// hooks such as Transformers cannot be expressed and come from Base.
+//
+//	{
+//	  "base": "default",
+//	  "allowed_tags": ["p", "b", "i", "a"],
+//	  "allowed_attributes": {"a": ["href", "title"]},
+//	  "disallowed_actions": {"*": "unwrap"},
+//	  "max_output_length": 65536
+//	}
+type PolicyConfig struct {
+	// Base names the registered policy the configuration starts from;
+	// empty means "default".
+	Base string `json:"base,omitempty"`
+
+	Version             string              `json:"version,omitempty"`
+	AllowedTags         []string            `json:"allowed_tags,omitempty"`
+	AllowedAttributes   map[string][]string `json:"allowed_attributes,omitempty"`
+	AllowDataAttributes *bool               `json:"allow_data_attributes,omitempty"`
+	AllowedSchemes      []string            `json:"allowed_schemes,omitempty"`
+	StripDisallowed     *bool               `json:"strip_disallowed,omitempty"`
+	DisallowedActions   map[string]string   `json:"disallowed_actions,omitempty"`
+	RawTextActions      map[string]string   `json:"raw_text_actions,omitempty"`
+	DropContentTags     []string            `json:"drop_content_tags,omitempty"`
+	Linkify             *bool               `json:"linkify,omitempty"`
+	Namespace           *string             `json:"namespace,omitempty"`
+	MaxDepth            *int                `json:"max_depth,omitempty"`
+	MaxNestingDepth     *int                `json:"max_nesting_depth,omitempty"`
+	MaxURLLength        *int                `json:"max_url_length,omitempty"`
+	MaxInputSize        *int                `json:"max_input_size,omitempty"`
+	MaxNodes            *int                `json:"max_nodes,omitempty"`
+	MaxOutputLength     *int                `json:"max_output_length,omitempty"`
+}
+
+// actionNames maps the names used in PolicyConfig to actions.
+var actionNames = map[string]DisallowedAction{
+	"escape": DisallowedEscape,
+	"strip":  DisallowedStrip,
+	"unwrap": DisallowedUnwrap,
+	"code":   DisallowedCode,
+}
+
+// ParsePolicy decodes a PolicyConfig from JSON and returns the policy
+// it describes. Unknown fields, unknown action names, and policies that
+// fail Validate are errors.
+func ParsePolicy(data []byte) (*Policy, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cfg PolicyConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("htmlsanitizer: policy config: %w", err)
+	}
+	return cfg.Policy()
+}
+
+// Policy returns the policy cfg describes, validated with
+// Policy.Validate.
+func (cfg *PolicyConfig) Policy() (*Policy, error) {
+	base := "default"
+	if cfg.Base != "" {
+		base = cfg.Base
+	}
+	bp, ok := PolicyByName(base)
+	if !ok {
+		return nil, fmt.Errorf("htmlsanitizer: policy config: unknown base policy %q", base)
+	}
+	p := clonePolicy(bp)
+	if cfg.Version != "" {
+		p.Version = cfg.Version
+	}
+	if cfg.AllowedTags != nil {
+		p.AllowedTags = cfg.AllowedTags
+	}
+	if cfg.AllowedAttributes != nil {
+		p.AllowedAttributes = cfg.AllowedAttributes
+	}
+	if cfg.AllowedSchemes != nil {
+		p.AllowedSchemes = cfg.AllowedSchemes
+	}
+	if cfg.DropContentTags != nil {
+		p.DropContentTags = cfg.DropContentTags
+	}
+	var err error
+	if cfg.DisallowedActions != nil {
+		if p.DisallowedActions, err = parseActions(cfg.DisallowedActions); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.RawTextActions != nil {
+		if p.RawTextActions, err = parseActions(cfg.RawTextActions); err != nil {
+			return nil, err
+		}
+	}
+	setIf(&p.AllowDataAttributes, cfg.AllowDataAttributes)
+	setIf(&p.StripDisallowed, cfg.StripDisallowed)
+	setIf(&p.Linkify, cfg.Linkify)
+	setIf(&p.Namespace, cfg.Namespace)
+	setIf(&p.MaxDepth, cfg.MaxDepth)
+	setIf(&p.MaxNestingDepth, cfg.MaxNestingDepth)
+	setIf(&p.MaxURLLength, cfg.MaxURLLength)
+	setIf(&p.MaxInputSize, cfg.MaxInputSize)
+	setIf(&p.MaxNodes, cfg.MaxNodes)
+	setIf(&p.MaxOutputLength, cfg.MaxOutputLength)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func setIf[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func parseActions(m map[string]string) (map[string]DisallowedAction, error) {
+	out := make(map[string]DisallowedAction, len(m))
+	for tag, name := range m {
+		action, ok := actionNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("htmlsanitizer: policy config: unknown action %q for %q", name, tag)
+		}
+		out[tag] = action
+	}
+	return out, nil
+}
+
+// unsafeTags are elements that run code or change how the rest of the
+// page is loaded; no sanitizing of their attributes makes them safe.
+var unsafeTags = map[string]bool{
+	"script": true, "style": true, "base": true, "meta": true, "link": true,
+	"object": true, "embed": true, "applet": true, "frame": true, "frameset": true,
+}
+
+// unsafeSchemes are URL schemes that run code when followed.
+var unsafeSchemes = map[string]bool{"javascript": true, "vbscript": true}
+
+// Validate reports configuration mistakes in p: allowed tags that run
+// code, event handler attributes, script URL schemes, malformed names,
+// and negative limits. It returns all of them joined, or nil. Policies
+// built in code may skip it; PolicyStore and ParsePolicy reject
+// policies that fail it.
+func (p *Policy) Validate() error {
+	var errs []error
+	bad := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("htmlsanitizer: invalid policy: "+format, args...))
+	}
+	for _, tag := range p.AllowedTags {
+		switch t := strings.ToLower(tag); {
+		case !validName(t):
+			bad("malformed tag name %q", tag)
+		case unsafeTags[t]:
+			bad("tag %q cannot be allowed safely", tag)
+		}
+	}
+	if t := strings.ToLower(p.TableContainer); t != "" && (!validName(t) || unsafeTags[t] || isVoidElement(t)) {
+		bad("TableContainer %q cannot hold a table", p.TableContainer)
+	}
+	for _, tag := range p.VoidElements {
+		if t := strings.ToLower(tag); !validName(t) || isRawTextTag(t) {
+			bad("VoidElements: %q cannot be void", tag)
+		}
+	}
+	tags := make([]string, 0, len(p.AllowedAttributes))
+	for tag := range p.AllowedAttributes {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		attrs := p.AllowedAttributes[tag]
+		if tag != "*" && !validName(strings.ToLower(tag)) {
+			bad("malformed tag name %q", tag)
+		}
+		for _, a := range attrs {
+			switch {
+			case !validName(strings.ToLower(a)):
+				bad("malformed attribute name %q on %q", a, tag)
+			case isEventHandler(a):
+				bad("event handler attribute %q on %q cannot be allowed safely", a, tag)
+			}
+		}
+	}
+	for _, s := range p.AllowedSchemes {
+		if unsafeSchemes[strings.ToLower(s)] {
+			bad("URL scheme %q cannot be allowed safely", s)
+		}
+	}
+	for _, limit := range []struct {
+		name string
+		v    int
+	}{
+		{"MaxDepth", p.MaxDepth}, {"MaxURLLength", p.MaxURLLength}, {"MaxInputSize", p.MaxInputSize},
+		{"MaxNodes", p.MaxNodes}, {"MaxOutputLength", p.MaxOutputLength},
+		{"MaxTableColumns", p.MaxTableColumns}, {"MaxTableCells", p.MaxTableCells},
+	} {
+		if limit.v < 0 {
+			bad("%s is negative", limit.name)
+		}
+	}
+	for name, n := range p.MaxAttributeLengths {
+		if n < 0 {
+			bad("MaxAttributeLengths[%q] is negative", name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validName reports whether s is a plausible lower-case tag or
+// attribute name.
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r <= ' ' || strings.ContainsRune(`"'<>/=`, r) {
+			return false
+		}
+	}
+	return true
+}
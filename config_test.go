@@ -0,0 +1,53 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := htmlsanitizer.ParsePolicy([]byte(`{
+		"base": "strict",
+		"allowed_tags": ["p", "b", "a"],
+		"allowed_attributes": {"a": ["href"]},
+		"disallowed_actions": {"*": "unwrap"},
+		"max_output_length": 100
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := htmlsanitizer.Sanitize(`<p><a href="https://go.dev" title="x">go</a> <i>it</i></p>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<p><a href="https://go.dev">go</a> it</p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if p.MaxOutputLength != 100 || p.AllowedSchemes[0] != "https" {
+		t.Errorf("fields: %d %v", p.MaxOutputLength, p.AllowedSchemes)
+	}
+
+	for _, bad := range []string{
+		`{"allowed_tag": ["p"]}`,
+		`{"base": "nope"}`,
+		`{"disallowed_actions": {"*": "explode"}}`,
+		`{"allowed_tags": ["script"]}`,
+		`{"allowed_attributes": {"*": ["onclick"]}}`,
+		`{"allowed_schemes": ["JavaScript"]}`,
+		`{"max_depth": -1}`,
+	} {
+		if _, err := htmlsanitizer.ParsePolicy([]byte(bad)); err == nil {
+			t.Errorf("ParsePolicy(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestPolicy_ValidatePresets(t *testing.T) {
+	for _, name := range htmlsanitizer.PolicyNames() {
+		p, _ := htmlsanitizer.PolicyByName(name)
+		if err := p.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
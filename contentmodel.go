@@ -0,0 +1,215 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// phrasingTags are the elements that are phrasing content, including
+// obsolete inline elements that browsers still treat as such.
+var phrasingTags = map[string]bool{
+	"a": true, "abbr": true, "acronym": true, "audio": true, "b": true,
+	"bdi": true, "bdo": true, "big": true, "br": true, "button": true,
+	"canvas": true, "cite": true, "code": true, "data": true, "del": true,
+	"dfn": true, "em": true, "embed": true, "font": true, "i": true,
+	"iframe": true, "img": true, "input": true, "ins": true, "kbd": true,
+	"label": true, "map": true, "mark": true, "math": true, "meter": true,
+	"object": true, "output": true, "picture": true, "progress": true,
+	"q": true, "ruby": true, "rp": true, "rt": true, "s": true, "samp": true,
+	"select": true, "small": true, "span": true, "strike": true,
+	"strong": true, "sub": true, "sup": true, "svg": true, "template": true,
+	"textarea": true, "time": true, "tt": true, "u": true, "var": true,
+	"video": true, "wbr": true,
+}
+
+// transparentTags are phrasing elements whose content model is that of
+// their parent.
+var transparentTags = map[string]bool{
+	"a": true, "ins": true, "del": true, "object": true, "video": true,
+	"audio": true, "canvas": true, "map": true,
+}
+
+// phrasingParents are the elements whose content must be phrasing
+// content.
+var phrasingParents = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "pre": true, "dt": true, "summary": true, "legend": true,
+}
+
+// requiredParents lists, for elements that are only valid in certain
+// containers, the containers they may appear in.
+var requiredParents = map[string][]string{
+	"li":         {"ul", "ol", "menu"},
+	"dt":         {"dl", "div"},
+	"dd":         {"dl", "div"},
+	"figcaption": {"figure"},
+	"caption":    {"table"},
+	"colgroup":   {"table"},
+	"col":        {"colgroup"},
+	"summary":    {"details"},
+	"legend":     {"fieldset"},
+}
+
+// enforceContentModel repairs the children of n so that they follow the
+// HTML content model. phrasing is true when n only admits phrasing
+// content.
+func (c *compiledPolicy) enforceContentModel(n *html.Node, phrasing bool) {
+	for child := n.FirstChild; child != nil; {
+		// Foreign content such as SVG has its own rules.
+		if child.Type != html.ElementNode || child.Namespace != "" {
+			child = child.NextSibling
+			continue
+		}
+		tag := child.Data
+		switch {
+		case phrasing && !phrasingTags[tag] && n.Data == "p" && n.Parent != nil:
+			splitParagraph(n, child)
+			return
+		case tag == "li" && !phrasing && !validParent(tag, n) && c.allowedTags["ul"]:
+			child = wrapStrayItems(child)
+		case phrasing && !phrasingTags[tag], !validParent(tag, n):
+			child = unwrapSeparated(child)
+			continue
+		}
+		if tag == "ul" || tag == "ol" || tag == "menu" {
+			c.wrapListItems(child)
+		}
+		switch {
+		case transparentTags[tag]:
+			c.enforceContentModel(child, phrasing)
+		case tag == "template":
+		default:
+			c.enforceContentModel(child, phrasingTags[tag] || phrasingParents[tag])
+		}
+		child = child.NextSibling
+	}
+}
+
+// validParent reports whether tag may appear directly inside parent.
+func validParent(tag string, parent *html.Node) bool {
+	parents, ok := requiredParents[tag]
+	if !ok {
+		return true
+	}
+	for _, p := range parents {
+		if parent.Type == html.ElementNode && parent.Data == p {
+			return tag != "dt" && tag != "dd" || p == "dl" || parent.Parent != nil && parent.Parent.Data == "dl"
+		}
+	}
+	return false
+}
+
+// splitParagraph ends p before the block element block and moves the
+// block out after it, followed by a new paragraph holding whatever came
+// after the block. Paragraphs left empty are removed.
+func splitParagraph(p, block *html.Node) {
+	rest := &html.Node{Type: html.ElementNode, Data: "p", DataAtom: atom.P}
+	for _, a := range p.Attr {
+		if !strings.EqualFold(a.Key, "id") {
+			rest.Attr = append(rest.Attr, a)
+		}
+	}
+	for c := block.NextSibling; c != nil; c = block.NextSibling {
+		p.RemoveChild(c)
+		rest.AppendChild(c)
+	}
+	p.RemoveChild(block)
+	p.Parent.InsertBefore(block, p.NextSibling)
+	if !isBlank(rest) {
+		p.Parent.InsertBefore(rest, block.NextSibling)
+	}
+	if isBlank(p) {
+		p.Parent.RemoveChild(p)
+	}
+}
+
+// wrapListItems moves runs of children of list that are not list items
+// into list items of their own. Whitespace between items is left as it
+// is.
+func (c *compiledPolicy) wrapListItems(list *html.Node) {
+	if !c.allowedTags["li"] {
+		return
+	}
+	var item *html.Node
+	for child := list.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.ElementNode && child.Data == "li":
+			item = nil
+		case item == nil && child.Type == html.TextNode && strings.TrimSpace(child.Data) == "":
+		default:
+			if item == nil {
+				item = &html.Node{Type: html.ElementNode, Data: "li", DataAtom: atom.Li}
+				list.InsertBefore(item, child)
+			}
+			list.RemoveChild(child)
+			item.AppendChild(child)
+		}
+		child = next
+	}
+}
+
+// wrapStrayItems moves the list item li and the list items that follow
+// it, with the whitespace between them, into a new ul and returns the ul.
+func wrapStrayItems(li *html.Node) *html.Node {
+	list := &html.Node{Type: html.ElementNode, Data: "ul", DataAtom: atom.Ul}
+	li.Parent.InsertBefore(list, li)
+	for c := li; c != nil; c = list.NextSibling {
+		switch {
+		case c.Type == html.ElementNode && c.Data == "li" && c.Namespace == "":
+		case c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" &&
+			c.NextSibling != nil && c.NextSibling.Type == html.ElementNode && c.NextSibling.Data == "li":
+		default:
+			return list
+		}
+		c.Parent.RemoveChild(c)
+		list.AppendChild(c)
+	}
+	return list
+}
+
+// unwrapSeparated unwraps n like unwrap, but keeps a space between n's
+// content and the content around it, so that unwrapping adjacent items
+// does not run their words together.
+func unwrapSeparated(n *html.Node) *html.Node {
+	if !separated(n.PrevSibling) {
+		n.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: " "}, n)
+	}
+	if !separated(n.NextSibling) {
+		n.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: " "}, n.NextSibling)
+	}
+	return unwrap(n)
+}
+
+// separated reports whether the sibling n of an unwrapped element needs
+// no space to keep it apart: it is missing or whitespace.
+func separated(n *html.Node) bool {
+	return n == nil || n.Type == html.TextNode && strings.TrimSpace(n.Data) == ""
+}
+
+// unwrap replaces n by its children and returns the node that now
+// follows n's previous sibling.
+func unwrap(n *html.Node) *html.Node {
+	first := n.FirstChild
+	if first == nil {
+		first = n.NextSibling
+	}
+	for c := n.FirstChild; c != nil; c = n.FirstChild {
+		n.RemoveChild(c)
+		n.Parent.InsertBefore(c, n)
+	}
+	n.Parent.RemoveChild(n)
+	return first
+}
+
+// isBlank reports whether n has no children other than whitespace.
+func isBlank(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.TextNode || strings.TrimSpace(c.Data) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestEnforceContentModel(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, "dl", "dt", "dd", "svg", "path")
+	p.AllowedAttributes["path"] = []string{"d"}
+	p.EnforceContentModel = true
+	tests := []struct{ in, want string }{
+		{`<p class="x" id="a">one<table><tr><td>two</td></tr></table>three</p>`,
+			`<p class="x" id="a">one</p><table><tbody><tr><td>two</td></tr></tbody></table><p class="x">three</p>`},
+		{`<p><table><tr><td>only</td></tr></table></p>`, `<table><tbody><tr><td>only</td></tr></tbody></table>`},
+		{`<span>a<div>b</div>c</span>`, `<span>a b c</span>`},
+		{`<h2>T<ul><li>x</li></ul></h2>`, `<h2>T x</h2>`},
+		{`<li>stray</li><div><li>also</li></div>`, `<ul><li>stray</li></ul><div><ul><li>also</li></ul></div>`},
+		{"<li>orphan</li>\n<li>two</li>text", "<ul><li>orphan</li>\n<li>two</li></ul>text"},
+		{`<ul><li>a</li>loose <b>text</b><li>b</li></ul>`, `<ul><li>a</li><li>loose <b>text</b></li><li>b</li></ul>`},
+		{`<dt>t</dt><dl><div><dt>k</dt><dd>v</dd></div></dl>`, `t <dl><div><dt>k</dt><dd>v</dd></div></dl>`},
+		{`<figcaption>c</figcaption>`, `c`},
+		{`<h2><li>orphan</li><li>two</li></h2>`, `<h2>orphan two</h2>`},
+		{`<a href="/x"><div>block link</div></a>`, `<a href="/x"><div>block link</div></a>`},
+		{`<b><a href="/x"><div>x</div></a></b>`, `<b><a href="/x">x</a></b>`},
+		{`<p><svg><path d="M0"></path></svg></p>`, `<p><svg><path d="M0"></path></svg></p>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Sanitize(%q)\n got  %q\n want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package htmlsanitizer
+
+import "strings"
+
+// ContextRule restricts what may appear inside an element. It is keyed
+// by the element's tag in Policy.ContextRules.
+type ContextRule struct {
+	// Children, if non-empty, lists the only tags allowed as direct
+	// children of the element, e.g. the row groups and rows of a
+	// <table>.
+	Children []string
+
+	// Deny lists tags not allowed anywhere inside the element, e.g.
+	// nested interactive content inside <a>.
+	Deny []string
+}
+
+// compiledContext is the lookup form of Policy.ContextRules and
+// Policy.AllowedParents.
+type compiledContext struct {
+	children map[string]map[string]bool
+	deny     map[string]map[string]bool
+	parents  map[string]map[string]bool
+}
+
+func compileContext(p *Policy) *compiledContext {
+	if len(p.ContextRules) == 0 && len(p.AllowedParents) == 0 {
+		return nil
+	}
+	cc := &compiledContext{
+		children: make(map[string]map[string]bool),
+		deny:     make(map[string]map[string]bool),
+		parents:  make(map[string]map[string]bool),
+	}
+	for tag, r := range p.ContextRules {
+		tag = strings.ToLower(tag)
+		if len(r.Children) > 0 {
+			cc.children[tag] = sliceToSet(r.Children)
+		}
+		if len(r.Deny) > 0 {
+			cc.deny[tag] = sliceToSet(r.Deny)
+		}
+	}
+	for tag, parents := range p.AllowedParents {
+		cc.parents[strings.ToLower(tag)] = sliceToSet(parents)
+	}
+	return cc
+}
+
+// contextAllowed reports whether an allowed tag may appear inside the
+// kept elements ancestors, innermost last.
+func (c *compiledPolicy) contextAllowed(tag string, ancestors []string) bool {
+	cc := c.context
+	if cc == nil {
+		return true
+	}
+	parent := ""
+	if len(ancestors) > 0 {
+		parent = ancestors[len(ancestors)-1]
+	}
+	if children, ok := cc.children[parent]; ok && !children[tag] {
+		return false
+	}
+	if parents, ok := cc.parents[tag]; ok && !parents[parent] {
+		return false
+	}
+	for _, a := range ancestors {
+		if cc.deny[a][tag] {
+			return false
+		}
+	}
+	return true
+}
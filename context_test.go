@@ -0,0 +1,49 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestContextRules(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.DisallowedActions = map[string]htmlsanitizer.DisallowedAction{"*": htmlsanitizer.DisallowedUnwrap}
+	p.ContextRules = map[string]htmlsanitizer.ContextRule{
+		"a":  {Deny: []string{"a", "details", "summary"}},
+		"UL": {Children: []string{"li"}},
+	}
+	p.AllowedParents = map[string][]string{
+		"figcaption": {"figure"},
+		"li":         {"ul", "ol"},
+	}
+	input := `<ul><li>a</li><p>x</p></ul>` +
+		`<a href="/x">go <details><summary>s</summary>d</details></a>` +
+		`<figure><img src="/a.png"><figcaption>c</figcaption></figure>` +
+		`<figcaption>loose</figcaption><li>stray</li>` +
+		`<font><ul><li>kept</li></ul></font>`
+	want := `<ul><li>a</li>x</ul>` +
+		`<a href="/x">go sd</a>` +
+		`<figure><img src="/a.png" /><figcaption>c</figcaption></figure>` +
+		`loosestray` +
+		`<ul><li>kept</li></ul>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if got := sanitizeTokens(t, input, p); got != want {
+		t.Errorf("tokens: got  %q\nwant %q", got, want)
+	}
+}
+
+func TestContextRules_Fingerprint(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	before := p.Fingerprint()
+	p.ContextRules = map[string]htmlsanitizer.ContextRule{"a": {Deny: []string{"a"}}}
+	if p.Fingerprint() == before {
+		t.Error("ContextRules did not change the fingerprint")
+	}
+}
@@ -0,0 +1,466 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// dialect describes a lightweight markup language that sanitized HTML
+// can be converted to.
+type dialect struct {
+	bold, italic, strike, underline, code string // inline delimiters; "" drops the formatting
+
+	// headings prefixes h1–h3 with "#" markers; otherwise headings are
+	// set in bold.
+	headings bool
+
+	// fence starts and ends code blocks, followed by the language name
+	// when langTag is set.
+	fence   string
+	langTag bool
+
+	images     bool // write images as links rather than their alt text
+	bullet     string
+	escape     func(string) string // for text
+	escapeCode func(string) string // for code spans and blocks
+	link       func(href, text string) string
+}
+
+// slackDialect is Slack's mrkdwn format.
+var slackDialect = &dialect{
+	bold: "*", italic: "_", strike: "~", code: "`",
+	fence:      "```",
+	bullet:     "•",
+	escape:     slackEscaper.Replace,
+	escapeCode: slackEscaper.Replace,
+	link: func(href, text string) string {
+		// "|" would end the URL and start the link text.
+		escaped := slackEscaper.Replace(href)
+		if text == "" || text == escaped {
+			return "<" + strings.ReplaceAll(escaped, "|", "%7C") + ">"
+		}
+		return "<" + strings.ReplaceAll(escaped, "|", "%7C") + "|" + text + ">"
+	},
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// discordURLEscaper encodes the characters that would end a URL written
+// in angle brackets.
+var discordURLEscaper = strings.NewReplacer("<", "%3C", ">", "%3E")
+
+// discordDialect is the Markdown flavour Discord renders in messages.
+var discordDialect = &dialect{
+	bold: "**", italic: "*", strike: "~~", underline: "__", code: "`",
+	headings:   true,
+	fence:      "```",
+	langTag:    true,
+	bullet:     "-",
+	escape:     markdownEscaper.Replace,
+	escapeCode: func(s string) string { return s },
+	link: func(href, text string) string {
+		if text == "" || text == markdownEscaper.Replace(href) {
+			return "<" + discordURLEscaper.Replace(href) + ">"
+		}
+		return "[" + text + "](<" + discordURLEscaper.Replace(href) + ">)"
+	},
+}
+
+// textDialect is plain text, as used for text/plain e-mail parts.
+var textDialect = &dialect{
+	bullet:     "-",
+	escape:     func(s string) string { return s },
+	escapeCode: func(s string) string { return s },
+	link: func(href, text string) string {
+		if text == "" || text == href {
+			return href
+		}
+		return text + " <" + href + ">"
+	},
+}
+
+// TextOptions configures ToText.
+type TextOptions struct {
+	// Width wraps lines longer than this many characters at word
+	// boundaries, keeping quote prefixes on continuation lines.
+	// Preformatted text is not wrapped. Zero disables wrapping.
+	Width int
+}
+
+// ToText sanitizes htmlStr with p and renders it as plain text suitable
+// for a text/plain e-mail part or a quoted reply. Block quotes are
+// prefixed with "> ", links are written as "text <url>", lists keep
+// their markers, and a "-- " signature separator line survives intact.
+// If p is nil, the default policy is used.
+func ToText(htmlStr string, p *Policy, opts TextOptions) (string, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	root, err := sanitizeTree(context.Background(), strings.NewReader(htmlStr), compilePolicy(p), nil)
+	if err != nil {
+		return "", err
+	}
+	w := &markupWriter{d: textDialect, width: opts.Width}
+	w.children(root)
+	lines := strings.Split(w.String(), "\n")
+	for i, line := range lines {
+		// Text collapsing eats the space of the "-- " separator.
+		if strings.TrimLeft(line, "> ") == "--" {
+			lines[i] = line + " "
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ToSlack sanitizes htmlStr with p and converts the result to Slack
+// mrkdwn: emphasis, links, code, block quotes, and lists are mapped to
+// their mrkdwn forms and everything else is reduced to text. If p is
+// nil, the default policy is used.
+func ToSlack(htmlStr string, p *Policy) (string, error) {
+	return convert(htmlStr, p, slackDialect)
+}
+
+// ToDiscord sanitizes htmlStr with p and converts the result to the
+// Markdown Discord renders in messages. If p is nil, the default policy is
+// used.
+func ToDiscord(htmlStr string, p *Policy) (string, error) {
+	return convert(htmlStr, p, discordDialect)
+}
+
+func convert(htmlStr string, p *Policy, d *dialect) (string, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	root, err := sanitizeTree(context.Background(), strings.NewReader(htmlStr), compilePolicy(p), nil)
+	if err != nil {
+		return "", err
+	}
+	w := &markupWriter{d: d}
+	w.children(root)
+	return w.String(), nil
+}
+
+// markupWriter renders a sanitized tree in a dialect. Block elements
+// request line breaks, which are written lazily before the next content
+// so that no blank lines pile up at block boundaries.
+type markupWriter struct {
+	d           *dialect
+	b           strings.Builder
+	width       int    // wrap width, or 0
+	prefix      string // written at the start of every line, e.g. "> "
+	breaks      int    // line breaks owed before the next content
+	breakPrefix string // prefix when the breaks were first requested
+	space       bool   // a collapsed space is owed before the next content
+	list        []int  // per open list: next item number, or 0 if unordered
+	inPre       bool
+	started     bool
+	lineOpen    bool // content has been written on the current line
+	lineLen     int  // characters on the current line
+}
+
+func (w *markupWriter) String() string {
+	return strings.TrimRight(w.b.String(), " \n")
+}
+
+// block requests n line breaks before the next content.
+func (w *markupWriter) block(n int) {
+	if w.breaks == 0 {
+		w.breakPrefix = w.prefix
+	}
+	w.breaks = max(w.breaks, n)
+	w.space = false
+}
+
+// newline ends the current line.
+func (w *markupWriter) newline() {
+	w.b.WriteByte('\n')
+	w.lineOpen = false
+	w.lineLen = 0
+}
+
+// write emits s, which is already in the dialect's syntax.
+func (w *markupWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.started && w.breaks > 0 {
+		// Blank lines inside a block quote keep its marker, so the
+		// quote reads as one block.
+		blank := strings.TrimRight(commonPrefix(w.breakPrefix, w.prefix), " ")
+		w.newline()
+		for i := 1; i < w.breaks; i++ {
+			w.b.WriteString(blank)
+			w.newline()
+		}
+	}
+	w.breaks = 0
+	n := utf8.RuneCountInString(s)
+	if w.lineOpen && w.space && w.width > 0 && !w.inPre && w.lineLen+1+n > w.width {
+		w.newline()
+	}
+	if !w.lineOpen {
+		w.b.WriteString(w.prefix)
+		w.lineLen = len(w.prefix)
+		w.space = false
+	} else if w.space {
+		w.b.WriteByte(' ')
+		w.lineLen++
+	}
+	w.space = false
+	w.b.WriteString(s)
+	w.lineLen += n
+	w.started, w.lineOpen = true, true
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+// text writes plain text, collapsing whitespace outside <pre>.
+func (w *markupWriter) text(s string) {
+	if w.inPre {
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			if i > 0 {
+				w.newline()
+			}
+			w.write(w.d.escapeCode(line))
+		}
+		return
+	}
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		w.space = w.space || (s != "" && w.lineOpen)
+		return
+	}
+	if s[0] == ' ' || s[0] == '\n' || s[0] == '\t' {
+		w.space = w.lineOpen
+	}
+	for i, word := range words {
+		if i > 0 {
+			w.space = true
+		}
+		w.write(w.d.escape(word))
+	}
+	last := s[len(s)-1]
+	w.space = last == ' ' || last == '\n' || last == '\t'
+}
+
+// inline renders the children of n on their own and returns the result
+// with surrounding whitespace trimmed.
+func (w *markupWriter) inline(n *html.Node) string {
+	sub := &markupWriter{d: w.d, inPre: w.inPre}
+	sub.children(n)
+	return strings.TrimSpace(strings.ReplaceAll(sub.String(), "\n", " "))
+}
+
+// wrap writes the children of n between delim.
+func (w *markupWriter) wrap(n *html.Node, delim string) {
+	lead := n.FirstChild != nil && n.FirstChild.Type == html.TextNode &&
+		strings.TrimLeft(n.FirstChild.Data, " \t\n") != n.FirstChild.Data
+	s := w.inline(n)
+	if s == "" {
+		return
+	}
+	if lead {
+		w.space = w.lineOpen
+	}
+	if delim == "" {
+		w.write(s)
+	} else {
+		w.write(delim + s + delim)
+	}
+	if n.LastChild != nil && n.LastChild.Type == html.TextNode &&
+		strings.TrimRight(n.LastChild.Data, " \t\n") != n.LastChild.Data {
+		w.space = true
+	}
+}
+
+func (w *markupWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *markupWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+	d := w.d
+	switch n.Data {
+	case "b", "strong":
+		w.wrap(n, d.bold)
+	case "i", "em", "cite", "var":
+		w.wrap(n, d.italic)
+	case "s", "del", "strike":
+		w.wrap(n, d.strike)
+	case "u", "ins":
+		w.wrap(n, d.underline)
+	case "code", "kbd", "samp":
+		if w.inPre {
+			w.children(n)
+			return
+		}
+		if s := strings.TrimSpace(textContent(n)); s != "" {
+			delim := fenceFor(d.code, s)
+			if delim != d.code && (s[0] == '`' || s[len(s)-1] == '`') {
+				s = " " + s + " "
+			}
+			w.write(delim + d.escapeCode(s) + delim)
+		}
+	case "a":
+		text := w.inline(n)
+		if href := GetAttr(n, "href"); href != "" {
+			w.write(d.link(href, text))
+		} else {
+			w.write(text)
+		}
+	case "img":
+		alt := d.escape(strings.TrimSpace(GetAttr(n, "alt")))
+		if src := GetAttr(n, "src"); src != "" && d.images {
+			w.write(d.link(src, alt))
+		} else {
+			w.write(alt)
+		}
+	case "br":
+		w.newline()
+		w.space = false
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.block(2)
+		s := w.inline(n)
+		switch {
+		case s == "":
+		case d.headings && n.Data <= "h3":
+			w.write(strings.Repeat("#", int(n.Data[1]-'0')) + " " + s)
+		case d.bold != "":
+			w.write(d.bold + s + d.bold)
+		default:
+			w.write(s)
+		}
+		w.block(2)
+	case "pre":
+		w.block(2)
+		lang := ""
+		if d.langTag {
+			lang = codeLanguage(n)
+		}
+		fence := fenceFor(d.fence, textContent(n))
+		if fence != "" {
+			w.write(fence + lang)
+			w.newline()
+		}
+		w.inPre = true
+		w.children(n)
+		w.inPre = false
+		if fence != "" {
+			w.newline()
+			w.write(fence)
+		}
+		w.block(2)
+	case "blockquote":
+		w.block(2)
+		saved := w.prefix
+		w.prefix += "> "
+		w.children(n)
+		w.prefix = saved
+		w.block(2)
+	case "ul", "ol":
+		w.block(2 - min(len(w.list), 1))
+		next := 0
+		if n.Data == "ol" {
+			next = 1
+		}
+		w.list = append(w.list, next)
+		w.children(n)
+		w.list = w.list[:len(w.list)-1]
+		w.block(2 - min(len(w.list), 1))
+	case "li":
+		w.block(1)
+		marker := d.bullet
+		if depth := len(w.list); depth > 0 {
+			if num := w.list[depth-1]; num > 0 {
+				marker = strconv.Itoa(num) + "."
+				w.list[depth-1]++
+			}
+			marker = strings.Repeat("   ", depth-1) + marker
+		}
+		w.write(marker)
+		w.space = true
+		w.children(n)
+		w.block(1)
+	case "tr":
+		w.block(1)
+		w.children(n)
+		w.block(1)
+	case "td", "th":
+		if n.PrevSibling != nil {
+			w.space = true
+			w.write("|")
+			w.space = true
+		}
+		w.children(n)
+	default:
+		if blockTags[n.Data] || textBreakTags[n.Data] {
+			w.block(2)
+			w.children(n)
+			w.block(2)
+			return
+		}
+		w.children(n)
+	}
+}
+
+// fenceFor returns delim, a run of backticks delimiting code, or a
+// longer run if content holds one as long, so that the content cannot
+// end the code early.
+func fenceFor(delim, content string) string {
+	if delim == "" {
+		return ""
+	}
+	longest, run := 0, 0
+	for i := 0; i < len(content); i++ {
+		if content[i] == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	if longest < len(delim) {
+		return delim
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// codeLanguage returns the language named by a language-* or lang-*
+// class on a <pre> element or its <code> child.
+func codeLanguage(pre *html.Node) string {
+	nodes := []*html.Node{pre}
+	if c := pre.FirstChild; c != nil && c.Type == html.ElementNode && c.Data == "code" {
+		nodes = append(nodes, c)
+	}
+	for _, n := range nodes {
+		for _, class := range strings.Fields(GetAttr(n, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if len(class) > len(prefix) && strings.EqualFold(class[:len(prefix)], prefix) {
+					return class[len(prefix):]
+				}
+			}
+		}
+	}
+	return ""
+}
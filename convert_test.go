@@ -0,0 +1,101 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+const convertInput = `<h2>Release <em>notes</em></h2>
+<p>Some <b>bold</b>, <i>italic</i> and <del>old</del> text with <code>x &lt; y</code>.
+See <a href="https://example.com/a?b=1&amp;c=2">the docs</a> or https://go.dev.</p>
+<ul><li>one</li><li>two <strong>2</strong><ol><li>nested</li></ol></li></ul>
+<blockquote><p>quoted</p><p>twice</p></blockquote>
+<pre><code class="language-go">if a < b {
+	return
+}</code></pre>
+<script>alert(1)</script>`
+
+func TestToSlack(t *testing.T) {
+	got, err := htmlsanitizer.ToSlack(convertInput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "*Release _notes_*\n\n" +
+		"Some *bold*, _italic_ and ~old~ text with `x &lt; y`. See <https://example.com/a?b=1&amp;c=2|the docs> or https://go.dev.\n\n" +
+		"• one\n• two *2*\n   1. nested\n\n" +
+		"> quoted\n>\n> twice\n\n" +
+		"```\nif a &lt; b {\n\treturn\n}\n```"
+	if got != want {
+		t.Errorf("got:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestToDiscord(t *testing.T) {
+	got, err := htmlsanitizer.ToDiscord(convertInput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Release *notes*\n\n" +
+		"Some **bold**, *italic* and ~~old~~ text with `x < y`. See [the docs](<https://example.com/a?b=1&c=2>) or https://go.dev.\n\n" +
+		"- one\n- two **2**\n   1. nested\n\n" +
+		"> quoted\n>\n> twice\n\n" +
+		"```go\nif a < b {\n\treturn\n}\n```"
+	if got != want {
+		t.Errorf("got:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvert_Injection(t *testing.T) {
+	input := `<a href="https://a.com/?q=<!channel>|x">hi</a> <code>a ` + "`b`" + ` c</code>` +
+		"<pre>one\n```\ntwo</pre>"
+	got, err := htmlsanitizer.ToSlack(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<https://a.com/?q=&lt;!channel&gt;%7Cx|hi> ``a `b` c``\n\n" +
+		"````\none\n```\ntwo\n````"
+	if got != want {
+		t.Errorf("Slack: got:\n%s\n\nwant:\n%s", got, want)
+	}
+
+	input = `<a href="https://a.com/?q=>)[x](https://evil.example/">hi</a> <code>` + "`tick`" + `</code>`
+	got, err = htmlsanitizer.ToDiscord(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[hi](<https://a.com/?q=%3E)[x](https://evil.example/>) `` `tick` ``"; got != want {
+		t.Errorf("Discord: got %q, want %q", got, want)
+	}
+}
+
+func TestToText(t *testing.T) {
+	input := `<p>Thanks, see <a href="https://example.com/x">the report</a>.</p>
+<blockquote><p>Can you send the numbers for the third quarter before Friday?</p><p>Ta</p></blockquote>
+<ul><li>one</li><li>two</li></ul>
+<pre>keep   this
+  as is</pre>
+<p>-- <br>Ann</p>`
+	got, err := htmlsanitizer.ToText(input, nil, htmlsanitizer.TextOptions{Width: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Thanks, see\nthe report <https://example.com/x>.\n\n" +
+		"> Can you send the numbers for\n> the third quarter before\n> Friday?\n>\n> Ta\n\n" +
+		"- one\n- two\n\n" +
+		"keep   this\n  as is\n\n" +
+		"-- \nAnn"
+	if got != want {
+		t.Errorf("got:\n%q\n\nwant:\n%q", got, want)
+	}
+}
+
+func TestToTextNoWrap(t *testing.T) {
+	got, err := htmlsanitizer.ToText(`<p>a <a href="https://go.dev">https://go.dev</a> <img src="https://example.com/i.png" alt="chart"></p>`, nil, htmlsanitizer.TextOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a https://go.dev chart"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// AttrDefault adds an attribute to allowed elements that do not carry
+// it after filtering. The value is either the static Value or, when
+// From is set, copied from another attribute of the same element:
+//
+//	{Tag: "img", Attr: "loading", Value: "lazy"}
+//	{Tag: "img", Attr: "title", From: "alt"}
+//
+// Defaults are applied after attribute filtering and AttrTransformers,
+// and before Transformers. The added attribute does not need to be in
+// AllowedAttributes, but URL attributes still pass the URL checks.
+type AttrDefault struct {
+	// Tag is the element the default applies to, or "*" for all.
+	Tag string
+
+	// Attr is the attribute to add.
+	Attr string
+
+	// Value is the value to add when From is empty.
+	Value string
+
+	// From names an attribute whose value is copied. If it is absent
+	// or empty, nothing is added.
+	From string
+
+	// Force replaces an existing value instead of only filling in a
+	// missing one.
+	Force bool
+}
+
+// applyDefaults adds the policy's AttrDefaults to the attributes of an
+// element with the given tag.
+func (c *compiledPolicy) applyDefaults(tag string, attrs []html.Attribute) []html.Attribute {
+	for _, d := range c.p.AttrDefaults {
+		if d.Tag != "*" && !strings.EqualFold(d.Tag, tag) {
+			continue
+		}
+		key := strings.ToLower(d.Attr)
+		i := attrIndex(attrs, key)
+		if i >= 0 && !d.Force {
+			continue
+		}
+		val := d.Value
+		if d.From != "" {
+			j := attrIndex(attrs, strings.ToLower(d.From))
+			if j < 0 || attrs[j].Val == "" {
+				continue
+			}
+			val = attrs[j].Val
+		}
+		if isURLAttr(key) {
+			var ok bool
+			if val, ok = c.checkURL(tag, key, val); !ok {
+				continue
+			}
+		}
+		if i >= 0 {
+			attrs[i].Val = val
+		} else {
+			attrs = append(attrs, html.Attribute{Key: key, Val: val})
+		}
+	}
+	return attrs
+}
+
+// attrIndex returns the index of the attribute key in attrs, or -1.
+func attrIndex(attrs []html.Attribute, key string) int {
+	for i, a := range attrs {
+		if strings.EqualFold(a.Key, key) {
+			return i
+		}
+	}
+	return -1
+}
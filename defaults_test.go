@@ -0,0 +1,38 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_AttrDefaults(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AttrDefaults = []htmlsanitizer.AttrDefault{
+		{Tag: "img", Attr: "loading", Value: "lazy"},
+		{Tag: "img", Attr: "title", From: "alt"},
+		{Tag: "a", Attr: "rel", Value: "nofollow", Force: true},
+		{Tag: "img", Attr: "src", From: "alt"},
+	}
+	tests := []struct{ in, want string }{
+		{`<img src="/a.png" alt="cat">`,
+			`<img src="/a.png" alt="cat" loading="lazy" title="cat" />`},
+		{`<img src="/a.png" loading="eager" title="t" alt="cat">`,
+			`<img src="/a.png" loading="eager" title="t" alt="cat" />`},
+		{`<img alt="javascript:alert(1)">`,
+			`<img alt="javascript:alert(1)" loading="lazy" title="javascript:alert(1)" />`},
+		{`<a href="/x" rel="me">x</a>`, `<a href="/x" rel="nofollow">x</a>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+		if got := sanitizeTokens(t, tt.in, p); got != tt.want {
+			t.Errorf("tokens %s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -15,9 +15,12 @@
 //   - Which attributes are allowed per tag ([Policy.AllowedAttributes])
 //   - Which URL schemes are allowed in href/src/action ([Policy.AllowedSchemes])
 //   - Whether disallowed tags are stripped (removed with children) or escaped ([Policy.StripDisallowed])
+//   - Per-tag overrides, including unwrapping, for disallowed tags ([Policy.DisallowedActions])
 //   - Zero or more [Transformer] callbacks that can mutate allowed nodes
+//   - Whole-tree passes run after sanitization ([Policy.PostTransformers])
 //   - Whether plain-text URLs in text nodes become clickable links ([Policy.Linkify])
 //   - A maximum DOM nesting depth ([Policy.MaxDepth])
+//   - Concurrent sanitization of large documents ([Policy.Parallel])
 //
 // Two built-in policies are provided:
 //   - [DefaultPolicy] — a permissive but safe policy covering common
@@ -33,6 +36,10 @@
 //   - javascript: and data: URL schemes (including entity-encoded forms)
 //   - CSS expression injection via style attributes
 //
+// Setting [Policy.Verify] re-parses the serialized output and checks
+// that it yields nothing the sanitized tree did not contain, as a guard
+// against mutation XSS.
+//
 // It does NOT provide a Content Security Policy header; pair with
 // proper HTTP headers for defence in depth.
 //
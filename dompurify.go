@@ -0,0 +1,159 @@
+package htmlsanitizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DOMPurifyConfig is the subset of DOMPurify's configuration object
+// that maps onto a Policy, so that a frontend using DOMPurify and a
+// backend using this package can share one configuration file.
+//
+// DOMPurify's built-in allowlists are far broader than DefaultPolicy
+// and include SVG; when ALLOWED_TAGS or ALLOWED_ATTR are left out, the
+// policy starts from DefaultPolicy's lists instead. DOMPurify allows
+// attributes on every tag, so ALLOWED_ATTR and ADD_ATTR become the
+// policy's "*" entry.
+type DOMPurifyConfig struct {
+	AllowedTags    []string `json:"ALLOWED_TAGS,omitempty"`
+	AllowedAttr    []string `json:"ALLOWED_ATTR,omitempty"`
+	AddTags        []string `json:"ADD_TAGS,omitempty"`
+	AddAttr        []string `json:"ADD_ATTR,omitempty"`
+	ForbidTags     []string `json:"FORBID_TAGS,omitempty"`
+	ForbidAttr     []string `json:"FORBID_ATTR,omitempty"`
+	ForbidContents []string `json:"FORBID_CONTENTS,omitempty"`
+
+	// AllowDataAttr defaults to true, as in DOMPurify.
+	AllowDataAttr *bool `json:"ALLOW_DATA_ATTR,omitempty"`
+
+	// KeepContent defaults to true, as in DOMPurify: removed elements
+	// keep their children. When false, they are removed with them.
+	KeepContent *bool `json:"KEEP_CONTENT,omitempty"`
+
+	// AllowedURIRegexp is matched against URL attribute values, which
+	// are dropped if it does not match. It must be valid in Go's RE2
+	// syntax as well as JavaScript's, and is matched case-insensitively,
+	// as DOMPurify's default is. AllowedSchemes still applies.
+	AllowedURIRegexp string `json:"ALLOWED_URI_REGEXP,omitempty"`
+
+	// UseProfiles selects DOMPurify's profiles. Only "html" and
+	// "mathMl" are supported.
+	UseProfiles map[string]bool `json:"USE_PROFILES,omitempty"`
+}
+
+// domPurifyOutputOptions are DOMPurify options that only select the
+// form of its result and have nothing to translate.
+var domPurifyOutputOptions = map[string]bool{
+	"RETURN_DOM": true, "RETURN_DOM_FRAGMENT": true, "RETURN_DOM_IMPORT": true,
+	"RETURN_TRUSTED_TYPE": true, "WHOLE_DOCUMENT": true, "IN_PLACE": true,
+	"SANITIZE_DOM": true, "FORCE_BODY": true,
+}
+
+// ParseDOMPurifyConfig decodes a DOMPurify configuration object from
+// JSON and returns the policy it describes. Output-only options such as
+// RETURN_DOM are ignored. Any other option that DOMPurifyConfig does
+// not cover is an error rather than being silently dropped, so that the
+// two sanitizers do not drift apart unnoticed.
+func ParseDOMPurifyConfig(data []byte) (*Policy, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("htmlsanitizer: DOMPurify config: %w", err)
+	}
+	var unsupported []string
+	for key := range raw {
+		if !knownDOMPurifyOption(key) && !domPurifyOutputOptions[key] {
+			unsupported = append(unsupported, key)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return nil, fmt.Errorf("htmlsanitizer: DOMPurify config: unsupported options %s", strings.Join(unsupported, ", "))
+	}
+	var cfg DOMPurifyConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("htmlsanitizer: DOMPurify config: %w", err)
+	}
+	return cfg.Policy()
+}
+
+// knownDOMPurifyOption reports whether key is a field of
+// DOMPurifyConfig.
+func knownDOMPurifyOption(key string) bool {
+	switch key {
+	case "ALLOWED_TAGS", "ALLOWED_ATTR", "ADD_TAGS", "ADD_ATTR", "FORBID_TAGS", "FORBID_ATTR",
+		"FORBID_CONTENTS", "ALLOW_DATA_ATTR", "KEEP_CONTENT", "ALLOWED_URI_REGEXP", "USE_PROFILES":
+		return true
+	}
+	return false
+}
+
+// Policy returns the policy cfg describes, validated with
+// Policy.Validate.
+func (cfg *DOMPurifyConfig) Policy() (*Policy, error) {
+	p := DefaultPolicy()
+	for profile, on := range cfg.UseProfiles {
+		switch {
+		case !on || profile == "html":
+		case profile == "mathMl":
+			p.AllowedTags = append(p.AllowedTags, mathMLTags...)
+			for _, tag := range mathMLTags {
+				p.AllowedAttributes[tag] = slices.Clone(mathMLAttrs)
+			}
+		default:
+			return nil, fmt.Errorf("htmlsanitizer: DOMPurify config: unsupported profile %q", profile)
+		}
+	}
+	if cfg.AllowedTags != nil {
+		p.AllowedTags = lowerAll(cfg.AllowedTags)
+	}
+	if cfg.AllowedAttr != nil {
+		p.AllowedAttributes = map[string][]string{"*": lowerAll(cfg.AllowedAttr)}
+	}
+	p.AllowedTags = append(p.AllowedTags, lowerAll(cfg.AddTags)...)
+	if len(cfg.AddAttr) > 0 {
+		p.AllowedAttributes["*"] = append(p.AllowedAttributes["*"], lowerAll(cfg.AddAttr)...)
+	}
+	forbidTags := sliceToSet(cfg.ForbidTags)
+	p.AllowedTags = slices.DeleteFunc(p.AllowedTags, func(tag string) bool { return forbidTags[tag] })
+	forbidAttr := sliceToSet(cfg.ForbidAttr)
+	for tag, attrs := range p.AllowedAttributes {
+		p.AllowedAttributes[tag] = slices.DeleteFunc(attrs, func(a string) bool { return forbidAttr[a] })
+	}
+	if cfg.ForbidContents != nil {
+		p.DropContentTags = lowerAll(cfg.ForbidContents)
+	}
+	p.AllowDataAttributes = cfg.AllowDataAttr == nil || *cfg.AllowDataAttr
+	p.DisallowedActions = map[string]DisallowedAction{"*": DisallowedUnwrap}
+	if cfg.KeepContent != nil && !*cfg.KeepContent {
+		p.DisallowedActions["*"] = DisallowedStrip
+	}
+	if cfg.AllowedURIRegexp != "" {
+		re, err := regexp.Compile("(?i)" + cfg.AllowedURIRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("htmlsanitizer: DOMPurify config: ALLOWED_URI_REGEXP: %w", err)
+		}
+		p.URLPolicy = URLPolicyFunc(func(tag, attr string, u *url.URL) Decision {
+			if re.MatchString(u.String()) {
+				return Defer
+			}
+			return Deny
+		})
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func lowerAll(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestParseDOMPurifyConfig(t *testing.T) {
+	p, err := htmlsanitizer.ParseDOMPurifyConfig([]byte(`{
+		"ALLOWED_TAGS": ["p", "b", "a", "span"],
+		"ALLOWED_ATTR": ["href", "class"],
+		"FORBID_ATTR": ["class"],
+		"ALLOWED_URI_REGEXP": "^https://example\\.com/",
+		"RETURN_TRUSTED_TYPE": true
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ in, want string }{
+		{`<p class="x" data-id="7"><i>hi</i></p>`, `<p data-id="7">hi</p>`},
+		{`<a href="https://example.com/a">ok</a>`, `<a href="https://example.com/a">ok</a>`},
+		{`<a href="https://evil.test/">no</a>`, `<a>no</a>`},
+		{`<span data-x="1" data-="2" onclick="x()">s</span>`, `<span data-x="1">s</span>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDOMPurifyConfig_Defaults(t *testing.T) {
+	p, err := htmlsanitizer.ParseDOMPurifyConfig([]byte(`{
+		"ADD_TAGS": ["mark"],
+		"FORBID_TAGS": ["img"],
+		"ALLOW_DATA_ATTR": false,
+		"KEEP_CONTENT": false,
+		"USE_PROFILES": {"html": true, "mathMl": true}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := htmlsanitizer.Sanitize(`<mark data-x="1">m</mark><img src="https://x.test/a.png"><font>gone</font><math><mi>x</mi></math>`, p)
+	if want := `<mark>m</mark><math><mi>x</mi></math>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if math := htmlsanitizer.MathPolicy(); len(math.AllowedAttributes["mi"]) == 0 {
+		t.Error("importing shared MathML lists modified them")
+	}
+}
+
+func TestParseDOMPurifyConfig_Errors(t *testing.T) {
+	for _, tt := range []struct{ config, want string }{
+		{`{"SAFE_FOR_TEMPLATES": true, "ADD_URI_SAFE_ATTR": []}`, "unsupported options ADD_URI_SAFE_ATTR, SAFE_FOR_TEMPLATES"},
+		{`{"USE_PROFILES": {"svg": true}}`, `unsupported profile "svg"`},
+		{`{"ALLOWED_URI_REGEXP": "(?=x)"}`, "ALLOWED_URI_REGEXP"},
+		{`{"ADD_TAGS": ["script"]}`, `tag "script"`},
+		{`{"ALLOWED_ATTR": ["onerror"]}`, `"onerror"`},
+		{`[]`, "DOMPurify config"},
+	} {
+		_, err := htmlsanitizer.ParseDOMPurifyConfig([]byte(tt.config))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.config, err, tt.want)
+		}
+	}
+}
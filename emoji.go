@@ -0,0 +1,86 @@
+package htmlsanitizer
+
+import (
+	"regexp"
+
+	"golang.org/x/net/html"
+)
+
+// shortcodeRegexp matches :shortcode: references; group 1 holds the
+// shortcode.
+var shortcodeRegexp = regexp.MustCompile(`:([A-Za-z0-9_+-]+):`)
+
+// DefaultEmoji maps common shortcodes to Unicode emoji, for use as
+// Policy.Emoji or as a base to extend with maps.Copy.
+var DefaultEmoji = map[string]string{
+	"+1":               "\U0001F44D",
+	"-1":               "\U0001F44E",
+	"thumbsup":         "\U0001F44D",
+	"thumbsdown":       "\U0001F44E",
+	"smile":            "\U0001F604",
+	"smiley":           "\U0001F603",
+	"grin":             "\U0001F601",
+	"joy":              "\U0001F602",
+	"laughing":         "\U0001F606",
+	"wink":             "\U0001F609",
+	"blush":            "\U0001F60A",
+	"heart_eyes":       "\U0001F60D",
+	"thinking":         "\U0001F914",
+	"neutral_face":     "\U0001F610",
+	"confused":         "\U0001F615",
+	"cry":              "\U0001F622",
+	"sob":              "\U0001F62D",
+	"angry":            "\U0001F620",
+	"scream":           "\U0001F631",
+	"sunglasses":       "\U0001F60E",
+	"heart":            "❤️",
+	"broken_heart":     "\U0001F494",
+	"fire":             "\U0001F525",
+	"sparkles":         "✨",
+	"star":             "⭐",
+	"tada":             "\U0001F389",
+	"rocket":           "\U0001F680",
+	"eyes":             "\U0001F440",
+	"clap":             "\U0001F44F",
+	"pray":             "\U0001F64F",
+	"wave":             "\U0001F44B",
+	"ok_hand":          "\U0001F44C",
+	"muscle":           "\U0001F4AA",
+	"100":              "\U0001F4AF",
+	"check":            "✔️",
+	"white_check_mark": "✅",
+	"x":                "❌",
+	"warning":          "⚠️",
+	"bulb":             "\U0001F4A1",
+	"bug":              "\U0001F41B",
+	"coffee":           "☕",
+}
+
+// appendEmoji appends a replacement for every :shortcode: in text
+// found in Policy.Emoji or Policy.CustomEmoji. Custom emoji become
+// images only if the policy allows img with src and the image URL
+// passes its checks; otherwise they fall back to Emoji, and then to
+// the text as written.
+func (c *compiledPolicy) appendEmoji(links []textLink, text string) []textLink {
+	p := c.p
+	if len(p.Emoji) == 0 && len(p.CustomEmoji) == 0 {
+		return links
+	}
+	for _, m := range shortcodeRegexp.FindAllStringSubmatchIndex(text, -1) {
+		code := text[m[2]:m[3]]
+		if src, ok := p.CustomEmoji[code]; ok && c.allowsEmbed("img") {
+			if src, ok = c.checkURL("img", "src", src); ok {
+				links = append(links, textLink{start: m[0], end: m[1], embed: "img", attrs: []html.Attribute{
+					{Key: "src", Val: src},
+					{Key: "alt", Val: text[m[0]:m[1]]},
+					{Key: "class", Val: "emoji"},
+				}})
+				continue
+			}
+		}
+		if emoji, ok := p.Emoji[code]; ok && emoji != "" {
+			links = append(links, textLink{start: m[0], end: m[1], text: emoji})
+		}
+	}
+	return links
+}
@@ -0,0 +1,58 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_Emoji(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Emoji = map[string]string{"tada": "🎉", "party": "🥳"}
+	p.CustomEmoji = map[string]string{
+		"gopher": "https://cdn.example.com/gopher.png",
+		"evil":   "javascript:alert(1)",
+		"party":  "data:image/png;base64,AAAA",
+	}
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"unicode", `<p>done :tada:!</p>`, `<p>done 🎉!</p>`},
+		{"image", `<p>:gopher: hi</p>`,
+			`<p><img src="https://cdn.example.com/gopher.png" alt=":gopher:" class="emoji" /> hi</p>`},
+		{"rejected URL", `<p>:evil:</p>`, `<p>:evil:</p>`},
+		{"falls back to unicode", `<p>:party:</p>`, `<p>🥳</p>`},
+		{"unknown", `<p>at 12:30:45 :nope:</p>`, `<p>at 12:30:45 :nope:</p>`},
+		{"in code", `<code>:tada:</code>`, `<code>:tada:</code>`},
+		{"plain text", `:tada::tada:`, `🎉🎉`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlsanitizer.Sanitize(tt.in, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+			q := p.Clone()
+			q.PreserveFormatting = true
+			if got, _ := htmlsanitizer.Sanitize(tt.in, q); got != tt.want {
+				t.Errorf("PreserveFormatting: got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize_CustomEmojiNeedsImages(t *testing.T) {
+	p := htmlsanitizer.StrictPolicy() // no images
+	p.CustomEmoji = map[string]string{"gopher": "https://cdn.example.com/gopher.png"}
+	got, err := htmlsanitizer.Sanitize(`:gopher:`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != ":gopher:" {
+		t.Errorf("got %q, want the shortcode as written", got)
+	}
+}
@@ -0,0 +1,95 @@
+package htmlsanitizer
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EntityMode selects how Policy.Entities writes characters in the
+// output.
+type EntityMode int
+
+const (
+	// EntitiesDefault writes text as UTF-8 and escapes only what HTML
+	// syntax requires, except that PreserveFormatting copies character
+	// references as the input wrote them.
+	EntitiesDefault EntityMode = iota
+
+	// EntitiesDecode writes every character as UTF-8 text, escaping
+	// only &, <, >, and, in attribute values, ", also under
+	// PreserveFormatting. It suits consumers that post-process the
+	// output as text, such as search indexing and translation.
+	EntitiesDecode
+
+	// EntitiesPreserve keeps character references as the input wrote
+	// them. Only PreserveFormatting copies text from the input, so with
+	// the tree-based entry points it is the same as EntitiesDecode.
+	EntitiesPreserve
+
+	// EntitiesNumeric writes every non-ASCII character as a decimal
+	// numeric character reference, and never copies named references
+	// from the input, so the output is plain ASCII that any XML parser
+	// accepts without the HTML entity table.
+	EntitiesNumeric
+)
+
+// copiesReferences reports whether PreserveFormatting may copy text
+// with its character references from the input.
+func (p *Policy) copiesReferences() bool {
+	return p.Entities == EntitiesDefault || p.Entities == EntitiesPreserve
+}
+
+// applyEntities rewrites out, serialized output, as p.Entities asks.
+// Only EntitiesNumeric changes already serialized output.
+func applyEntities(out string, p *Policy) string {
+	if p.Entities != EntitiesNumeric {
+		return out
+	}
+	return numericReferences(out)
+}
+
+// numericReferences replaces the non-ASCII characters of out, which
+// must be output of this package, with numeric character references
+// wherever they stand for text: outside tags and inside quoted
+// attribute values. Names, which cannot hold references, are left
+// alone.
+func numericReferences(out string) string {
+	i := 0
+	for i < len(out) && out[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(out) {
+		return out
+	}
+	var b strings.Builder
+	b.Grow(len(out) + len(out)/4)
+	inTag, inValue := false, false
+	last := 0
+	for i := 0; i < len(out); {
+		c := out[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '<' && !inTag:
+				inTag = true
+			case c == '"' && inTag:
+				inValue = !inValue
+			case c == '>' && inTag && !inValue:
+				inTag = false
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(out[i:])
+		if !inTag || inValue {
+			b.WriteString(out[last:i])
+			b.WriteString("&#")
+			b.WriteString(strconv.Itoa(int(r)))
+			b.WriteByte(';')
+			last = i + size
+		}
+		i += size
+	}
+	b.WriteString(out[last:])
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_Entities(t *testing.T) {
+	in := `<p title="café">caf&eacute; &copy; 2024 &lt;3 ☃</p>`
+	tests := []struct {
+		mode     htmlsanitizer.EntityMode
+		preserve bool
+		want     string
+	}{
+		{htmlsanitizer.EntitiesDefault, false, `<p title="café">café © 2024 &lt;3 ☃</p>`},
+		{htmlsanitizer.EntitiesDecode, false, `<p title="café">café © 2024 &lt;3 ☃</p>`},
+		{htmlsanitizer.EntitiesPreserve, false, `<p title="café">café © 2024 &lt;3 ☃</p>`},
+		{htmlsanitizer.EntitiesNumeric, false, `<p title="caf&#233;">caf&#233; &#169; 2024 &lt;3 &#9731;</p>`},
+
+		{htmlsanitizer.EntitiesDefault, true, `<p title="café">caf&eacute; &copy; 2024 &lt;3 ☃</p>`},
+		{htmlsanitizer.EntitiesDecode, true, `<p title="café">café © 2024 &lt;3 ☃</p>`},
+		{htmlsanitizer.EntitiesPreserve, true, `<p title="café">caf&eacute; &copy; 2024 &lt;3 ☃</p>`},
+		{htmlsanitizer.EntitiesNumeric, true, `<p title="caf&#233;">caf&#233; &#169; 2024 &lt;3 &#9731;</p>`},
+	}
+	for _, tt := range tests {
+		p := htmlsanitizer.DefaultPolicy()
+		p.AllowedAttributes["p"] = []string{"title"}
+		p.Entities = tt.mode
+		p.PreserveFormatting = tt.preserve
+		got, err := htmlsanitizer.Sanitize(in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("mode %d, PreserveFormatting %v:\ngot  %q\nwant %q", tt.mode, tt.preserve, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize_EntitiesNumericNames(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Entities = htmlsanitizer.EntitiesNumeric
+	p.AllowDataAttributes = true
+	p.AllowedAttributes["span"] = []string{"title"}
+	for in, want := range map[string]string{
+		`plain naïve text`:                 `plain na&#239;ve text`,
+		`<b data-ü="ü">ü</b>`:              `<b data-ü="&#252;">&#252;</b>`,
+		`<span title='a "ü" b'>x</span>`:   `<span title="a &#34;&#252;&#34; b">x</span>`,
+		`<blink title="é">x</blink> after`: `&lt;blink title="&#233;"&gt;x&lt;/blink&gt; after`,
+	} {
+		got, err := htmlsanitizer.Sanitize(in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// escapeText writes s to w, escaping the characters that are significant
+// in HTML text content. Quotes are left alone since they carry no meaning
+// outside of a tag.
+func escapeText(w *bytes.Buffer, s string) {
+	writeEscaped(w, s, false)
+}
+
+// escapeAttr writes s to w, escaping it for use inside a double-quoted
+// attribute value. Angle brackets are escaped as well to guard against
+// parsers that re-interpret attribute contents.
+func escapeAttr(w *bytes.Buffer, s string) {
+	writeEscaped(w, s, true)
+}
+
+// writeEscaped copies s into w in runs, only breaking a run when a byte
+// needs escaping. Strings without special characters are written with a
+// single call and no intermediate allocation.
+func writeEscaped(w *bytes.Buffer, s string, attr bool) {
+	last := 0
+	for i := 0; i < len(s); i++ {
+		var esc string
+		switch s[i] {
+		case '&':
+			esc = "&amp;"
+		case '<':
+			esc = "&lt;"
+		case '>':
+			esc = "&gt;"
+		case '"':
+			if !attr {
+				continue
+			}
+			esc = "&#34;"
+		default:
+			continue
+		}
+		w.WriteString(s[last:i])
+		w.WriteString(esc)
+		last = i + 1
+	}
+	w.WriteString(s[last:])
+}
+
+// escapedTag returns the text that stands in for a start or end tag of
+// a disallowed element in escape mode. Attribute values are quoted and
+// escaped, so the text reads as the tag it replaced even when a value
+// contains quotes or angle brackets.
+func (c *compiledPolicy) escapedTag(n *html.Node, closing bool) string {
+	if c.p.EscapedTagFormatter != nil {
+		return c.p.EscapedTagFormatter(n, closing)
+	}
+	tag := strings.ToLower(n.Data)
+	if closing {
+		return "</" + tag + ">"
+	}
+	if c.p.EscapeOmitAttributes || len(n.Attr) == 0 {
+		return "<" + tag + ">"
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('<')
+	buf.WriteString(tag)
+	for _, a := range n.Attr {
+		buf.WriteByte(' ')
+		buf.WriteString(a.Key)
+		buf.WriteString(`="`)
+		escapeAttr(&buf, a.Val)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+	return buf.String()
+}
@@ -0,0 +1,116 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestSanitize_TextEscaping(t *testing.T) {
+	input := `<p>Tom &amp; "Jerry" say 1 &lt; 2</p>`
+	got, err := htmlsanitizer.Sanitize(input, htmlsanitizer.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p>Tom &amp; "Jerry" say 1 &lt; 2</p>`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_AttrEscaping(t *testing.T) {
+	input := `<a href="/q?a=1&amp;b=2" title='say "hi" &lt;now&gt;'>x</a>`
+	got, err := htmlsanitizer.Sanitize(input, htmlsanitizer.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a href="/q?a=1&amp;b=2" title="say &#34;hi&#34; &lt;now&gt;">x</a>`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func BenchmarkSanitize_TextHeavy(b *testing.B) {
+	input := strings.Repeat(`<p>Plain prose with no markup at all, just words & the odd "quote".</p>`, 200)
+	p := htmlsanitizer.DefaultPolicy()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = htmlsanitizer.Sanitize(input, p)
+	}
+}
+
+func TestSanitize_EscapedTagAttributes(t *testing.T) {
+	p := &htmlsanitizer.Policy{AllowedTags: []string{"p"}}
+	input := `<div title='a"b<c>' onclick="x()">t</div>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `&lt;div title="a&amp;#34;b&amp;lt;c&amp;gt;" onclick="x()"&gt;t&lt;/div&gt;`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	p = p.Clone()
+	p.EscapeOmitAttributes = true
+	got, err = htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `&lt;div&gt;t&lt;/div&gt;`; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_EscapedTagFormatter(t *testing.T) {
+	p := &htmlsanitizer.Policy{
+		AllowedTags: []string{"p"},
+		EscapedTagFormatter: func(n *html.Node, closing bool) string {
+			if closing {
+				return "]"
+			}
+			return "[" + n.Data + ": "
+		},
+	}
+	got, err := htmlsanitizer.Sanitize(`<p><blink>hi</blink></p>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<p>[blink: hi]</p>`; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_RenderCompatible(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedAttributes["p"] = []string{"title"}
+	p.RenderCompatible = true
+	input := `<p title='a"b'>"quoted" &amp; it's<br></p>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p title="a&#34;b">&#34;quoted&#34; &amp; it&#39;s<br/></p>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// The output must survive a parse/render round trip unchanged.
+	nodes, err := html.ParseFragment(strings.NewReader(got), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	for _, n := range nodes {
+		if err := html.Render(&buf, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.String() != got {
+		t.Errorf("round trip changed output:\n%q\n%q", got, buf.String())
+	}
+}
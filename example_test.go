@@ -31,10 +31,10 @@ func ExampleStripTags() {
 
 func ExampleSanitize_customPolicy() {
 	p := &htmlsanitizer.Policy{
-		AllowedTags: []string{"b", "i"},
+		AllowedTags:       []string{"b", "i"},
 		AllowedAttributes: map[string][]string{},
-		AllowedSchemes:  []string{"https"},
-		StripDisallowed: true,
+		AllowedSchemes:    []string{"https"},
+		StripDisallowed:   true,
 	}
 	input := `<b>bold</b> <div>stripped</div>`
 	clean, err := htmlsanitizer.Sanitize(input, p)
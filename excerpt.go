@@ -0,0 +1,92 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockTags are the elements Excerpt counts as blocks.
+var blockTags = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "dl": true, "blockquote": true, "pre": true,
+	"table": true, "figure": true, "hr": true, "details": true, "address": true,
+	"div": true, "section": true, "article": true, "main": true,
+	"header": true, "footer": true, "aside": true, "nav": true,
+}
+
+// containerTags are block elements that Excerpt descends into when they
+// hold further blocks, so that a wrapping <article> does not count as
+// a single block.
+var containerTags = map[string]bool{
+	"div": true, "section": true, "article": true, "main": true,
+	"header": true, "footer": true, "aside": true, "nav": true,
+}
+
+// Excerpt sanitizes htmlStr with p and returns its first nBlocks
+// block-level elements, such as paragraphs, headings, and lists, with
+// their markup intact. Wrapping containers like <article> are kept but
+// do not count. Text and inline elements outside any block form an
+// implied paragraph, as browsers show them on lines of their own: the
+// run up to the next block counts as one block, so that
+// Excerpt("text first<p>a</p>", nil, 1) returns "text first". Comments
+// do not count. If p is nil, the default policy is used.
+func Excerpt(htmlStr string, p *Policy, nBlocks int) (string, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	c := compilePolicy(p)
+	root, err := sanitizeTree(context.Background(), strings.NewReader(htmlStr), c, nil)
+	if err != nil {
+		return "", err
+	}
+	remaining := max(nBlocks, 0)
+	excerptChildren(root, &remaining)
+	return renderRoot(root, c)
+}
+
+// excerptChildren keeps the children of parent that make up the next
+// *remaining blocks and removes the rest.
+func excerptChildren(parent *html.Node, remaining *int) {
+	inRun := false // inside a run of inline content
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+		keep := true
+		switch {
+		case c.Type == html.TextNode && strings.TrimSpace(c.Data) == "", c.Type == html.CommentNode:
+			keep = inRun || *remaining > 0
+		case c.Type == html.ElementNode && blockTags[c.Data]:
+			inRun = false
+			switch {
+			case *remaining == 0:
+				keep = false
+			case containerTags[c.Data] && hasBlockChild(c):
+				excerptChildren(c, remaining)
+			default:
+				*remaining--
+			}
+		default:
+			if !inRun {
+				keep = *remaining > 0
+				if keep {
+					*remaining--
+					inRun = true
+				}
+			}
+		}
+		if !keep {
+			parent.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockTags[c.Data] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{`<h1>T</h1><p>one <b>bold</b></p><p>two</p><p>three</p>`, 2,
+			`<h1>T</h1><p>one <b>bold</b></p>`},
+		{`<article><header><h1>T</h1></header><p>one</p><p>two</p></article>`, 2,
+			`<article><header><h1>T</h1></header><p>one</p></article>`},
+		{"loose <i>text</i>\n<p>para</p>\n<p>more</p>", 2,
+			"loose <i>text</i>\n<p>para</p>"},
+		{`<p>a</p><script>x</script><ul><li>b</li></ul>`, 5,
+			`<p>a</p><ul><li>b</li></ul>`},
+		{`text first<p>a</p>`, 1, `text first`},
+		{`text <b>and</b> more<p>a</p>tail`, 2, `text <b>and</b> more<p>a</p>`},
+		{`<p>a</p>`, 0, ``},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Excerpt(tt.in, nil, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s (%d):\ngot  %q\nwant %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
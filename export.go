@@ -0,0 +1,166 @@
+package htmlsanitizer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SanitizeHTMLConfig is the subset of the options of the sanitize-html
+// JavaScript package that ExportSanitizeHTMLConfig fills in. It
+// marshals to the option object sanitize-html expects.
+type SanitizeHTMLConfig struct {
+	AllowedTags           []string            `json:"allowedTags"`
+	AllowedAttributes     map[string][]string `json:"allowedAttributes"`
+	AllowedSchemes        []string            `json:"allowedSchemes"`
+	AllowProtocolRelative bool                `json:"allowProtocolRelative"`
+	DisallowedTagsMode    string              `json:"disallowedTagsMode"`
+	NonTextTags           []string            `json:"nonTextTags"`
+	NestingLimit          int                 `json:"nestingLimit,omitempty"`
+}
+
+// ExportDOMPurifyConfig returns the DOMPurify options closest to p, for
+// sanitizing on the client with the same rules before content is sent.
+// The export is best-effort: each rule of p that DOMPurify cannot
+// express is described in the returned warnings, and the server must
+// still sanitize with p. Marshal the result with encoding/json.
+func ExportDOMPurifyConfig(p *Policy) (*DOMPurifyConfig, []string) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	warnings := inexpressible(p, "DOMPurify")
+	attrs, perTag := flattenAttrs(p)
+	if len(perTag) > 0 {
+		warnings = append(warnings, fmt.Sprintf("DOMPurify allows attributes on every tag: the attributes allowed only on %s are allowed everywhere", strings.Join(perTag, ", ")))
+	}
+	if p.MaxDepth > 0 {
+		warnings = append(warnings, "DOMPurify has no MaxDepth")
+	}
+	action, actionWarnings := exportActions(p, "DOMPurify")
+	warnings = append(warnings, actionWarnings...)
+	if action == DisallowedEscape {
+		warnings = append(warnings, "DOMPurify cannot escape disallowed elements: they are unwrapped")
+	}
+	keep, data := action != DisallowedStrip, p.AllowDataAttributes
+	cfg := &DOMPurifyConfig{
+		AllowedTags:      lowerAll(p.AllowedTags),
+		AllowedAttr:      attrs,
+		ForbidContents:   lowerAll(p.dropContentTags()),
+		AllowDataAttr:    &data,
+		KeepContent:      &keep,
+		AllowedURIRegexp: schemeRegexp(p),
+	}
+	return cfg, warnings
+}
+
+// ExportSanitizeHTMLConfig returns the sanitize-html options closest to
+// p, with warnings for the rules sanitize-html cannot express, in the
+// same way as ExportDOMPurifyConfig.
+func ExportSanitizeHTMLConfig(p *Policy) (*SanitizeHTMLConfig, []string) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	warnings := inexpressible(p, "sanitize-html")
+	cfg := &SanitizeHTMLConfig{
+		AllowedTags:           lowerAll(p.AllowedTags),
+		AllowedAttributes:     make(map[string][]string, len(p.AllowedAttributes)),
+		AllowedSchemes:        lowerAll(p.AllowedSchemes),
+		AllowProtocolRelative: !p.RejectSchemeRelativeURLs,
+		DisallowedTagsMode:    "discard",
+		NonTextTags:           lowerAll(p.dropContentTags()),
+		NestingLimit:          p.MaxDepth,
+	}
+	for tag, list := range p.AllowedAttributes {
+		cfg.AllowedAttributes[strings.ToLower(tag)] = lowerAll(list)
+	}
+	if p.AllowDataAttributes {
+		cfg.AllowedAttributes["*"] = append(cfg.AllowedAttributes["*"], "data-*")
+	}
+	action, actionWarnings := exportActions(p, "sanitize-html")
+	warnings = append(warnings, actionWarnings...)
+	switch action {
+	case DisallowedEscape:
+		cfg.DisallowedTagsMode = "escape"
+	case DisallowedStrip:
+		warnings = append(warnings, "sanitize-html keeps the text of disallowed elements other than nonTextTags")
+	}
+	return cfg, warnings
+}
+
+// exportActions returns what p does with disallowed elements in
+// general, which is all the JavaScript sanitizers can be configured
+// with, and warnings for the tags that p treats differently.
+func exportActions(p *Policy, lib string) (DisallowedAction, []string) {
+	def := compilePolicy(p).disallowedAction("*")
+	if def == DisallowedCode {
+		def = DisallowedUnwrap
+	}
+	var tags []string
+	for tag, action := range p.DisallowedActions {
+		if tag != "*" && action != DisallowedDefault && action != def {
+			tags = append(tags, strings.ToLower(tag))
+		}
+	}
+	if len(tags) == 0 {
+		return def, nil
+	}
+	sort.Strings(tags)
+	return def, []string{fmt.Sprintf("%s has no per-tag DisallowedActions: %s", lib, strings.Join(tags, ", "))}
+}
+
+// flattenAttrs returns every attribute p allows on any tag, sorted, and
+// the tags that have attributes of their own.
+func flattenAttrs(p *Policy) (attrs, perTag []string) {
+	seen := make(map[string]bool)
+	for tag, list := range p.AllowedAttributes {
+		if tag != "*" && len(list) > 0 {
+			perTag = append(perTag, strings.ToLower(tag))
+		}
+		for _, a := range list {
+			seen[strings.ToLower(a)] = true
+		}
+	}
+	for a := range seen {
+		attrs = append(attrs, a)
+	}
+	sort.Strings(attrs)
+	sort.Strings(perTag)
+	return attrs, perTag
+}
+
+// schemeRegexp returns a DOMPurify ALLOWED_URI_REGEXP accepting the
+// schemes p allows and, as DOMPurify's default does, relative URLs.
+func schemeRegexp(p *Policy) string {
+	schemes := make([]string, len(p.AllowedSchemes))
+	for i, s := range p.AllowedSchemes {
+		schemes[i] = regexp.QuoteMeta(strings.ToLower(s))
+	}
+	return `^(?:(?:` + strings.Join(schemes, "|") + `):|[^a-z]|[a-z+.\-]+(?:[^a-z+.\-:]|$))`
+}
+
+// inexpressible describes the settings of p that no JavaScript
+// sanitizer configuration can carry: hooks written in Go and the
+// package's own checks.
+func inexpressible(p *Policy, lib string) []string {
+	var warnings []string
+	add := func(set bool, what string) {
+		if set {
+			warnings = append(warnings, fmt.Sprintf("%s cannot express %s", lib, what))
+		}
+	}
+	add(len(p.Transformers)+len(p.PreFilterTransformers)+len(p.PostTransformers) > 0, "Transformers")
+	add(len(p.AttrTransformers) > 0, "AttrTransformers")
+	add(len(p.AttrDefaults) > 0, "AttrDefaults")
+	add(p.URLPolicy != nil, "URLPolicy")
+	add(p.RejectURLUserinfo || p.RejectIPHosts || len(p.AllowedURLPorts) > 0 || p.IDNHosts != IDNPreserve, "URL host rules")
+	add(p.MaxURLLength > 0 || p.MaxURLEncodedRatio > 0 || p.RejectNestedURLEncoding, "URL length and encoding limits")
+	add(p.ValidateMailto, "ValidateMailto")
+	add(p.Linkify || p.Mentions != nil || p.Hashtags != nil, "linkifying")
+	add(len(p.ContextRules) > 0 || len(p.AllowedParents) > 0 || p.EnforceContentModel, "context rules")
+	add(p.NestedInteractive != InteractiveKeep, "NestedInteractive")
+	add(p.MaxImageWidth > 0 || p.MaxImageHeight > 0, "image size limits")
+	add(p.ValidateLang, "ValidateLang")
+	add(p.Namespace != "", "Namespace")
+	return warnings
+}
@@ -0,0 +1,87 @@
+package htmlsanitizer_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestExportDOMPurifyConfig_RoundTrip(t *testing.T) {
+	p := &htmlsanitizer.Policy{
+		AllowedTags:       []string{"p", "a", "b"},
+		AllowedAttributes: map[string][]string{"*": {"title"}, "a": {"href"}},
+		AllowedSchemes:    []string{"https", "mailto"},
+		DisallowedActions: map[string]htmlsanitizer.DisallowedAction{"*": htmlsanitizer.DisallowedUnwrap},
+	}
+	cfg, warnings := htmlsanitizer.ExportDOMPurifyConfig(p)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "only on a are allowed everywhere") {
+		t.Errorf("warnings = %q", warnings)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"ALLOWED_ATTR":["href","title"]`) {
+		t.Errorf("config = %s", data)
+	}
+	back, err := htmlsanitizer.ParseDOMPurifyConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range []string{
+		`<p title="t"><a href="https://go.dev">go</a> <i>x</i></p>`,
+		`<a href="javascript:alert(1)">x</a><a href="/rel">r</a><a href="mailto:a@b.test">m</a>`,
+		`<script>alert(1)</script><b>ok</b>`,
+	} {
+		want, _ := htmlsanitizer.Sanitize(in, p)
+		got, _ := htmlsanitizer.Sanitize(in, back)
+		if got != want {
+			t.Errorf("%s: round trip gives %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExportDOMPurifyConfig_Warnings(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	p.MaxDepth = 4
+	p.DisallowedActions = map[string]htmlsanitizer.DisallowedAction{"font": htmlsanitizer.DisallowedUnwrap}
+	cfg, warnings := htmlsanitizer.ExportDOMPurifyConfig(p)
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"cannot express linkifying", "no MaxDepth", "per-tag DisallowedActions: font", "cannot escape"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings missing %q:\n%s", want, joined)
+		}
+	}
+	if !*cfg.KeepContent {
+		t.Error("KEEP_CONTENT = false for an escaping policy")
+	}
+}
+
+func TestExportSanitizeHTMLConfig(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowDataAttributes = true
+	p.MaxDepth = 6
+	cfg, warnings := htmlsanitizer.ExportSanitizeHTMLConfig(p)
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %q", warnings)
+	}
+	if cfg.DisallowedTagsMode != "escape" || cfg.NestingLimit != 6 || !cfg.AllowProtocolRelative {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if got := cfg.AllowedAttributes["*"]; got[len(got)-1] != "data-*" {
+		t.Errorf("* attributes = %q", got)
+	}
+	if p.AllowedAttributes["*"][len(p.AllowedAttributes["*"])-1] == "data-*" {
+		t.Error("export modified the policy")
+	}
+
+	p = p.Clone()
+	p.StripDisallowed = true
+	cfg, warnings = htmlsanitizer.ExportSanitizeHTMLConfig(p)
+	if cfg.DisallowedTagsMode != "discard" || len(warnings) != 1 {
+		t.Errorf("strip: mode %q, warnings %q", cfg.DisallowedTagsMode, warnings)
+	}
+}
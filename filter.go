@@ -0,0 +1,210 @@
+package htmlsanitizer
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// FilterAction is the outcome of a ContentFilter check. Where several
+// checks apply to the same content, the greatest action wins.
+type FilterAction int
+
+const (
+	// FilterPass keeps the content.
+	FilterPass FilterAction = iota
+
+	// FilterHold keeps the content but sets Report.Held, so that the
+	// caller can queue it for review instead of publishing it.
+	FilterHold
+
+	// FilterDrop removes the content: a link to a blocked host loses
+	// its link but keeps its text, any other element referring to one,
+	// such as an image, is removed, and blocked text is removed.
+	FilterDrop
+)
+
+// ContentFilter inspects the sanitized content before it is serialized,
+// so that spam and abuse lists can drop links or hold content for
+// review in the same pass. CheckHost is called with the lower-case host
+// of every absolute URL kept in href, src, action, and xlink:href
+// attributes, and CheckText with the content of every text node.
+// Implementations must be safe for concurrent use.
+type ContentFilter interface {
+	CheckHost(host string) FilterAction
+	CheckText(text string) FilterAction
+}
+
+// Blocklist is a list of domains and phrases for NewBlocklistFilter,
+// together with the action taken on content that matches it.
+type Blocklist struct {
+	// Domains block hosts equal to, or subdomains of, one of them,
+	// ignoring case: "example.com" matches "spam.example.com".
+	Domains []string
+
+	// Phrases block text that contains one of them as whole words,
+	// ignoring case and differences in whitespace: "buy now" matches
+	// "Buy\n now!" but not "buy nowhere".
+	Phrases []string
+
+	// Action is taken on a match. FilterPass turns the list off.
+	Action FilterAction
+}
+
+// NewBlocklistFilter returns a ContentFilter that matches hosts and text
+// against lists, taking the greatest action of the lists that match.
+func NewBlocklistFilter(lists ...Blocklist) ContentFilter {
+	b := &blocklistFilter{domains: make(map[string]FilterAction)}
+	for _, l := range lists {
+		for _, d := range l.Domains {
+			d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), ".")
+			if d != "" {
+				b.domains[d] = max(b.domains[d], l.Action)
+			}
+		}
+		for _, ph := range l.Phrases {
+			if ph = normalizeSnippet(ph); ph != "" {
+				b.phrases = append(b.phrases, blockedPhrase{ph, l.Action})
+			}
+		}
+	}
+	return b
+}
+
+// blocklistFilter is the ContentFilter returned by NewBlocklistFilter.
+type blocklistFilter struct {
+	domains map[string]FilterAction
+	phrases []blockedPhrase
+}
+
+// blockedPhrase is a normalized phrase and the action for it.
+type blockedPhrase struct {
+	text   string
+	action FilterAction
+}
+
+// CheckHost returns the greatest action for host and its parent
+// domains.
+func (b *blocklistFilter) CheckHost(host string) FilterAction {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	action := FilterPass
+	for host != "" {
+		action = max(action, b.domains[host])
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return action
+}
+
+// CheckText returns the greatest action for the phrases found in text.
+func (b *blocklistFilter) CheckText(text string) FilterAction {
+	if len(b.phrases) == 0 {
+		return FilterPass
+	}
+	text = normalizeSnippet(text)
+	action := FilterPass
+	for _, ph := range b.phrases {
+		if ph.action > action && containsWords(text, ph.text) {
+			action = ph.action
+		}
+	}
+	return action
+}
+
+// normalizeSnippet lower-cases s and collapses its runs of whitespace
+// into single spaces.
+func normalizeSnippet(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// containsWords reports whether text contains phrase, not counting
+// occurrences that begin or end inside a word.
+func containsWords(text, phrase string) bool {
+	for from := 0; ; {
+		i := strings.Index(text[from:], phrase)
+		if i < 0 {
+			return false
+		}
+		start, end := from+i, from+i+len(phrase)
+		first, _ := utf8.DecodeRuneInString(phrase)
+		last, _ := utf8.DecodeLastRuneInString(phrase)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !(isWordRune(first) && start > 0 && isWordRune(before)) &&
+			!(isWordRune(last) && end < len(text) && isWordRune(after)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		from = start + size
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// filterContent applies Policy.ContentFilter to the children of n and
+// their descendants, recording holds and removed elements in rep if
+// it is non-nil.
+func (c *compiledPolicy) filterContent(n *html.Node, rep *Report) {
+	f := c.p.ContentFilter
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		var action FilterAction
+		switch child.Type {
+		case html.TextNode:
+			action = f.CheckText(child.Data)
+		case html.ElementNode:
+			action = checkHosts(f, child)
+		}
+		switch action {
+		case FilterHold:
+			if rep != nil {
+				rep.Held = true
+			}
+		case FilterDrop:
+			if child.Type == html.ElementNode {
+				if rep != nil {
+					rep.RemovedElements++
+				}
+				if child.Data == "a" && child.Namespace == "" {
+					// Its children are filtered in turn.
+					child = unwrap(child)
+					continue
+				}
+			}
+			n.RemoveChild(child)
+			child = next
+			continue
+		}
+		if child.Type == html.ElementNode {
+			c.filterContent(child, rep)
+		}
+		child = next
+	}
+}
+
+// checkHosts returns the greatest action f takes for the hosts in the
+// URL attributes of n.
+func checkHosts(f ContentFilter, n *html.Node) FilterAction {
+	action := FilterPass
+	for _, a := range n.Attr {
+		if !isURLAttr(strings.ToLower(a.Key)) {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(a.Val))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		if host := strings.ToLower(u.Hostname()); host != "" {
+			action = max(action, f.CheckHost(host))
+		}
+	}
+	return action
+}
@@ -0,0 +1,66 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestContentFilter_Blocklist(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.ContentFilter = htmlsanitizer.NewBlocklistFilter(
+		htmlsanitizer.Blocklist{Domains: []string{"spam.example"}, Action: htmlsanitizer.FilterDrop},
+		htmlsanitizer.Blocklist{Phrases: []string{"cheap pills"}, Action: htmlsanitizer.FilterDrop},
+		htmlsanitizer.Blocklist{Phrases: []string{"crypto"}, Action: htmlsanitizer.FilterHold},
+	)
+
+	tests := []struct {
+		name, in, want string
+		held           bool
+	}{
+		{"clean", `<p>hello <a href="https://example.org/">there</a></p>`,
+			`<p>hello <a href="https://example.org/">there</a></p>`, false},
+		{"blocked link", `<p>see <a href="https://WWW.Spam.Example/x">this <b>offer</b></a></p>`,
+			`<p>see this <b>offer</b></p>`, false},
+		{"blocked image", `<p>a<img src="http://cdn.spam.example/a.png" alt="">b</p>`,
+			`<p>ab</p>`, false},
+		{"relative URL", `<a href="/spam.example">x</a>`, `<a href="/spam.example">x</a>`, false},
+		{"blocked phrase", `<p>Cheap   Pills!</p><p>ok</p>`, `<p></p><p>ok</p>`, false},
+		{"inside a word", `<p>cheap pillsbury</p>`, `<p>cheap pillsbury</p>`, false},
+		{"held", `<p>Buy CRYPTO now</p>`, `<p>Buy CRYPTO now</p>`, true},
+		{"plain text", `crypto`, `crypto`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rep, err := htmlsanitizer.SanitizeWithReport(tt.in, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+			if rep.Held != tt.held {
+				t.Errorf("Held = %v, want %v", rep.Held, tt.held)
+			}
+		})
+	}
+}
+
+func TestNewBlocklistFilter_GreatestActionWins(t *testing.T) {
+	f := htmlsanitizer.NewBlocklistFilter(
+		htmlsanitizer.Blocklist{Domains: []string{"example.com"}, Action: htmlsanitizer.FilterHold},
+		htmlsanitizer.Blocklist{Domains: []string{"bad.example.com"}, Action: htmlsanitizer.FilterDrop},
+		htmlsanitizer.Blocklist{Domains: []string{"off.example"}},
+	)
+	for host, want := range map[string]htmlsanitizer.FilterAction{
+		"example.com":        htmlsanitizer.FilterHold,
+		"www.example.com":    htmlsanitizer.FilterHold,
+		"x.bad.example.com.": htmlsanitizer.FilterDrop,
+		"notexample.com":     htmlsanitizer.FilterPass,
+		"off.example":        htmlsanitizer.FilterPass,
+	} {
+		if got := f.CheckHost(host); got != want {
+			t.Errorf("CheckHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
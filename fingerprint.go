@@ -0,0 +1,194 @@
+package htmlsanitizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Fingerprint returns a stable hash of the effective configuration of
+// p. Two policies with the same Version that sanitize identically
+// produce the same fingerprint regardless of the order or case in which
+// their tags, attributes, and schemes were listed, so stored content
+// can record the fingerprint and be re-sanitized once it changes.
+//
+// Transformers, URLPolicy, ContentFilter, and the link hooks are code
+// and cannot be inspected; only their presence contributes to the
+// fingerprint. Version is hashed as well, so changing it when their
+// behaviour changes changes the fingerprint too.
+func (p *Policy) Fingerprint() string {
+	if p == nil {
+		p = nilPolicy()
+	}
+	f := fingerprinter{h: sha256.New()}
+	f.strings("tags", p.AllowedTags)
+	attrs := make(map[string][]string, len(p.AllowedAttributes))
+	for k, list := range p.AllowedAttributes {
+		k = strings.ToLower(k)
+		attrs[k] = append(attrs[k], list...)
+	}
+	attrKeys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		attrKeys = append(attrKeys, k)
+	}
+	sort.Strings(attrKeys)
+	for _, k := range attrKeys {
+		f.strings("attrs:"+k, attrs[k])
+	}
+	f.field("dataattrs", p.AllowDataAttributes)
+	f.field("preserveattrcase", p.PreserveAttributeCase)
+	f.field("normalizeattrs", p.NormalizeAttributes)
+	lengths := make([]string, 0, len(p.MaxAttributeLengths))
+	for k, v := range p.MaxAttributeLengths {
+		lengths = append(lengths, fmt.Sprintf("%s:%d", strings.ToLower(k), v))
+	}
+	sort.Strings(lengths)
+	f.field("maxattrlengths", strings.Join(lengths, ","))
+	f.strings("schemes", p.AllowedSchemes)
+	f.field("urlpolicy", p.URLPolicy != nil)
+	f.field("schemerelative", p.schemeRelativeScheme())
+	f.field("rejectschemerelative", p.RejectSchemeRelativeURLs)
+	f.field("idnhosts", p.IDNHosts)
+	f.field("rejectuserinfo", p.RejectURLUserinfo)
+	f.field("anyport", p.AllowedURLPorts == nil)
+	ports := slices.Clone(p.AllowedURLPorts)
+	slices.Sort(ports)
+	f.field("ports", slices.Compact(ports))
+	f.field("rejectiphosts", p.RejectIPHosts)
+	f.field("maxurllength", p.MaxURLLength)
+	f.field("truncateurls", p.TruncateLongURLs)
+	f.field("maxurlencoded", p.MaxURLEncodedRatio)
+	f.field("rejectnestedencoding", p.RejectNestedURLEncoding)
+	f.field("validatemailto", p.ValidateMailto)
+	f.field("maxmailtosubject", p.MaxMailtoSubjectLength)
+	f.field("maxmailtobody", p.MaxMailtoBodyLength)
+	f.field("stripmailtocopies", p.StripMailtoCopies)
+	f.field("strip", p.StripDisallowed)
+	f.actions("disallowed", p.DisallowedActions)
+	f.strings("dropcontent", p.dropContentTags())
+	f.actions("rawtext", p.rawTextActions())
+	f.field("escapeomitattrs", p.EscapeOmitAttributes)
+	f.field("escapeformatter", p.EscapedTagFormatter != nil)
+	f.field("transformers", len(p.Transformers))
+	f.field("prefiltertransformers", len(p.PreFilterTransformers))
+	f.field("posttransformers", len(p.PostTransformers))
+	f.field("warn", p.Warn != nil)
+	f.field("contentfilter", p.ContentFilter != nil)
+	f.field("attrtransformers", len(p.AttrTransformers))
+	f.field("texttransformers", len(p.TextTransformers))
+	f.field("version", p.Version)
+	f.field("validatelang", p.ValidateLang)
+	f.field("maximagewidth", p.MaxImageWidth)
+	f.field("maximageheight", p.MaxImageHeight)
+	for _, d := range p.AttrDefaults {
+		f.field("attrdefault", fmt.Sprintf("%s|%s|%s|%s|%t", strings.ToLower(d.Tag),
+			strings.ToLower(d.Attr), d.Value, strings.ToLower(d.From), d.Force))
+	}
+	f.field("linkify", p.Linkify)
+	if p.LinkifyPattern != nil {
+		f.field("linkifypattern", p.LinkifyPattern.String())
+	}
+	f.strings("linkifytlds", p.LinkifyTLDs)
+	f.strings("linkifyexclude", p.linkifyExclude())
+	f.field("linkifytext", p.LinkifyText != nil)
+	f.field("linkifymedia", p.LinkifyMedia)
+	f.strings("linkifyimageexts", p.linkifyImageExtensions())
+	f.strings("linkifyvideoexts", p.linkifyVideoExtensions())
+	f.strings("linkifymediahosts", p.LinkifyMediaHosts)
+	f.field("mentions", p.Mentions != nil)
+	f.field("hashtags", p.Hashtags != nil)
+	f.stringMap("emoji", p.Emoji)
+	f.stringMap("customemoji", p.CustomEmoji)
+	f.field("maxdepth", p.MaxDepth)
+	f.field("maxnesting", p.maxNestingDepth())
+	f.field("namespace", p.Namespace)
+	f.field("nestedinteractive", p.NestedInteractive)
+	f.field("contentmodel", p.EnforceContentModel)
+	f.field("maxtablecolumns", p.MaxTableColumns)
+	f.field("maxtablecells", p.MaxTableCells)
+	f.field("tablecontainer", strings.ToLower(p.TableContainer))
+	f.field("tablecontainerclass", p.TableContainerClass)
+	f.field("normalizequotes", p.NormalizeQuotes)
+	f.field("galleryminimages", p.GalleryMinImages)
+	f.field("modernizetags", p.ModernizeTags)
+	f.field("convertlegacyattributes", p.ConvertLegacyAttributes)
+	f.stringMap("legacyclasses", p.LegacyClasses)
+	contextKeys := make([]string, 0, len(p.ContextRules))
+	for k := range p.ContextRules {
+		contextKeys = append(contextKeys, k)
+	}
+	sort.Strings(contextKeys)
+	for _, k := range contextKeys {
+		f.strings("context:"+strings.ToLower(k)+":children", p.ContextRules[k].Children)
+		f.strings("context:"+strings.ToLower(k)+":deny", p.ContextRules[k].Deny)
+	}
+	parentKeys := make([]string, 0, len(p.AllowedParents))
+	for k := range p.AllowedParents {
+		parentKeys = append(parentKeys, k)
+	}
+	sort.Strings(parentKeys)
+	for _, k := range parentKeys {
+		f.strings("parents:"+strings.ToLower(k), p.AllowedParents[k])
+	}
+	f.field("maxoutput", p.MaxOutputLength)
+	f.field("maxinput", p.MaxInputSize)
+	f.field("maxnodes", p.MaxNodes)
+	f.field("marker", p.EmbedMarker)
+	f.field("preserveformatting", p.PreserveFormatting)
+	f.field("entities", p.Entities)
+	f.strings("voidelements", p.VoidElements)
+	f.field("emptyelements", p.EmptyElements)
+	f.field("strictparse", p.StrictParse)
+	f.field("xhtml", p.XHTML)
+	f.field("verify", p.Verify)
+	f.field("rendercompatible", p.RenderCompatible)
+	f.field("sortattrs", p.SortAttributes)
+	if p.SortAttributes {
+		f.field("attrorder", strings.ToLower(strings.Join(p.AttributeOrder, ",")))
+	}
+	return hex.EncodeToString(f.h.Sum(nil))
+}
+
+// fingerprinter writes policy fields to a hash in a canonical form.
+type fingerprinter struct {
+	h hash.Hash
+}
+
+func (f fingerprinter) field(name string, v any) {
+	fmt.Fprintf(f.h, "%s=%v\n", name, v)
+}
+
+// actions writes a map of per-tag actions in sorted order.
+func (f fingerprinter) actions(name string, m map[string]DisallowedAction) {
+	keys := make([]string, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, fmt.Sprintf("%s:%d", strings.ToLower(k), v))
+	}
+	sort.Strings(keys)
+	f.field(name, strings.Join(keys, ","))
+}
+
+// stringMap writes a map of strings in sorted order.
+func (f fingerprinter) stringMap(name string, m map[string]string) {
+	entries := make([]string, 0, len(m))
+	for k, v := range m {
+		entries = append(entries, fmt.Sprintf("%q:%q", k, v))
+	}
+	sort.Strings(entries)
+	f.field(name, strings.Join(entries, ","))
+}
+
+// strings writes a set-valued field: case-folded, deduplicated, sorted.
+func (f fingerprinter) strings(name string, list []string) {
+	set := sliceToSet(list)
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	f.field(name, strings.Join(keys, ","))
+}
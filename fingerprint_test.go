@@ -0,0 +1,63 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestPolicyFingerprint_Stable(t *testing.T) {
+	a := &htmlsanitizer.Policy{
+		AllowedTags:       []string{"b", "i", "a"},
+		AllowedAttributes: map[string][]string{"a": {"href", "title"}},
+		AllowedSchemes:    []string{"https", "http"},
+	}
+	b := &htmlsanitizer.Policy{
+		AllowedTags:       []string{"A", "i", "b", "b"},
+		AllowedAttributes: map[string][]string{"a": {"title", "href"}},
+		AllowedSchemes:    []string{"http", "https"},
+	}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("equivalent policies should share a fingerprint")
+	}
+	b.Version = "v2"
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Errorf("changing Version should change the fingerprint")
+	}
+	b.StripDisallowed = true
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Errorf("changing StripDisallowed should change the fingerprint")
+	}
+}
+
+func TestPolicyFingerprint_Ports(t *testing.T) {
+	anyPort := &htmlsanitizer.Policy{}
+	defaultPorts := &htmlsanitizer.Policy{AllowedURLPorts: []int{}}
+	if anyPort.Fingerprint() == defaultPorts.Fingerprint() {
+		t.Errorf("nil and empty AllowedURLPorts should fingerprint differently")
+	}
+	a := &htmlsanitizer.Policy{AllowedURLPorts: []int{8443, 8080}}
+	b := &htmlsanitizer.Policy{AllowedURLPorts: []int{8080, 8443, 8080}}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("the order of AllowedURLPorts should not change the fingerprint")
+	}
+}
+
+func TestSanitizeWithReport(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Version = "2024-06"
+	input := `<p onclick="x()">hi</p><script>bad()</script><a href="javascript:x">a</a>`
+	got, rep, err := htmlsanitizer.SanitizeWithReport(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `<p>hi</p><a>a</a>` {
+		t.Errorf("unexpected output %q", got)
+	}
+	if rep.PolicyVersion != "2024-06" || rep.PolicyFingerprint != p.Fingerprint() {
+		t.Errorf("report does not identify the policy: %+v", rep)
+	}
+	if rep.RemovedElements != 1 || rep.RemovedAttributes != 2 {
+		t.Errorf("unexpected counts: %+v", rep)
+	}
+}
@@ -0,0 +1,144 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Footnotes returns a post-transformer, for Policy.PostTransformers,
+// that keeps footnote references and back-references consistent.
+// Footnote anchors are recognized by the doc-noteref and doc-backlink
+// roles or the footnote-ref and footnote-backref classes that common
+// Markdown renderers emit.
+//
+// A reference is kept only if its fragment names an element in the
+// same document, and a back-reference only if its fragment names an
+// element that links to the footnote containing it. Dangling anchors
+// are removed, together with a <sup> left empty. The ids involved and
+// the fragments pointing at them are then given prefix, such as
+// "user-content-", so that they cannot clobber ids of the page the
+// content is embedded in.
+func Footnotes(prefix string) func(root *html.Node) {
+	return func(root *html.Node) {
+		ids := make(map[string]*html.Node)
+		var refs, backrefs []*html.Node
+		walkElements(root, func(n *html.Node) {
+			if id := GetAttr(n, "id"); id != "" && ids[id] == nil {
+				ids[id] = n
+			}
+			if n.Data != "a" {
+				return
+			}
+			switch footnoteKind(n) {
+			case "ref":
+				refs = append(refs, n)
+			case "backref":
+				backrefs = append(backrefs, n)
+			}
+		})
+
+		targets := make(map[string]bool)
+		var keep []*html.Node
+		for _, a := range refs {
+			id, ok := fragment(a)
+			if !ok || ids[id] == nil {
+				removeFootnoteAnchor(a)
+				continue
+			}
+			targets[id] = true
+			keep = append(keep, a)
+		}
+		for _, a := range backrefs {
+			id, ok := fragment(a)
+			if !ok || ids[id] == nil || !linksTo(ids[id], a, ids) {
+				removeFootnoteAnchor(a)
+				continue
+			}
+			targets[id] = true
+			keep = append(keep, a)
+		}
+
+		for id := range targets {
+			if !strings.HasPrefix(id, prefix) {
+				SetAttr(ids[id], "id", prefix+id)
+			}
+		}
+		for _, a := range keep {
+			id, _ := fragment(a)
+			if !strings.HasPrefix(id, prefix) {
+				SetAttr(a, "href", "#"+prefix+id)
+			}
+		}
+	}
+}
+
+// footnoteKind classifies an anchor as a footnote "ref", a "backref",
+// or neither.
+func footnoteKind(a *html.Node) string {
+	switch GetAttr(a, "role") {
+	case "doc-noteref":
+		return "ref"
+	case "doc-backlink":
+		return "backref"
+	}
+	for _, class := range strings.Fields(GetAttr(a, "class")) {
+		switch strings.ToLower(class) {
+		case "footnote-ref":
+			return "ref"
+		case "footnote-backref", "footnote-back":
+			return "backref"
+		}
+	}
+	return ""
+}
+
+// fragment returns the id an in-document link points at.
+func fragment(a *html.Node) (string, bool) {
+	id, ok := strings.CutPrefix(GetAttr(a, "href"), "#")
+	return id, ok && id != ""
+}
+
+// linksTo reports whether target, or an anchor inside it, links to an
+// element containing backref.
+func linksTo(target, backref *html.Node, ids map[string]*html.Node) bool {
+	found := false
+	check := func(n *html.Node) {
+		if id, ok := fragment(n); ok && n.Data == "a" && ids[id] != nil && contains(ids[id], backref) {
+			found = true
+		}
+	}
+	check(target)
+	walkElements(target, check)
+	return found
+}
+
+// contains reports whether n is an ancestor of, or is, d.
+func contains(n, d *html.Node) bool {
+	for ; d != nil; d = d.Parent {
+		if d == n {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFootnoteAnchor removes a, and its parent if that is a <sup>
+// with nothing else in it.
+func removeFootnoteAnchor(a *html.Node) {
+	parent := a.Parent
+	parent.RemoveChild(a)
+	if parent.Type == html.ElementNode && parent.Data == "sup" && parent.FirstChild == nil && parent.Parent != nil {
+		parent.Parent.RemoveChild(parent)
+	}
+}
+
+// walkElements calls fn for every element below n, in document order.
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+		}
+		walkElements(c, fn)
+	}
+}
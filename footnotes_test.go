@@ -0,0 +1,42 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestFootnotes(t *testing.T) {
+	p := htmlsanitizer.DocsPolicy()
+	p.PostTransformers = []func(*html.Node){htmlsanitizer.Footnotes("user-content-")}
+	input := `<p>A<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>` +
+		` B<sup id="fnref:2"><a href="#fn:2" class="footnote-ref">2</a></sup></p>` +
+		`<ol><li id="fn:1">One <a href="#fnref:1" class="footnote-backref" role="doc-backlink">↩</a>` +
+		` <a href="#top" class="footnote-backref">↩</a></li>` +
+		`<li id="fn:3">Three <a href="#fnref:1" class="footnote-backref">↩</a></li></ol>`
+	want := `<p>A<sup id="user-content-fnref:1"><a href="#user-content-fn:1" class="footnote-ref" role="doc-noteref">1</a></sup> B</p>` +
+		`<ol><li id="user-content-fn:1">One <a href="#user-content-fnref:1" class="footnote-backref" role="doc-backlink">↩</a> </li>` +
+		`<li id="fn:3">Three </li></ol>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestFootnotes_AlreadyPrefixed(t *testing.T) {
+	p := htmlsanitizer.DocsPolicy()
+	p.PostTransformers = []func(*html.Node){htmlsanitizer.Footnotes("fn-")}
+	input := `<p><a id="fn-r1" href="#fn-1" class="footnote-ref">1</a></p><p id="fn-1">x <a href="#fn-r1" class="footnote-backref">↩</a></p>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != input {
+		t.Errorf("got  %q\nwant %q", got, input)
+	}
+}
@@ -0,0 +1,106 @@
+package htmlsanitizer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// PayloadKind classifies a Payload.
+type PayloadKind string
+
+const (
+	PayloadEventHandler PayloadKind = "event-handler" // an on* attribute
+	PayloadScript       PayloadKind = "script"        // the source of a <script>
+	PayloadURL          PayloadKind = "url"           // a URL that failed validation
+)
+
+// Defaults for Policy.MaxPayloadSize and Policy.MaxPayloads.
+const (
+	DefaultMaxPayloadSize = 1 << 10
+	DefaultMaxPayloads    = 100
+)
+
+// Payload is potentially hostile content removed from the input,
+// recorded when Policy.Forensics is set.
+type Payload struct {
+	Kind PayloadKind
+
+	// Element is the tag of the element it was found on or in.
+	Element string
+
+	// Attribute is the attribute it was found in, if any.
+	Attribute string
+
+	// Content is the payload, cut to Policy.MaxPayloadSize bytes.
+	Content string
+
+	// Truncated reports whether Content was cut short.
+	Truncated bool
+}
+
+// recordPayload adds a payload to rep if forensics are on and the
+// report has room for it.
+func (c *compiledPolicy) recordPayload(rep *Report, kind PayloadKind, element, attr, content string) {
+	p := c.p
+	if rep == nil || !p.Forensics {
+		return
+	}
+	if len(rep.Payloads) >= p.maxPayloads() {
+		rep.PayloadsDropped++
+		return
+	}
+	size := p.MaxPayloadSize
+	if size <= 0 {
+		size = DefaultMaxPayloadSize
+	}
+	pl := Payload{Kind: kind, Element: element, Attribute: attr, Content: content}
+	if len(content) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		pl.Content, pl.Truncated = content[:cut], true
+	}
+	rep.Payloads = append(rep.Payloads, pl)
+}
+
+// maxPayloads returns the effective MaxPayloads.
+func (p *Policy) maxPayloads() int {
+	if p.MaxPayloads <= 0 {
+		return DefaultMaxPayloads
+	}
+	return p.MaxPayloads
+}
+
+// isEventHandler reports whether key names an event handler attribute.
+func isEventHandler(key string) bool {
+	return len(key) > 2 && strings.HasPrefix(strings.ToLower(key), "on")
+}
+
+// recordRemoved records the payloads of a removed element: its event
+// handlers, its source if it is a script, and, if strip is set, those
+// of its descendants, which are removed with it.
+func (c *compiledPolicy) recordRemoved(rep *Report, n *html.Node, strip bool) {
+	if rep == nil || !c.p.Forensics {
+		return
+	}
+	tag := strings.ToLower(n.Data)
+	for _, a := range n.Attr {
+		if isEventHandler(a.Key) {
+			c.recordPayload(rep, PayloadEventHandler, tag, a.Key, a.Val)
+		}
+	}
+	if tag == "script" {
+		c.recordPayload(rep, PayloadScript, tag, "", textContent(n))
+		return
+	}
+	if strip {
+		for d := n.FirstChild; d != nil; d = d.NextSibling {
+			if d.Type == html.ElementNode {
+				c.recordRemoved(rep, d, true)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestForensics(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Forensics = true
+	p.MaxPayloadSize = 8
+	input := `<p onclick="steal()">x</p><script>alert(document.cookie)</script>` +
+		`<a href="javascript:go()">y</a><object><svg onload="z()"></svg></object>`
+	_, rep, err := htmlsanitizer.SanitizeWithReport(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []htmlsanitizer.Payload{
+		{Kind: htmlsanitizer.PayloadEventHandler, Element: "p", Attribute: "onclick", Content: "steal()"},
+		{Kind: htmlsanitizer.PayloadScript, Element: "script", Content: "alert(do", Truncated: true},
+		{Kind: htmlsanitizer.PayloadURL, Element: "a", Attribute: "href", Content: "javascri", Truncated: true},
+		{Kind: htmlsanitizer.PayloadEventHandler, Element: "svg", Attribute: "onload", Content: "z()"},
+	}
+	if len(rep.Payloads) != len(want) {
+		t.Fatalf("got %d payloads: %+v", len(rep.Payloads), rep.Payloads)
+	}
+	for i := range want {
+		if rep.Payloads[i] != want[i] {
+			t.Errorf("payload %d = %+v, want %+v", i, rep.Payloads[i], want[i])
+		}
+	}
+}
+
+func TestForensics_Limits(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Forensics = true
+	p.MaxPayloads = 2
+	_, rep, err := htmlsanitizer.SanitizeWithReport(strings.Repeat(`<b onmouseover="x()">é</b>`, 5), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Payloads) != 2 || rep.PayloadsDropped != 3 {
+		t.Errorf("got %d payloads, %d dropped", len(rep.Payloads), rep.PayloadsDropped)
+	}
+
+	p.Forensics = false
+	_, rep, err = htmlsanitizer.SanitizeWithReport(`<b onclick="x()">x</b>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Payloads) != 0 {
+		t.Errorf("payloads recorded with Forensics off: %+v", rep.Payloads)
+	}
+}
@@ -0,0 +1,38 @@
+package htmlsanitizer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Clone returns a deep copy of p that can be modified without affecting
+// p or anything p is in use by. Its slices and maps are copied;
+// functions and values held by reference, such as Transformers,
+// URLPolicy, and Logger, are shared. Clone of a nil policy returns nil.
+func (p *Policy) Clone() *Policy {
+	if p == nil {
+		return nil
+	}
+	return clonePolicy(p)
+}
+
+// frozen records the fingerprint of every policy at its first use, in
+// builds where checkPolicies is set.
+var frozen sync.Map // *Policy -> string
+
+// checkFrozen panics if p was modified since it was first used to
+// sanitize. A policy is shared by every goroutine sanitizing with it, so
+// a modification is a data race and may be applied to half a document;
+// the check reports it where it happens rather than as corrupted
+// output. It runs only in builds with the race detector or the
+// htmlsanitizer_checkpolicy build tag, as it fingerprints the policy on
+// every use.
+func checkFrozen(p *Policy) {
+	if !checkPolicies {
+		return
+	}
+	fp := p.Fingerprint()
+	if first, loaded := frozen.LoadOrStore(p, fp); loaded && first != fp {
+		panic(fmt.Sprintf("htmlsanitizer: Policy %p modified after first use; use Clone to derive a changed policy", p))
+	}
+}
@@ -0,0 +1,25 @@
+//go:build race || htmlsanitizer_checkpolicy
+
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestPolicy_ModifiedAfterUse(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	if _, err := htmlsanitizer.Sanitize(`<b>x</b>`, p); err != nil {
+		t.Fatal(err)
+	}
+	p.AllowedTags = append(p.AllowedTags, "blink")
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "modified after first use") {
+			t.Errorf("recovered %q, want a panic about the modification", msg)
+		}
+	}()
+	htmlsanitizer.Sanitize(`<b>x</b>`, p)
+}
@@ -0,0 +1,5 @@
+//go:build !race && !htmlsanitizer_checkpolicy
+
+package htmlsanitizer
+
+const checkPolicies = false
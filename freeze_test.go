@@ -0,0 +1,29 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestPolicy_Clone(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	if _, err := htmlsanitizer.Sanitize(`<b>x</b>`, p); err != nil {
+		t.Fatal(err)
+	}
+	want := p.Fingerprint()
+
+	q := p.Clone()
+	if q.Fingerprint() != want {
+		t.Fatal("clone sanitizes differently")
+	}
+	q.AllowedTags[0] = "blink"
+	q.AllowedAttributes["a"] = append(q.AllowedAttributes["a"], "onclick")
+	q.AllowedSchemes = append(q.AllowedSchemes, "javascript")
+	if p.Fingerprint() != want {
+		t.Error("modifying the clone changed the original")
+	}
+	if (*htmlsanitizer.Policy)(nil).Clone() != nil {
+		t.Error("Clone of nil is not nil")
+	}
+}
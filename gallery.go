@@ -0,0 +1,126 @@
+package htmlsanitizer
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// groupGalleries applies Policy.GalleryMinImages to root. It does
+// nothing unless the policy allows figure.
+func (c *compiledPolicy) groupGalleries(root *html.Node) {
+	if !c.allowedTags["figure"] {
+		return
+	}
+	var parents []*html.Node
+	seen := make(map[*html.Node]bool)
+	walkElements(root, func(n *html.Node) {
+		parent := n.Parent
+		if !isGalleryItem(n) || seen[parent] || parent.Data == "figure" || parent.Data == "picture" {
+			return
+		}
+		seen[parent] = true
+		parents = append(parents, parent)
+	})
+	for _, n := range parents {
+		c.groupImages(n)
+	}
+}
+
+// groupImages wraps the runs of images among the children of n in
+// gallery figures. A paragraph holding nothing but a run is replaced
+// by the figure, which a paragraph cannot contain; other paragraphs
+// are left alone.
+func (c *compiledPolicy) groupImages(n *html.Node) {
+	minImages := max(c.p.GalleryMinImages, 2)
+	paragraph := n.Type == html.ElementNode && n.Data == "p" && n.Namespace == ""
+	for child := n.FirstChild; child != nil; {
+		if !isGalleryItem(child) {
+			child = child.NextSibling
+			continue
+		}
+		// The run takes in the whitespace and breaks between images.
+		end, count := child, 1
+		for s := child.NextSibling; s != nil; s = s.NextSibling {
+			if isGalleryItem(s) {
+				end = s
+				count++
+			} else if !isSpaceOrBreak(s) {
+				break
+			}
+		}
+		next := end.NextSibling
+		whole := onlySpaceOrBreaks(n.FirstChild, child) && onlySpaceOrBreaks(next, nil)
+		if count < minImages || paragraph && !whole {
+			child = next
+			continue
+		}
+		fig := c.galleryFigure(count)
+		for s := child; s != next; {
+			following := s.NextSibling
+			n.RemoveChild(s)
+			if isGalleryItem(s) {
+				fig.AppendChild(s)
+			}
+			s = following
+		}
+		if paragraph {
+			n.Parent.InsertBefore(fig, n)
+			n.Parent.RemoveChild(n)
+			return
+		}
+		n.InsertBefore(fig, next)
+		child = next
+	}
+}
+
+// galleryFigure returns an empty gallery figure for count images, with
+// the class and data attribute the policy allows on figure.
+func (c *compiledPolicy) galleryFigure(count int) *html.Node {
+	fig := &html.Node{Type: html.ElementNode, Data: "figure", DataAtom: atom.Figure}
+	if c.attrAllowed("class", "figure") {
+		fig.Attr = append(fig.Attr, html.Attribute{Key: "class", Val: withPrefix(c.p.Namespace, "gallery")})
+	}
+	if c.attrAllowed("data-gallery-size", "figure") {
+		fig.Attr = append(fig.Attr, html.Attribute{Key: "data-gallery-size", Val: strconv.Itoa(count)})
+	}
+	return fig
+}
+
+// isGalleryItem reports whether n is an image, or a link holding
+// nothing but an image.
+func isGalleryItem(n *html.Node) bool {
+	if isImage(n) {
+		return true
+	}
+	if n.Type != html.ElementNode || n.Data != "a" || n.Namespace != "" {
+		return false
+	}
+	img := firstElementChild(n)
+	return img != nil && isImage(img) && onlySpace(n.FirstChild, img) && onlySpace(img.NextSibling, nil)
+}
+
+func isImage(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.Data == "img" && n.Namespace == ""
+}
+
+// isSpaceOrBreak reports whether n is whitespace text or a <br>.
+func isSpaceOrBreak(n *html.Node) bool {
+	if n.Type == html.TextNode {
+		return strings.TrimSpace(n.Data) == ""
+	}
+	return n.Type == html.ElementNode && n.Data == "br" && n.Namespace == ""
+}
+
+// onlySpaceOrBreaks reports whether the siblings from n up to end are
+// all whitespace text or <br>.
+func onlySpaceOrBreaks(n, end *html.Node) bool {
+	for s := n; s != end; s = s.NextSibling {
+		if !isSpaceOrBreak(s) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestGalleryMinImages(t *testing.T) {
+	p := htmlsanitizer.EditorialPolicy()
+	tests := []struct {
+		name, in, want string
+	}{
+		{"paragraph of images",
+			"<p><img src=\"/a.png\"><br>\n<img src=\"/b.png\"></p>",
+			`<figure class="gallery" data-gallery-size="2"><img src="/a.png" /><img src="/b.png" /></figure>`},
+		{"linked images in a div",
+			`<div>Intro <a href="/a"><img src="/a.png"></a> <img src="/b.png"> <img src="/c.png"> outro</div>`,
+			`<div>Intro <figure class="gallery" data-gallery-size="3"><a href="/a"><img src="/a.png" /></a><img src="/b.png" /><img src="/c.png" /></figure> outro</div>`},
+		{"single image", `<p><img src="/a.png"></p>`, `<p><img src="/a.png" /></p>`},
+		{"paragraph with text", `<p>See <img src="/a.png"><img src="/b.png"></p>`, `<p>See <img src="/a.png" /><img src="/b.png" /></p>`},
+		{"already a figure", `<figure><img src="/a.png"><img src="/b.png"></figure>`, `<figure><img src="/a.png" /><img src="/b.png" /></figure>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlsanitizer.Sanitize(tt.in, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGalleryMinImages_PolicyAttributes(t *testing.T) {
+	p := htmlsanitizer.StrictPolicy()
+	p.AllowedTags = append(p.AllowedTags, "img", "figure")
+	p.AllowedAttributes["img"] = []string{"src"}
+	p.GalleryMinImages = 3
+	got, err := htmlsanitizer.Sanitize(`<img src="https://example.com/a.png"><img src="https://example.com/b.png">`+
+		`<img src="https://example.com/c.png">`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<figure><img src="https://example.com/a.png" /><img src="https://example.com/b.png" /><img src="https://example.com/c.png" /></figure>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/njchilds90/htmlsanitizer
 
 go 1.21
 
-require golang.org/x/net v0.24.0
\ No newline at end of file
+require (
+	golang.org/x/net v0.24.0
+	golang.org/x/text v0.14.0
+)
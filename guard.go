@@ -0,0 +1,122 @@
+package htmlsanitizer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html"
+)
+
+// ErrTooManyNodes is the error an InputLimitError for MaxNodes wraps.
+var ErrTooManyNodes = errors.New("htmlsanitizer: input exceeds MaxNodes")
+
+// InputLimitError reports input rejected by Policy.MaxInputSize or
+// Policy.MaxNodes. It wraps ErrInputTooLarge or ErrTooManyNodes.
+type InputLimitError struct {
+	Limit string // "MaxInputSize" or "MaxNodes"
+	Max   int    // the value of the limit
+	Read  int64  // input bytes read when sanitizing stopped
+}
+
+func (e *InputLimitError) Error() string {
+	return fmt.Sprintf("htmlsanitizer: input exceeds %s (%d) after %d bytes", e.Limit, e.Max, e.Read)
+}
+
+func (e *InputLimitError) Unwrap() error {
+	if e.Limit == "MaxNodes" {
+		return ErrTooManyNodes
+	}
+	return ErrInputTooLarge
+}
+
+// guardReader enforces MaxInputSize and MaxNodes while the parser
+// reads, so hostile input is rejected before the tree grows. Nodes are
+// estimated from the tags and text runs seen.
+type guardReader struct {
+	r        io.Reader
+	p        *Policy
+	read     int64
+	nodes    int
+	prev     byte // last byte read
+	afterTag bool // a tag has just ended; the next byte may start text
+}
+
+// guardInput wraps r in a guardReader if p sets input limits.
+func guardInput(r io.Reader, p *Policy) io.Reader {
+	if p.MaxInputSize <= 0 && p.MaxNodes <= 0 {
+		return r
+	}
+	return &guardReader{r: r, p: p, afterTag: true}
+}
+
+func (g *guardReader) Read(b []byte) (int, error) {
+	n, err := g.r.Read(b)
+	g.read += int64(n)
+	if max := g.p.MaxInputSize; max > 0 && g.read > int64(max) {
+		return 0, &InputLimitError{Limit: "MaxInputSize", Max: max, Read: g.read}
+	}
+	if g.p.MaxNodes > 0 {
+		for _, c := range b[:n] {
+			switch {
+			case g.prev == '<' && isASCIILetter(c):
+				g.nodes++
+			case c == '>':
+				g.afterTag = true
+			case g.afterTag && c != '<':
+				g.nodes++ // a text node starts
+				g.afterTag = false
+			case g.afterTag:
+				g.afterTag = false
+			}
+			g.prev = c
+		}
+		if g.nodes > g.p.MaxNodes {
+			return 0, &InputLimitError{Limit: "MaxNodes", Max: g.p.MaxNodes, Read: g.read}
+		}
+	}
+	return n, err
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// bytesRead returns the bytes read through r if it is a guardReader.
+func bytesRead(r io.Reader) int64 {
+	if g, ok := r.(*guardReader); ok {
+		return g.read
+	}
+	return 0
+}
+
+// checkNodeCount counts the nodes of a parsed document, including those
+// the parser added while repairing the markup, against MaxNodes.
+func checkNodeCount(doc *html.Node, p *Policy, read int64) error {
+	if p.MaxNodes <= 0 {
+		return nil
+	}
+	// The walk does not recurse: the parsed tree has not been through
+	// the MaxNestingDepth limit yet.
+	count := 0
+	for n := nextNode(doc, doc); n != nil; n = nextNode(n, doc) {
+		if count++; count > p.MaxNodes {
+			return &InputLimitError{Limit: "MaxNodes", Max: p.MaxNodes, Read: read}
+		}
+	}
+	return nil
+}
+
+// nextNode returns the node after n in document order among the
+// descendants of root, or nil once they are exhausted.
+func nextNode(n, root *html.Node) *html.Node {
+	if n.FirstChild != nil {
+		return n.FirstChild
+	}
+	for ; n != root; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestMaxInputSize(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxInputSize = 10 << 10
+	if _, err := htmlsanitizer.Sanitize(strings.Repeat("a", 10<<10), p); err != nil {
+		t.Fatalf("input at the limit: %v", err)
+	}
+	_, err := htmlsanitizer.SanitizeReader(strings.NewReader(strings.Repeat("a", 1<<20)), p)
+	var lerr *htmlsanitizer.InputLimitError
+	if !errors.As(err, &lerr) || !errors.Is(err, htmlsanitizer.ErrInputTooLarge) {
+		t.Fatalf("err = %v", err)
+	}
+	if lerr.Limit != "MaxInputSize" || lerr.Read > 64<<10 {
+		t.Errorf("got %+v; reading should stop early", lerr)
+	}
+
+	p = p.Clone()
+	p.PreserveFormatting = true
+	if _, err := htmlsanitizer.Sanitize(strings.Repeat("a", 20<<10), p); !errors.Is(err, htmlsanitizer.ErrInputTooLarge) {
+		t.Errorf("PreserveFormatting: err = %v", err)
+	}
+}
+
+func TestMaxNodes(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxNodes = 100
+	if _, err := htmlsanitizer.Sanitize(strings.Repeat("<b>x</b>", 40), p); err != nil {
+		t.Fatalf("small input: %v", err)
+	}
+	_, err := htmlsanitizer.Sanitize(strings.Repeat("<b>x</b>", 10000), p)
+	var lerr *htmlsanitizer.InputLimitError
+	if !errors.As(err, &lerr) || !errors.Is(err, htmlsanitizer.ErrTooManyNodes) || lerr.Read >= int64(len("<b>x</b>")*10000) {
+		t.Errorf("err = %v", err)
+	}
+
+	// Nodes created by the parser's repairs count too: text in every
+	// new paragraph reopens the four formatting elements.
+	_, err = htmlsanitizer.Sanitize("<p><b><i><u><s>"+strings.Repeat("<p>x", 30), p)
+	if !errors.Is(err, htmlsanitizer.ErrTooManyNodes) {
+		t.Errorf("repaired input: err = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package htmlsanitizer
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// IDNMode selects how internationalized host names in URLs are handled.
+type IDNMode int
+
+const (
+	// IDNPreserve leaves hosts as written.
+	IDNPreserve IDNMode = iota
+
+	// IDNPunycode validates hosts and rewrites them to their ASCII form,
+	// e.g. bücher.example becomes xn--bcher-kva.example.
+	IDNPunycode
+
+	// IDNUnicode validates hosts and rewrites them to their Unicode
+	// display form, e.g. xn--bcher-kva.example becomes bücher.example.
+	IDNUnicode
+)
+
+// idnProfile maps hosts the way browsers do before a lookup, folding
+// case and full-width characters. Its own label checks would reject
+// underscores, which occur in real host names, so validHostName checks
+// the result instead.
+var idnProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.StrictDomainName(false),
+)
+
+// normalizeHost validates the host of u and rewrites it according to
+// mode. IP literals are left alone. It reports false if the host is not
+// a valid host name.
+func normalizeHost(u *url.URL, mode IDNMode) bool {
+	host := u.Hostname()
+	if host == "" || net.ParseIP(host) != nil {
+		return true
+	}
+	ascii, err := idnProfile.ToASCII(host)
+	if err != nil || !validHostName(ascii) {
+		return false
+	}
+	host = ascii
+	if mode == IDNUnicode {
+		if host, err = idnProfile.ToUnicode(ascii); err != nil {
+			return false
+		}
+	}
+	if port := u.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	u.Host = host
+	return true
+}
+
+// validHostName reports whether host, in ASCII form, is a host name:
+// dot-separated labels of 1 to 63 letters, digits, hyphens, and
+// underscores that neither start nor end with a hyphen, with at most a
+// trailing dot after the last one.
+func validHostName(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			switch c := label[i]; {
+			case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_':
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// withHost returns u serialized with its host written literally rather
+// than percent-encoded, so that Unicode hosts stay readable. If
+// schemeRelative is set the scheme is omitted.
+func withHost(u *url.URL, schemeRelative bool) string {
+	var b strings.Builder
+	if !schemeRelative {
+		b.WriteString(u.Scheme)
+		b.WriteByte(':')
+	}
+	b.WriteString("//")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(u.Host)
+	rest := *u
+	rest.Scheme, rest.User, rest.Host = "", nil, ""
+	b.WriteString(rest.String())
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package htmlsanitizer_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_IDNHosts(t *testing.T) {
+	tests := []struct {
+		mode htmlsanitizer.IDNMode
+		in   string
+		want string
+	}{
+		{htmlsanitizer.IDNPunycode, `<a href="https://BÜCHER.example/a?b=1">x</a>`,
+			`<a href="https://xn--bcher-kva.example/a?b=1">x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="//bücher.example:8080/">x</a>`,
+			`<a href="//xn--bcher-kva.example:8080/">x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="https://example.com/">x</a>`,
+			`<a href="https://example.com/">x</a>`},
+		{htmlsanitizer.IDNUnicode, `<a href="https://xn--bcher-kva.example/">x</a>`,
+			`<a href="https://bücher.example/">x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="https://exa mple.com/">x</a>`,
+			`<a>x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="/relative">x</a>`,
+			`<a href="/relative">x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="https://my_host.example/">x</a>`,
+			`<a href="https://my_host.example/">x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="https://bad_host!.com/">x</a>`, `<a>x</a>`},
+		{htmlsanitizer.IDNUnicode, `<a href="https://bad_host!.com/">x</a>`, `<a>x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="https://a..b/">x</a>`, `<a>x</a>`},
+		{htmlsanitizer.IDNUnicode, `<a href="https://a..b/">x</a>`, `<a>x</a>`},
+		{htmlsanitizer.IDNPunycode, `<a href="https://-a.example/">x</a>`, `<a>x</a>`},
+	}
+	for _, tt := range tests {
+		p := htmlsanitizer.DefaultPolicy()
+		p.IDNHosts = tt.mode
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize_IDNHostsURLPolicy(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.IDNHosts = htmlsanitizer.IDNPunycode
+	p.RejectIPHosts = true
+	var seen string
+	p.URLPolicy = htmlsanitizer.URLPolicyFunc(func(tag, attr string, u *url.URL) htmlsanitizer.Decision {
+		seen = u.Host
+		return htmlsanitizer.Defer
+	})
+	hrefKept(t, p, "https://bücher.example/")
+	if seen != "xn--bcher-kva.example" {
+		t.Errorf("URLPolicy saw host %q", seen)
+	}
+	// Full-width digits map to an IP address.
+	if hrefKept(t, p, "http://１２７.０.０.１/") {
+		t.Error("full-width IP host kept")
+	}
+}
@@ -0,0 +1,92 @@
+package htmlsanitizer
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// clampImage limits the width and height attributes of an <img> to
+// MaxImageWidth and MaxImageHeight. When both dimensions are declared,
+// the other one is scaled to keep the aspect ratio. Dimensions that are
+// not whole numbers of pixels are dropped, since they cannot be checked.
+func (c *compiledPolicy) clampImage(tag string, attrs []html.Attribute) []html.Attribute {
+	p := c.p
+	if tag != "img" || (p.MaxImageWidth <= 0 && p.MaxImageHeight <= 0) {
+		return attrs
+	}
+	wi, hi := attrIndex(attrs, "width"), attrIndex(attrs, "height")
+	w, h := -1, -1
+	if wi >= 0 {
+		w = parsePixels(attrs[wi].Val)
+	}
+	if hi >= 0 {
+		h = parsePixels(attrs[hi].Val)
+	}
+	if p.MaxImageWidth > 0 && w > p.MaxImageWidth {
+		if h > 0 {
+			h = max(1, h*p.MaxImageWidth/w)
+		}
+		w = p.MaxImageWidth
+	}
+	if p.MaxImageHeight > 0 && h > p.MaxImageHeight {
+		if w > 0 {
+			w = max(1, w*p.MaxImageHeight/h)
+		}
+		h = p.MaxImageHeight
+	}
+
+	out := attrs[:0]
+	for i, a := range attrs {
+		switch i {
+		case wi:
+			if w < 0 {
+				continue
+			}
+			a.Val = strconv.Itoa(w)
+		case hi:
+			if h < 0 {
+				continue
+			}
+			a.Val = strconv.Itoa(h)
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// parsePixels parses a dimension attribute such as "640" or "640px".
+// It returns -1 for anything else.
+func parsePixels(s string) int {
+	s = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "px")
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || strings.HasPrefix(s, "+") {
+		return -1
+	}
+	return n
+}
+
+// LazyLoadingDefaults returns AttrDefaults that force loading="lazy" on
+// images and iframes and decoding="async" on images, overriding any
+// value in the input. Combine them with DropHighFetchPriority so user
+// content cannot compete with the page for bandwidth:
+//
+//	p.AttrDefaults = append(p.AttrDefaults, htmlsanitizer.LazyLoadingDefaults()...)
+//	p.AttrTransformers = append(p.AttrTransformers, htmlsanitizer.DropHighFetchPriority)
+func LazyLoadingDefaults() []AttrDefault {
+	return []AttrDefault{
+		{Tag: "img", Attr: "loading", Value: "lazy", Force: true},
+		{Tag: "iframe", Attr: "loading", Value: "lazy", Force: true},
+		{Tag: "img", Attr: "decoding", Value: "async", Force: true},
+	}
+}
+
+// DropHighFetchPriority is an AttrTransformer that removes
+// fetchpriority="high" from every element.
+func DropHighFetchPriority(tag, key, val string) (string, bool) {
+	if key == "fetchpriority" && strings.EqualFold(strings.TrimSpace(val), "high") {
+		return "", false
+	}
+	return val, true
+}
@@ -0,0 +1,59 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_ClampImageDimensions(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxImageWidth = 800
+	p.MaxImageHeight = 600
+	tests := []struct{ in, want string }{
+		{`<img src="/a.png" width="400" height="300">`, `<img src="/a.png" width="400" height="300" />`},
+		{`<img src="/a.png" width="10000" height="5000">`, `<img src="/a.png" width="800" height="400" />`},
+		{`<img src="/a.png" width="1000" height="2000">`, `<img src="/a.png" width="300" height="600" />`},
+		{`<img src="/a.png" width="1600px">`, `<img src="/a.png" width="800" />`},
+		{`<img src="/a.png" width="100%" height="9999">`, `<img src="/a.png" height="600" />`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+		if got := sanitizeTokens(t, tt.in, p); got != tt.want {
+			t.Errorf("tokens %s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize_LazyLoading(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, "iframe")
+	p.DropContentTags = []string{"script", "style"}
+	p.AllowedAttributes["img"] = append(p.AllowedAttributes["img"], "fetchpriority")
+	p.AllowedAttributes["iframe"] = []string{"src", "loading"}
+	p.AttrDefaults = htmlsanitizer.LazyLoadingDefaults()
+	p.AttrTransformers = []htmlsanitizer.AttrTransformer{htmlsanitizer.DropHighFetchPriority}
+	tests := []struct{ in, want string }{
+		{`<img src="/a.png" loading="eager" fetchpriority="high">`,
+			`<img src="/a.png" loading="lazy" decoding="async" />`},
+		{`<img src="/a.png" fetchpriority="low">`,
+			`<img src="/a.png" fetchpriority="low" loading="lazy" decoding="async" />`},
+		{`<iframe src="https://example.com/"></iframe>`,
+			`<iframe src="https://example.com/" loading="lazy"></iframe>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// InteractiveAction selects how Policy.NestedInteractive resolves an
+// interactive element inside another one.
+type InteractiveAction int
+
+const (
+	// InteractiveKeep leaves nested interactive elements alone.
+	InteractiveKeep InteractiveAction = iota
+
+	// InteractiveUnwrap removes the tags of the inner element and
+	// keeps its children, so <a href=1>x <a href=2>y</a></a> becomes
+	// <a href=1>x y</a>.
+	InteractiveUnwrap
+
+	// InteractiveSplit moves the inner element out, ending the outer
+	// element before it and resuming it after, so
+	// <a href=1>x <a href=2>y</a> z</a> becomes
+	// <a href=1>x </a><a href=2>y</a><a href=1> z</a>.
+	InteractiveSplit
+)
+
+// isInteractive reports whether n is interactive content.
+func isInteractive(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+	switch n.Data {
+	case "a":
+		return hasAttr(n, "href")
+	case "button", "details", "embed", "iframe", "label", "select", "textarea":
+		return true
+	case "input":
+		return !strings.EqualFold(GetAttr(n, "type"), "hidden")
+	case "audio", "video":
+		return hasAttr(n, "controls")
+	}
+	return false
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveNestedInteractive applies action to every interactive element
+// below root that has an interactive ancestor.
+func resolveNestedInteractive(root *html.Node, action InteractiveAction) {
+	for {
+		outer, inner := findNestedInteractive(root, nil)
+		if inner == nil {
+			return
+		}
+		if action == InteractiveSplit {
+			splitAround(outer, inner)
+		} else {
+			unwrap(inner)
+		}
+	}
+}
+
+// findNestedInteractive returns the first interactive element below n,
+// in document order, that lies inside another, together with its
+// outermost interactive ancestor. outer is that ancestor for n, if
+// any.
+func findNestedInteractive(n, outer *html.Node) (*html.Node, *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isInteractive(c) {
+			if outer != nil {
+				return outer, c
+			}
+			if o, i := findNestedInteractive(c, c); i != nil {
+				return o, i
+			}
+			continue
+		}
+		if o, i := findNestedInteractive(c, outer); i != nil {
+			return o, i
+		}
+	}
+	return nil, nil
+}
+
+// splitAround moves inner, a descendant of outer, out to follow outer.
+// The elements between them are ended before inner and continued, as
+// shallow copies without ids, after it. Copies and originals left
+// without content are removed.
+func splitAround(outer, inner *html.Node) {
+	var rest *html.Node
+	cur := inner
+	for anc := inner.Parent; ; anc = anc.Parent {
+		clone := &html.Node{Type: html.ElementNode, Data: anc.Data, DataAtom: anc.DataAtom}
+		for _, a := range anc.Attr {
+			if !strings.EqualFold(a.Key, "id") {
+				clone.Attr = append(clone.Attr, a)
+			}
+		}
+		if rest != nil {
+			clone.AppendChild(rest)
+		}
+		for c := cur.NextSibling; c != nil; c = cur.NextSibling {
+			anc.RemoveChild(c)
+			clone.AppendChild(c)
+		}
+		rest, cur = clone, anc
+		if anc == outer {
+			break
+		}
+	}
+	parent := inner.Parent
+	parent.RemoveChild(inner)
+	for parent != outer && parent.FirstChild == nil {
+		empty := parent
+		parent = parent.Parent
+		parent.RemoveChild(empty)
+	}
+	outer.Parent.InsertBefore(inner, outer.NextSibling)
+	if hasContent(rest) {
+		outer.Parent.InsertBefore(rest, inner.NextSibling)
+	}
+	if !hasContent(outer) {
+		outer.Parent.RemoveChild(outer)
+	}
+}
+
+// hasContent reports whether n holds any text other than whitespace or
+// any element without children, such as an image.
+func hasContent(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode && strings.TrimSpace(c.Data) != "":
+			return true
+		case c.Type == html.ElementNode && (c.FirstChild == nil || hasContent(c)):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestNestedInteractive(t *testing.T) {
+	input := `<p><a href="/1" id="x" class="c">see <b>https://go.dev now</b></a></p><a href="/2"><button>b</button></a>`
+	tests := []struct {
+		action htmlsanitizer.InteractiveAction
+		want   string
+	}{
+		{htmlsanitizer.InteractiveKeep,
+			`<p><a href="/1" id="x" class="c">see <b><a href="https://go.dev" rel="noopener noreferrer">https://go.dev</a> now</b></a></p><a href="/2"><button>b</button></a>`},
+		{htmlsanitizer.InteractiveUnwrap,
+			`<p><a href="/1" id="x" class="c">see <b>https://go.dev now</b></a></p><a href="/2">b</a>`},
+		{htmlsanitizer.InteractiveSplit,
+			`<p><a href="/1" id="x" class="c">see </a><a href="https://go.dev" rel="noopener noreferrer">https://go.dev</a><a href="/1" class="c"><b> now</b></a></p><button>b</button>`},
+	}
+	for _, tt := range tests {
+		p := htmlsanitizer.DefaultPolicy()
+		p.AllowedTags = append(p.AllowedTags, "button")
+		p.Linkify = true
+		p.LinkifyExclude = []string{}
+		p.NestedInteractive = tt.action
+		got, err := htmlsanitizer.Sanitize(input, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("action %d:\n got  %q\n want %q", tt.action, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxInterned bounds the names one walk remembers, so that input full
+// of distinct made-up names cannot grow the table without limit.
+const maxInterned = 256
+
+// names interns the lower-cased tag and attribute names seen during a
+// single walk. Names that are HTML atoms resolve to the atom's constant
+// string; any other name is lower-cased once and the copy is shared by
+// every later occurrence, so large tables and lists of custom elements
+// or data attributes do not allocate a string per element. The zero
+// value is ready to use.
+type names struct {
+	m map[string]string
+}
+
+// lower returns the interned lower-case form of s.
+func (t *names) lower(s string) string {
+	if v, ok := t.m[s]; ok {
+		return v
+	}
+	v := strings.ToLower(s)
+	if a := atom.Lookup([]byte(v)); a != 0 {
+		v = a.String()
+	}
+	t.remember(s, v)
+	return v
+}
+
+// bytes returns the interned string for name, a lower-case name slice
+// from the tokenizer. Looking up a []byte converted to string does not
+// allocate, so a name already seen costs nothing.
+func (t *names) bytes(name []byte) string {
+	if a := atom.Lookup(name); a != 0 {
+		return a.String()
+	}
+	if v, ok := t.m[string(name)]; ok {
+		return v
+	}
+	v := string(name)
+	t.remember(v, v)
+	return v
+}
+
+func (t *names) remember(k, v string) {
+	if t.m == nil {
+		t.m = make(map[string]string)
+	}
+	if len(t.m) < maxInterned {
+		t.m[k] = v
+	}
+}
+
+// token returns z's current token, of type tt, like z.Token but with
+// the tag name and attribute keys interned.
+func (t *names) token(z *html.Tokenizer, tt html.TokenType) html.Token {
+	tok := html.Token{Type: tt}
+	switch tt {
+	case html.TextToken, html.CommentToken, html.DoctypeToken:
+		tok.Data = string(z.Text())
+	case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+		name, more := z.TagName()
+		for more {
+			var key, val []byte
+			key, val, more = z.TagAttr()
+			tok.Attr = append(tok.Attr, html.Attribute{Key: t.bytes(key), Val: string(val)})
+		}
+		tok.DataAtom = atom.Lookup(name)
+		tok.Data = t.bytes(name)
+	}
+	return tok
+}
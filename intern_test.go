@@ -0,0 +1,77 @@
+package htmlsanitizer_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+// customInput is a large table whose cells use an element and
+// attributes that are not HTML atoms.
+var customInput = "<table><tbody>" + strings.Repeat(`<tr data-row="1"><td data-col="a"><x-cell data-v="1">1</x-cell></td><td data-col="b">2</td></tr>`, 200) + "</tbody></table>"
+
+func customPolicy() *htmlsanitizer.Policy {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, "table", "tbody", "tr", "td", "x-cell")
+	p.AllowedAttributes["tr"] = []string{"data-row"}
+	p.AllowedAttributes["td"] = []string{"data-col"}
+	p.AllowedAttributes["x-cell"] = []string{"data-v"}
+	return p
+}
+
+func TestInternedNames(t *testing.T) {
+	p := customPolicy()
+	want, err := htmlsanitizer.Sanitize(customInput, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(customInput))
+	if err := htmlsanitizer.SanitizeTokens(z, p, htmlsanitizer.TokenWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("token output differs from tree output:\n got  %.200q\n want %.200q", got, want)
+	}
+}
+
+func TestTokenSanitizer_MixedCaseNames(t *testing.T) {
+	s := htmlsanitizer.NewTokenSanitizer(customPolicy())
+	var buf bytes.Buffer
+	emit := htmlsanitizer.TokenWriter(&buf)
+	for _, tok := range []html.Token{
+		{Type: html.StartTagToken, Data: "X-Cell"},
+		{Type: html.TextToken, Data: "a"},
+		{Type: html.EndTagToken, Data: "X-CELL"},
+		{Type: html.StartTagToken, Data: "B"},
+		{Type: html.TextToken, Data: "b"},
+		{Type: html.EndTagToken, Data: "b"},
+	} {
+		if err := s.Token(tok, emit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(emit); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "<x-cell>a</x-cell><b>b</b>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func BenchmarkSanitizeTokensCustomNames(b *testing.B) {
+	p := customPolicy()
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	b.SetBytes(int64(len(customInput)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		z := html.NewTokenizer(strings.NewReader(customInput))
+		_ = htmlsanitizer.SanitizeTokens(z, p, htmlsanitizer.TokenWriter(&buf))
+	}
+}
@@ -0,0 +1,113 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors identifying the kind of an Issue. Use errors.Is to test for
+// them.
+var (
+	ErrURLRejected   = errors.New("URL rejected")
+	ErrDepthExceeded = errors.New("element nested deeper than MaxDepth")
+
+	// ErrNestingTooDeep reports an element dropped with its content
+	// for exceeding Policy.MaxNestingDepth.
+	ErrNestingTooDeep = errors.New("element nested deeper than MaxNestingDepth")
+
+	// ErrTableTooLarge reports a table cut down to Policy.MaxTableColumns
+	// or Policy.MaxTableCells.
+	ErrTableTooLarge = errors.New("table larger than MaxTableColumns or MaxTableCells")
+)
+
+// Issue is a recoverable problem met while sanitizing. The sanitizer
+// repairs it, by dropping the attribute or element concerned, and goes
+// on.
+type Issue struct {
+	// Err is the kind of problem: ErrURLRejected, ErrDepthExceeded,
+	// ErrNestingTooDeep, ErrTableTooLarge, or ErrOutputTooLarge.
+	Err error
+
+	// Element is the tag name of the element concerned, if any.
+	Element string
+
+	// Attribute is the attribute concerned, if any.
+	Attribute string
+
+	// Value is the offending attribute value, if any.
+	Value string
+}
+
+func (i *Issue) Error() string {
+	var b strings.Builder
+	b.WriteString("htmlsanitizer: ")
+	if i.Element != "" {
+		b.WriteByte('<')
+		b.WriteString(i.Element)
+		if i.Attribute != "" {
+			b.WriteByte(' ')
+			b.WriteString(i.Attribute)
+		}
+		b.WriteString(">: ")
+	}
+	b.WriteString(i.Err.Error())
+	if i.Value != "" {
+		fmt.Fprintf(&b, ": %q", i.Value)
+	}
+	return b.String()
+}
+
+func (i *Issue) Unwrap() error { return i.Err }
+
+// Issues is the error returned by SanitizeLenient. It lists every
+// recoverable problem in document order.
+type Issues []*Issue
+
+func (is Issues) Error() string {
+	if len(is) == 1 {
+		return is[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more issues)", is[0].Error(), len(is)-1)
+}
+
+// Unwrap returns the individual issues, so that errors.Is and errors.As
+// look through them.
+func (is Issues) Unwrap() []error {
+	errs := make([]error, len(is))
+	for i, issue := range is {
+		errs[i] = issue
+	}
+	return errs
+}
+
+// SanitizeLenient is like Sanitize but always returns the best-effort
+// output. Recoverable problems that Sanitize repairs silently, such as
+// rejected URLs and elements beyond MaxDepth, and that it fails on,
+// such as exceeding MaxOutputLength, are returned together as an
+// Issues error, letting the caller decide whether to keep the result.
+// Other errors are returned as they are, with empty output.
+//
+// If p is nil, the default policy is used.
+func SanitizeLenient(htmlStr string, p *Policy) (string, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	rep := &Report{lenient: true}
+	out, err := sanitize(context.Background(), strings.NewReader(htmlStr), compilePolicy(p), rep)
+	if err != nil {
+		return "", err
+	}
+	if len(rep.Issues) == 0 {
+		return out, nil
+	}
+	return out, Issues(rep.Issues)
+}
+
+// addIssue records an issue if a report is being kept.
+func (r *Report) addIssue(issue *Issue) {
+	if r != nil {
+		r.Issues = append(r.Issues, issue)
+	}
+}
@@ -0,0 +1,40 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitizeLenient(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxDepth = 2
+	p.MaxOutputLength = 40
+	input := `<p><a href="javascript:alert(1)">x</a><b><i>deep</i></b></p><p>` + strings.Repeat("y", 30) + `</p>`
+	got, err := htmlsanitizer.SanitizeLenient(input, p)
+	want := `<p><a>x</a><b>&lt;i&gt;deep&lt;/i&gt;</b></p><p>` + strings.Repeat("y", 30) + `</p>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	var issues htmlsanitizer.Issues
+	if !errors.As(err, &issues) || len(issues) != 3 {
+		t.Fatalf("err = %v, want 3 issues", err)
+	}
+	for _, target := range []error{htmlsanitizer.ErrURLRejected, htmlsanitizer.ErrDepthExceeded, htmlsanitizer.ErrOutputTooLarge} {
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(err, %v) = false", target)
+		}
+	}
+	if got := issues[0].Error(); got != `htmlsanitizer: <a href>: URL rejected: "javascript:alert(1)"` {
+		t.Errorf("issue text %q", got)
+	}
+
+	if _, err := htmlsanitizer.Sanitize(input, p); err != htmlsanitizer.ErrOutputTooLarge {
+		t.Errorf("Sanitize err = %v, want ErrOutputTooLarge", err)
+	}
+	if _, err := htmlsanitizer.SanitizeLenient(`<p>fine</p>`, p); err != nil {
+		t.Errorf("clean input: err = %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/language"
+)
+
+// isLangAttr reports whether key holds a language tag.
+func isLangAttr(key string) bool {
+	return key == "lang" || key == "xml:lang"
+}
+
+// canonicalLang parses s as a BCP 47 language tag and returns its
+// canonical form. The empty string, which marks the language as
+// unknown, is valid.
+func canonicalLang(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", true
+	}
+	tag, err := language.Parse(s)
+	if err != nil {
+		return "", false
+	}
+	return tag.String(), true
+}
+
+// detectLanguage runs Policy.DetectLanguage over the text of root and
+// returns the canonical tag it reports, or "" if detection is off or
+// the result is not a valid tag.
+func detectLanguage(root *html.Node, p *Policy) string {
+	if p.DetectLanguage == nil {
+		return ""
+	}
+	var b strings.Builder
+	writeBlockText(&b, root)
+	lang, ok := canonicalLang(p.DetectLanguage(strings.Join(strings.Fields(b.String()), " ")))
+	if !ok {
+		return ""
+	}
+	return lang
+}
@@ -0,0 +1,58 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_ValidateLang(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.ValidateLang = true
+	tests := []struct{ in, want string }{
+		{`<p lang="EN_us">x</p>`, `<p lang="en-US">x</p>`},
+		{`<p lang="zh-Hant-TW">x</p>`, `<p lang="zh-Hant-TW">x</p>`},
+		{`<p lang="">x</p>`, `<p lang="">x</p>`},
+		{`<p lang="en&quot; onclick=&quot;x">x</p>`, `<p>x</p>`},
+		{`<p lang="not a language">x</p>`, `<p>x</p>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeWithReport_DetectLanguage(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	var seen string
+	p.DetectLanguage = func(text string) string {
+		seen = text
+		if strings.Contains(text, "Bonjour") {
+			return "FR"
+		}
+		return "???"
+	}
+	_, rep, err := htmlsanitizer.SanitizeWithReport("<p>Bonjour</p><p>le monde</p>", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Language != "fr" {
+		t.Errorf("Language = %q, want fr", rep.Language)
+	}
+	if seen != "Bonjour le monde" {
+		t.Errorf("detector saw %q", seen)
+	}
+	_, rep, err = htmlsanitizer.SanitizeWithReport("<p>hi</p>", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Language != "" {
+		t.Errorf("invalid detection recorded as %q", rep.Language)
+	}
+}
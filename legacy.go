@@ -0,0 +1,258 @@
+package htmlsanitizer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// legacyTags maps the presentational elements that
+// Policy.ModernizeTags replaces to the elements that take their place.
+var legacyTags = map[string]string{
+	"font":    "span",
+	"center":  "div",
+	"big":     "span",
+	"tt":      "code",
+	"strike":  "s",
+	"acronym": "abbr",
+}
+
+// fontSizes maps the sizes of <font size>, 1 to 7, to CSS keywords.
+var fontSizes = [...]string{"x-small", "small", "medium", "large", "x-large", "xx-large", "xxx-large"}
+
+// legacyClasses name the classes that stand for the declarations
+// ModernizeTags and ConvertLegacyAttributes write, for policies that
+// allow class but not style. Declarations without a class, such as
+// colors, are dropped there unless Policy.LegacyClasses names one.
+var legacyClasses = map[string]string{
+	"text-align:left":     "text-left",
+	"text-align:center":   "text-center",
+	"text-align:right":    "text-right",
+	"text-align:justify":  "text-justify",
+	"float:left":          "float-left",
+	"float:right":         "float-right",
+	"font-size:larger":    "text-larger",
+	"font-size:x-small":   "text-x-small",
+	"font-size:small":     "text-small",
+	"font-size:medium":    "text-medium",
+	"font-size:large":     "text-large",
+	"font-size:x-large":   "text-x-large",
+	"font-size:xx-large":  "text-xx-large",
+	"font-size:xxx-large": "text-xxx-large",
+}
+
+var (
+	// legacyColor matches the colors <font color> may give: hex
+	// triplets and named colors.
+	legacyColor = regexp.MustCompile(`^(?:#[0-9A-Fa-f]{3}|#[0-9A-Fa-f]{6}|[A-Za-z]+)$`)
+
+	// legacyFamily matches a font family name that needs no quoting.
+	legacyFamily = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9 -]*$`)
+)
+
+// modernizeTag applies Policy.ModernizeTags to n, an element named tag,
+// and returns the name n now has. The presentation of the old element
+// is added to the new one by addPresentation.
+func (c *compiledPolicy) modernizeTag(n *html.Node, tag string) string {
+	modern, ok := legacyTags[tag]
+	if !ok || n.Namespace != "" {
+		return tag
+	}
+	var decls []string
+	switch tag {
+	case "font":
+		decls = fontDecls(n)
+	case "center":
+		decls = []string{"text-align:center"}
+	case "big":
+		decls = []string{"font-size:larger"}
+	}
+	n.Data, n.DataAtom = modern, atom.Lookup([]byte(modern))
+	c.addPresentation(n, modern, decls)
+	return modern
+}
+
+// addPresentation gives n, an element named tag, the CSS declarations
+// decls. Declarations with a class in Policy.LegacyClasses become that
+// class where the policy allows class; the others become an inline
+// style where it allows style, or else classes from legacyClasses.
+func (c *compiledPolicy) addPresentation(n *html.Node, tag string, decls []string) {
+	classes, styles := c.attrAllowed("class", tag), c.attrAllowed("style", tag)
+	var style []string
+	for _, d := range decls {
+		switch {
+		case classes && c.legacyClasses[d] != "":
+			addClasses(n, c.legacyClasses[d])
+		case styles:
+			style = append(style, d)
+		case classes:
+			addClasses(n, legacyClasses[d])
+		}
+	}
+	if len(style) == 0 {
+		return
+	}
+	if old := strings.TrimRight(strings.TrimSpace(GetAttr(n, "style")), ";"); old != "" {
+		style = append([]string{old}, style...)
+	}
+	SetAttr(n, "style", strings.Join(style, ";"))
+}
+
+// addClasses adds the space-separated class names in names to n.
+func addClasses(n *html.Node, names string) {
+	for _, name := range strings.Fields(names) {
+		addClass(n, name)
+	}
+}
+
+// legacyAttrs are the presentational attributes that
+// Policy.ConvertLegacyAttributes converts.
+var legacyAttrs = map[string]bool{
+	"align": true, "valign": true, "bgcolor": true, "border": true,
+	"cellpadding": true, "hspace": true, "vspace": true,
+}
+
+// maxLegacyPixels caps the borders, margins, and padding converted from
+// legacy attributes, so that they cannot cover the page.
+const maxLegacyPixels = 100
+
+// convertLegacyAttrs applies Policy.ConvertLegacyAttributes to n, an
+// element named tag: the legacy attributes the policy does not allow
+// on it are removed and their presentation added as style or classes.
+// The cellpadding of a table moves to its cells, which have yet to be
+// cleaned.
+func (c *compiledPolicy) convertLegacyAttrs(n *html.Node, tag string) {
+	var decls []string
+	kept := n.Attr[:0]
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" || !legacyAttrs[key] || c.attrAllowed(key, tag) {
+			kept = append(kept, a)
+			continue
+		}
+		if key == "cellpadding" && tag == "table" {
+			for _, row := range tableRows(n) {
+				for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") && !hasAttr(cell, key) {
+						SetAttr(cell, key, a.Val)
+					}
+				}
+			}
+			continue
+		}
+		decls = append(decls, legacyDecls(tag, key, strings.ToLower(strings.TrimSpace(a.Val)))...)
+	}
+	n.Attr = kept
+	if len(decls) > 0 {
+		c.addPresentation(n, tag, decls)
+	}
+}
+
+// legacyDecls returns the CSS declarations for the legacy attribute
+// key with the lower-cased value val on tag, or nil if val is invalid
+// there.
+func legacyDecls(tag, key, val string) []string {
+	switch key {
+	case "align":
+		switch {
+		case (tag == "img" || tag == "table") && (val == "left" || val == "right"):
+			return []string{"float:" + val}
+		case tag == "img" && (val == "top" || val == "middle" || val == "bottom"):
+			return []string{"vertical-align:" + val}
+		case tag == "table" && val == "center":
+			return []string{"margin-left:auto", "margin-right:auto"}
+		case tag != "img" && tag != "table" && (val == "left" || val == "center" || val == "right" || val == "justify"):
+			return []string{"text-align:" + val}
+		}
+	case "valign":
+		if val == "top" || val == "middle" || val == "bottom" || val == "baseline" {
+			return []string{"vertical-align:" + val}
+		}
+	case "bgcolor":
+		if legacyColor.MatchString(val) {
+			return []string{"background-color:" + val}
+		}
+	case "border":
+		if px, ok := legacyPixels(val); ok && (tag == "table" || tag == "img") {
+			if px == "0" {
+				return []string{"border:0"}
+			}
+			return []string{"border:" + px + " solid"}
+		}
+	case "cellpadding":
+		if px, ok := legacyPixels(val); ok && (tag == "td" || tag == "th") {
+			return []string{"padding:" + px}
+		}
+	case "hspace", "vspace":
+		if px, ok := legacyPixels(val); ok && tag == "img" {
+			if key == "hspace" {
+				return []string{"margin-left:" + px, "margin-right:" + px}
+			}
+			return []string{"margin-top:" + px, "margin-bottom:" + px}
+		}
+	}
+	return nil
+}
+
+// legacyPixels converts a legacy length in pixels to CSS, capped at
+// maxLegacyPixels.
+func legacyPixels(val string) (string, bool) {
+	n, err := strconv.Atoi(strings.TrimSuffix(val, "px"))
+	switch {
+	case err != nil || n < 0:
+		return "", false
+	case n == 0:
+		return "0", true
+	}
+	return strconv.Itoa(min(n, maxLegacyPixels)) + "px", true
+}
+
+// fontDecls removes the color, size, and face attributes of the <font>
+// n and returns the CSS declarations for the ones that are valid.
+func fontDecls(n *html.Node) []string {
+	var decls []string
+	kept := n.Attr[:0]
+	for _, a := range n.Attr {
+		val := strings.TrimSpace(a.Val)
+		switch strings.ToLower(a.Key) {
+		case "color":
+			if legacyColor.MatchString(val) {
+				decls = append(decls, "color:"+strings.ToLower(val))
+			}
+		case "size":
+			if size, ok := fontSize(val); ok {
+				decls = append(decls, "font-size:"+size)
+			}
+		case "face":
+			var families []string
+			for _, f := range strings.Split(val, ",") {
+				if f = strings.Join(strings.Fields(f), " "); legacyFamily.MatchString(f) {
+					families = append(families, f)
+				}
+			}
+			if len(families) > 0 {
+				decls = append(decls, "font-family:"+strings.Join(families, ","))
+			}
+		default:
+			kept = append(kept, a)
+		}
+	}
+	n.Attr = kept
+	return decls
+}
+
+// fontSize converts the value of <font size>, an absolute size from 1
+// to 7 or one relative to the default of 3, to a CSS keyword.
+func fontSize(val string) (string, bool) {
+	size, err := strconv.Atoi(val)
+	if err != nil {
+		return "", false
+	}
+	if val[0] == '+' || val[0] == '-' {
+		size += 3
+	}
+	return fontSizes[min(max(size, 1), 7)-1], true
+}
@@ -0,0 +1,121 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_ModernizeTags(t *testing.T) {
+	styled := htmlsanitizer.DefaultPolicy()
+	styled.ModernizeTags = true
+	styled.AllowedAttributes["span"] = []string{"style"}
+	styled.AllowedAttributes["div"] = []string{"style"}
+
+	classed := htmlsanitizer.DefaultPolicy()
+	classed.ModernizeTags = true
+
+	tests := []struct {
+		name string
+		p    *htmlsanitizer.Policy
+		in   string
+		want string
+	}{
+		{"font style", styled,
+			`<font color="#F00" size="5" face="Arial, Times New Roman">a</font>`,
+			`<span style="color:#f00;font-size:x-large;font-family:Arial,Times New Roman">a</span>`},
+		{"relative size", styled, `<font size="+2">a</font>`, `<span style="font-size:x-large">a</span>`},
+		{"invalid values", styled,
+			`<font color="red;background:url(x)" size="big" face="&quot;x&quot;">a</font>`,
+			`<span>a</span>`},
+		{"center style", styled, `<center style="color:red">a</center>`, `<div style="color:red;text-align:center">a</div>`},
+		{"font classes", classed, `<font color="red" size="1">a</font>`, `<span class="text-x-small">a</span>`},
+		{"center class", classed, `<center class="intro">a</center>`, `<div class="intro text-center">a</div>`},
+		{"big", classed, `<big>a</big>`, `<span class="text-larger">a</span>`},
+		{"renamed", classed,
+			`<tt>a</tt> <strike>b</strike> <acronym title="c">c</acronym> <small>d</small>`,
+			`<code>a</code> <s>b</s> <abbr title="c">c</abbr> &lt;small&gt;d&lt;/small&gt;`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlsanitizer.Sanitize(tt.in, tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+			q := *tt.p
+			q.PreserveFormatting = true
+			if got, err = htmlsanitizer.Sanitize(tt.in, &q); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("preserving: got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize_ModernizeTagsOff(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	got, err := htmlsanitizer.Sanitize(`<font color="red">a</font><strike>b</strike>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `&lt;font color="red"&gt;a&lt;/font&gt;<strike>b</strike>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSanitize_ConvertLegacyAttributes(t *testing.T) {
+	styled := htmlsanitizer.DefaultPolicy()
+	styled.ConvertLegacyAttributes = true
+	for _, tag := range []string{"p", "img", "table", "td", "div"} {
+		styled.AllowedAttributes[tag] = append(styled.AllowedAttributes[tag], "style")
+	}
+
+	classed := htmlsanitizer.DefaultPolicy()
+	classed.ConvertLegacyAttributes = true
+	classed.LegacyClasses = map[string]string{"background-color: yellow": "highlight"}
+
+	tests := []struct {
+		name string
+		p    *htmlsanitizer.Policy
+		in   string
+		want string
+	}{
+		{"align", styled, `<p align="Center">a</p>`, `<p style="text-align:center">a</p>`},
+		{"image", styled, `<img src="a.png" align="left" hspace="8" border="0">`,
+			`<img src="a.png" style="float:left;margin-left:8px;margin-right:8px;border:0" />`},
+		{"table", styled, `<table bgcolor="#EEE" border="500" cellpadding="4"><tr><td align="right">a</td></tr></table>`,
+			`<table style="background-color:#eee;border:100px solid"><tbody><tr><td align="right" style="padding:4px">a</td></tr></tbody></table>`},
+		{"invalid", styled, `<div align="middle" bgcolor="red;x:y">a</div>`, `<div>a</div>`},
+		{"classes", classed, `<p align="right">a</p><div bgcolor="Yellow">b</div><div bgcolor="red">c</div>`,
+			`<p class="text-right">a</p><div class="highlight">b</div><div>c</div>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlsanitizer.Sanitize(tt.in, tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize_ConvertLegacyAttributesPreserving(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.ConvertLegacyAttributes = true
+	p.PreserveFormatting = true
+	got, err := htmlsanitizer.Sanitize(`<p align="center">a</p> <img src="a.png" align="right">`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<p class="text-center">a</p> <img src="a.png" class="float-right" />`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Errors returned by SanitizerWithLimits.
+var (
+	ErrInputTooLarge = errors.New("htmlsanitizer: input exceeds MaxInputSize")
+	ErrBusy          = errors.New("htmlsanitizer: too many concurrent calls")
+)
+
+// Limits bounds the resources a SanitizerWithLimits spends on behalf of
+// untrusted callers. Zero values mean unlimited.
+type Limits struct {
+	// MaxInputSize rejects inputs longer than this many bytes with
+	// ErrInputTooLarge before any work is done.
+	MaxInputSize int
+
+	// Timeout bounds each call, in addition to the caller's context.
+	Timeout time.Duration
+
+	// MaxConcurrent limits the calls sanitizing at once. Further
+	// callers wait for a slot, up to MaxQueued of them; the rest fail
+	// with ErrBusy. With MaxQueued zero, callers never wait.
+	MaxConcurrent int
+	MaxQueued     int
+}
+
+// SanitizerWithLimits applies a policy under Limits, so that a service
+// can expose sanitizing to untrusted callers. It is safe for concurrent
+// use.
+type SanitizerWithLimits struct {
+	c       *compiledPolicy
+	limits  Limits
+	slots   chan struct{}
+	waiting atomic.Int64
+}
+
+// NewSanitizerWithLimits returns a SanitizerWithLimits applying p under
+// limits. Like NewSanitizer, it compiles a copy of p. If p is nil,
+// the default policy is used.
+func NewSanitizerWithLimits(p *Policy, limits Limits) *SanitizerWithLimits {
+	if p == nil {
+		p = nilPolicy()
+	}
+	s := &SanitizerWithLimits{c: compilePolicy(clonePolicy(p)), limits: limits}
+	if limits.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return s
+}
+
+// Sanitize sanitizes htmlStr, returning ctx.Err() if ctx is done or the
+// Timeout passes first. Parsing and cleaning stop soon after the
+// deadline passes, so an abandoned call gives up its slot quickly; one
+// cut short while rendering holds its slot until rendering finishes.
+func (s *SanitizerWithLimits) Sanitize(ctx context.Context, htmlStr string) (string, error) {
+	if s.limits.MaxInputSize > 0 && len(htmlStr) > s.limits.MaxInputSize {
+		s.logLimit(ctx, "MaxInputSize")
+		return "", ErrInputTooLarge
+	}
+	if out, ok := plainText(htmlStr, s.c.p); ok && ctx.Err() == nil {
+		return out, nil // too cheap to need a slot
+	}
+	if s.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.limits.Timeout)
+		defer cancel()
+	}
+	if err := s.acquire(ctx); err != nil {
+		if errors.Is(err, ErrBusy) {
+			s.logLimit(ctx, "MaxQueued")
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			s.logLimit(ctx, "Timeout")
+		}
+		return "", err
+	}
+
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer s.release()
+		out, err := sanitize(ctx, &ctxReader{ctx: ctx, r: strings.NewReader(htmlStr)}, s.c, nil)
+		done <- result{out, err}
+	}()
+	select {
+	case res := <-done:
+		if ctx.Err() == nil {
+			return res.out, res.err
+		}
+	case <-ctx.Done():
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		s.logLimit(ctx, "Timeout")
+	}
+	return "", ctx.Err()
+}
+
+func (s *SanitizerWithLimits) logLimit(ctx context.Context, limit string) {
+	logEvent(ctx, s.c.p, slog.LevelWarn, "htmlsanitizer: limit hit", slog.String("limit", limit))
+}
+
+func (s *SanitizerWithLimits) acquire(ctx context.Context) error {
+	if s.slots == nil {
+		return ctx.Err()
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if s.waiting.Add(1) > int64(s.limits.MaxQueued) {
+		s.waiting.Add(-1)
+		return ErrBusy
+	}
+	defer s.waiting.Add(-1)
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *SanitizerWithLimits) release() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// ctxReader fails reads once ctx is done, stopping the parser.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(p) > 4096 {
+		p = p[:4096] // check the context regularly
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,122 @@
+package htmlsanitizer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+// blockingPolicy returns a policy whose sanitizing signals started and
+// then waits for release to be closed.
+func blockingPolicy(started chan<- struct{}, release <-chan struct{}) *htmlsanitizer.Policy {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Transformers = []htmlsanitizer.Transformer{func(n *html.Node) *html.Node {
+		started <- struct{}{}
+		<-release
+		return n
+	}}
+	return p
+}
+
+func TestSanitizerWithLimits(t *testing.T) {
+	s := htmlsanitizer.NewSanitizerWithLimits(nil, htmlsanitizer.Limits{MaxInputSize: 32})
+	got, err := s.Sanitize(context.Background(), `<b onclick="x()">hi</b>`)
+	if err != nil || got != `<b>hi</b>` {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := s.Sanitize(context.Background(), strings.Repeat("x", 33)); !errors.Is(err, htmlsanitizer.ErrInputTooLarge) {
+		t.Errorf("oversized input: err = %v", err)
+	}
+}
+
+func TestSanitizerWithLimits_Concurrency(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	s := htmlsanitizer.NewSanitizerWithLimits(blockingPolicy(started, release),
+		htmlsanitizer.Limits{MaxConcurrent: 1, MaxQueued: 1})
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.Sanitize(context.Background(), `<b>1</b>`)
+		first <- err
+	}()
+	<-started
+
+	// Of two more callers, one waits in the queue until its deadline
+	// and the other finds the queue full.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := s.Sanitize(ctx, `<b>2</b>`)
+			errs <- err
+		}()
+	}
+	var busy, timedOut int
+	for i := 0; i < 2; i++ {
+		switch err := <-errs; {
+		case errors.Is(err, htmlsanitizer.ErrBusy):
+			busy++
+		case errors.Is(err, context.DeadlineExceeded):
+			timedOut++
+		default:
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if busy != 1 || timedOut != 1 {
+		t.Errorf("%d busy, %d timed out; want 1 each", busy, timedOut)
+	}
+	close(release)
+	if err := <-first; err != nil {
+		t.Errorf("first call: %v", err)
+	}
+}
+
+func TestSanitizerWithLimits_Timeout(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	s := htmlsanitizer.NewSanitizerWithLimits(blockingPolicy(started, release),
+		htmlsanitizer.Limits{Timeout: 10 * time.Millisecond})
+	if _, err := s.Sanitize(context.Background(), `<b>x</b>`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestSanitizerWithLimits_TimeoutStopsCleaning(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	var texts, posts atomic.Int64
+	p.TextTransformers = []func(string) string{func(s string) string {
+		if texts.Add(1) == 1 {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return s
+	}}
+	p.PostTransformers = []func(*html.Node){func(*html.Node) { posts.Add(1) }}
+	s := htmlsanitizer.NewSanitizerWithLimits(p, htmlsanitizer.Limits{Timeout: 10 * time.Millisecond, MaxConcurrent: 1})
+	if _, err := s.Sanitize(context.Background(), strings.Repeat("<b>x</b>", 10000)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+
+	// The abandoned call gives up its slot soon after the deadline
+	// instead of cleaning the rest of the document.
+	for start := time.Now(); ; {
+		if _, err := s.Sanitize(context.Background(), "<b>y</b>"); err == nil {
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatal("slot still held a second after the deadline")
+		}
+	}
+	if n := texts.Load(); n > 1000 {
+		t.Errorf("cleaned %d text nodes after the deadline", n)
+	}
+	if n := posts.Load(); n != 1 {
+		t.Errorf("PostTransformers ran %d times, want 1", n)
+	}
+}
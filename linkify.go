@@ -0,0 +1,336 @@
+package htmlsanitizer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// urlRegexp matches http/https URLs inside plain text.
+var urlRegexp = regexp.MustCompile(`https?://[^"` + "'" + `<>\s]+`)
+
+// mentionRegexp and hashtagRegexp match @name and #tag references. The
+// leading group keeps them from matching inside e-mail addresses, URL
+// fragments, and character references; group 1 holds the marker and
+// name.
+var (
+	mentionRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@/.])(@[\p{L}\p{N}_]+)`)
+	hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])(#[\p{L}\p{N}_]+)`)
+)
+
+// domainRegexp matches bare domains such as example.com/path for
+// LinkifyTLDs. Group 1 is the whole match and group 2 the host.
+var domainRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@/.:-])(((?:[\p{L}\p{N}](?:[\p{L}\p{N}-]*[\p{L}\p{N}])?\.)+\p{L}{2,})(?:/[^"'<>\s]*)?)`)
+
+// DefaultLinkifyExclude is the set of elements used when
+// Policy.LinkifyExclude is nil. Linking inside an anchor would nest
+// anchors, and code is usually meant literally.
+var DefaultLinkifyExclude = []string{"a", "code", "pre"}
+
+// linkifyExclude returns the effective LinkifyExclude.
+func (p *Policy) linkifyExclude() []string {
+	if p.LinkifyExclude == nil {
+		return DefaultLinkifyExclude
+	}
+	return p.LinkifyExclude
+}
+
+// textLink is a span of text to be wrapped in an anchor or, for
+// emoji, replaced by an image or by other text.
+type textLink struct {
+	start, end int
+	attrs      []html.Attribute
+	embed      string // replace the span by this element with attrs, if not empty
+	text       string // replace the span by text, if not empty
+	label      string // text of the anchor, if not the span itself
+}
+
+// linksText reports whether text nodes need to be searched for links.
+func (c *compiledPolicy) linksText() bool {
+	p := c.p
+	return p.Linkify || p.Mentions != nil || p.Hashtags != nil || len(p.Emoji) > 0 || len(p.CustomEmoji) > 0
+}
+
+// linkExcluded reports whether the text node n sits inside an element
+// listed in LinkifyExclude.
+func (c *compiledPolicy) linkExcluded(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && c.linkifyExclude[p.Data] {
+			return true
+		}
+	}
+	return false
+}
+
+// findLinks returns the non-overlapping spans of text to link or
+// replace, in order. URLs take precedence over mentions and hashtags,
+// and those over emoji.
+func (c *compiledPolicy) findLinks(text string) []textLink {
+	var links []textLink
+	if c.p.Linkify {
+		links = c.appendURLs(links, text)
+	}
+	links = c.appendRefs(links, text, mentionRegexp, c.p.Mentions, "mention")
+	links = c.appendRefs(links, text, hashtagRegexp, c.p.Hashtags, "hashtag")
+	links = c.appendEmoji(links, text)
+	if len(links) < 2 {
+		return links
+	}
+	sort.SliceStable(links, func(i, j int) bool { return links[i].start < links[j].start })
+	out := links[:1]
+	for _, l := range links[1:] {
+		if l.start >= out[len(out)-1].end {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// appendURLs appends a link for every URL in text: matches of
+// LinkifyPattern, or of the built-in pattern with trailing punctuation
+// trimmed, followed by bare domains under LinkifyTLDs. Every URL must
+// pass the policy's URL checks.
+func (c *compiledPolicy) appendURLs(links []textLink, text string) []textLink {
+	re := c.p.LinkifyPattern
+	if re == nil {
+		re = urlRegexp
+	}
+	n := len(links)
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		if c.p.LinkifyPattern == nil {
+			m[1] = m[0] + len(trimURLEnd(text[m[0]:m[1]]))
+		}
+		links = c.appendURL(links, text, m[0], m[1], "")
+	}
+	if len(c.linkifyTLDs) == 0 {
+		return links
+	}
+	for _, m := range domainRegexp.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2], m[2]+len(trimURLEnd(text[m[2]:m[3]]))
+		host := text[m[4]:m[5]]
+		tld := strings.ToLower(host[strings.LastIndexByte(host, '.')+1:])
+		if !c.linkifyTLDs[tld] || overlaps(links[n:], start, end) {
+			continue
+		}
+		links = c.appendURL(links, text, start, end, "https://")
+	}
+	return links
+}
+
+// appendURL appends a link for text[start:end] with the given prefix
+// added to its href, if the URL passes the policy's checks.
+//
+// Text nodes are already entity-decoded, so the href is the URL exactly
+// as the text reads, ampersands included, and is escaped once on
+// output. Text that still spells &amp; after decoding, because it was
+// escaped twice at the source, links to what it shows rather than being
+// decoded a second time.
+func (c *compiledPolicy) appendURL(links []textLink, text string, start, end int, prefix string) []textLink {
+	if start >= end {
+		return links
+	}
+	raw := prefix + text[start:end]
+	if c.p.LinkifyMedia && onOwnLine(text, start, end) {
+		if l, ok := c.embedMedia(raw); ok {
+			l.start, l.end = start, end
+			return append(links, l)
+		}
+	}
+	href, ok := c.checkURL("a", "href", raw)
+	if !ok {
+		return links
+	}
+	l := textLink{start: start, end: end, attrs: []html.Attribute{
+		{Key: "href", Val: href},
+		{Key: "rel", Val: "noopener noreferrer"},
+	}}
+	if c.p.LinkifyText != nil {
+		l.label = c.p.LinkifyText(href)
+	}
+	return append(links, l)
+}
+
+// endsWithCharRef reports whether s ends in a character reference such
+// as &amp; whose semicolon must not be trimmed.
+func endsWithCharRef(s string) bool {
+	i := strings.LastIndexByte(s, '&')
+	if i < 0 || i+2 >= len(s) {
+		return false
+	}
+	for _, r := range strings.TrimPrefix(s[i+1:len(s)-1], "#") {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func overlaps(links []textLink, start, end int) bool {
+	for _, l := range links {
+		if start < l.end && l.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// trimURLEnd removes trailing punctuation that usually ends the
+// surrounding sentence rather than the URL. Closing brackets are kept
+// when they balance an opening one inside the URL, as in
+// https://en.wikipedia.org/wiki/Go_(programming_language).
+func trimURLEnd(s string) string {
+	for s != "" {
+		r, size := utf8.DecodeLastRuneInString(s)
+		switch {
+		case r == ';' && endsWithCharRef(s):
+			return s
+		case strings.ContainsRune(".,;:!?'\"*。，、！？；：", r):
+		case r == ')' && strings.Count(s, "(") < strings.Count(s, ")"):
+		case r == ']' && strings.Count(s, "[") < strings.Count(s, "]"):
+		default:
+			return s
+		}
+		s = s[:len(s)-size]
+	}
+	return s
+}
+
+// appendRefs appends a link for every match of re in text that resolve
+// accepts.
+func (c *compiledPolicy) appendRefs(links []textLink, text string, re *regexp.Regexp, resolve func(string) (string, bool), class string) []textLink {
+	if resolve == nil {
+		return links
+	}
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		href, ok := resolve(text[m[2]+1 : m[3]])
+		if !ok {
+			continue
+		}
+		if href, ok = c.checkURL("a", "href", href); !ok {
+			continue
+		}
+		links = append(links, textLink{start: m[2], end: m[3], attrs: []html.Attribute{
+			{Key: "href", Val: href},
+			{Key: "class", Val: class},
+		}})
+	}
+	return links
+}
+
+// linkifyNode splits the text node n around the links it contains,
+// inserting an <a> element for each one, and replaces its emoji.
+func (c *compiledPolicy) linkifyNode(n *html.Node) {
+	text := n.Data
+	links := c.findLinks(text)
+	if len(links) == 0 {
+		return
+	}
+	parent := n.Parent
+	last := 0
+	for _, l := range links {
+		if l.start > last {
+			parent.InsertBefore(&html.Node{Type: html.TextNode, Data: text[last:l.start]}, n)
+		}
+		parent.InsertBefore(l.node(text), n)
+		last = l.end
+	}
+	if last < len(text) {
+		n.Data = text[last:]
+	} else {
+		parent.RemoveChild(n)
+	}
+}
+
+// emitLinkedText is the token counterpart of linkifyNode.
+func (c *compiledPolicy) emitLinkedText(text string, emit func(html.Token) error) error {
+	last := 0
+	for _, l := range c.findLinks(text) {
+		toks := append([]html.Token{{Type: html.TextToken, Data: text[last:l.start]}}, l.tokens(text)...)
+		for _, tok := range toks {
+			if tok.Type == html.TextToken && tok.Data == "" {
+				continue
+			}
+			if err := emit(tok); err != nil {
+				return err
+			}
+		}
+		last = l.end
+	}
+	if last == len(text) {
+		return nil
+	}
+	return emit(html.Token{Type: html.TextToken, Data: text[last:]})
+}
+
+// node returns the node that takes the place of l in text.
+func (l textLink) node(text string) *html.Node {
+	switch {
+	case l.embed != "":
+		return &html.Node{Type: html.ElementNode, Data: l.embed, DataAtom: atom.Lookup([]byte(l.embed)), Attr: l.attrs}
+	case l.text != "":
+		return &html.Node{Type: html.TextNode, Data: l.text}
+	}
+	a := &html.Node{Type: html.ElementNode, Data: "a", DataAtom: atom.A, Attr: l.attrs}
+	a.AppendChild(&html.Node{Type: html.TextNode, Data: l.anchorText(text)})
+	return a
+}
+
+// tokens is the token counterpart of node.
+func (l textLink) tokens(text string) []html.Token {
+	switch {
+	case l.embed != "" && isVoidElement(l.embed):
+		return []html.Token{{Type: html.StartTagToken, Data: l.embed, Attr: l.attrs}}
+	case l.embed != "":
+		return []html.Token{{Type: html.StartTagToken, Data: l.embed, Attr: l.attrs}, {Type: html.EndTagToken, Data: l.embed}}
+	case l.text != "":
+		return []html.Token{{Type: html.TextToken, Data: l.text}}
+	}
+	return []html.Token{
+		{Type: html.StartTagToken, Data: "a", Attr: l.attrs},
+		{Type: html.TextToken, Data: l.anchorText(text)},
+		{Type: html.EndTagToken, Data: "a"},
+	}
+}
+
+// anchorText returns the text of the anchor for l.
+func (l textLink) anchorText(text string) string {
+	if l.label != "" {
+		return l.label
+	}
+	return text[l.start:l.end]
+}
+
+// ShortLinkText returns a function for Policy.LinkifyText that shows
+// URLs without their scheme, a leading "www.", and a trailing slash,
+// and cuts them to limit characters with an ellipsis, keeping the host
+// whole: "https://www.example.com/docs/" becomes "example.com/docs". A
+// limit of zero or less does not cut.
+func ShortLinkText(limit int) func(href string) string {
+	return func(href string) string {
+		s := href
+		if i := strings.Index(s, "://"); i >= 0 {
+			s = s[i+len("://"):]
+		}
+		s = strings.TrimPrefix(s, "www.")
+		if !strings.ContainsAny(s, "?#") {
+			s = strings.TrimSuffix(s, "/")
+		}
+		host := strings.IndexAny(s, "/?#")
+		if limit <= 0 || host < 0 || utf8.RuneCountInString(s) <= limit {
+			return s
+		}
+		// Leave room for the ellipsis.
+		cut, n := 0, 0
+		for cut = range s {
+			if n == limit-1 {
+				break
+			}
+			n++
+		}
+		return s[:max(cut, host)] + "…"
+	}
+}
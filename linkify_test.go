@@ -0,0 +1,181 @@
+package htmlsanitizer_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_LinkifyExclude(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	input := `<p><a href="https://a.example/">see https://a.example/</a> <code>https://b.example/</code></p>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != input {
+		t.Errorf("got  %q\nwant %q", got, input)
+	}
+
+	p = p.Clone()
+	p.LinkifyExclude = []string{}
+	got, err = htmlsanitizer.Sanitize(`<code>https://b.example/</code>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<code><a href="https://b.example/" rel="noopener noreferrer">https://b.example/</a></code>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestSanitize_MentionsHashtags(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	p.Mentions = func(name string) (string, bool) {
+		return "/users/" + name, name != "nobody"
+	}
+	p.Hashtags = func(tag string) (string, bool) {
+		if tag == "evil" {
+			return "javascript:alert(1)", true
+		}
+		return "/tags/" + tag, true
+	}
+	tests := []struct{ in, want string }{
+		{`hi @ann and @nobody`,
+			`hi <a href="/users/ann" class="mention">@ann</a> and @nobody`},
+		{`#go is #evil`,
+			`<a href="/tags/go" class="hashtag">#go</a> is #evil`},
+		{`mail ann@example.com, see https://x.example/#frag`,
+			`mail ann@example.com, see <a href="https://x.example/#frag" rel="noopener noreferrer">https://x.example/#frag</a>`},
+		{`<code>@ann #go</code>`, `<code>@ann #go</code>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+		if got := sanitizeTokens(t, tt.in, p); got != tt.want {
+			t.Errorf("tokens %s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize_LinkifyTrailingPunctuation(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	tests := []struct{ in, url string }{
+		{`See https://go.dev.`, `https://go.dev`},
+		{`(see https://go.dev/doc)`, `https://go.dev/doc`},
+		{`https://en.wikipedia.org/wiki/Go_(programming_language), ok`, `https://en.wikipedia.org/wiki/Go_(programming_language)`},
+		{`参见https://go.dev/doc。`, `https://go.dev/doc`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `<a href="` + tt.url + `" rel="noopener noreferrer">` + tt.url + `</a>`
+		if !strings.Contains(got, want) {
+			t.Errorf("%s:\ngot  %q\nwant link %q", tt.in, got, want)
+		}
+	}
+}
+
+func TestSanitize_LinkifyPatternAndTLDs(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	p.LinkifyTLDs = []string{"dev", "org"}
+	tests := []struct{ in, want string }{
+		{`visit go.dev/doc, or example.com`,
+			`visit <a href="https://go.dev/doc" rel="noopener noreferrer">go.dev/doc</a>, or example.com`},
+		{`mail me@golang.org or see https://golang.org/x`,
+			`mail me@golang.org or see <a href="https://golang.org/x" rel="noopener noreferrer">https://golang.org/x</a>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+
+	p = htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	p.LinkifyPattern = regexp.MustCompile(`(?:https?|javascript):\S+`)
+	got, err := htmlsanitizer.Sanitize(`a https://go.dev. b javascript:alert(1)`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `a <a href="https://go.dev." rel="noopener noreferrer">https://go.dev.</a> b javascript:alert(1)`
+	if got != want {
+		t.Errorf("custom pattern:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestSanitize_LinkifyAmpersands(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	tests := []struct{ in, want string }{
+		{`go https://e.com/?a=1&amp;b=2&amp;c=3 now`,
+			`go <a href="https://e.com/?a=1&amp;b=2&amp;c=3" rel="noopener noreferrer">https://e.com/?a=1&amp;b=2&amp;c=3</a> now`},
+		// Escaped twice at the source: the href matches the text and is
+		// not decoded again.
+		{`<p>https://e.com/?a=1&amp;amp;b=2&amp;#38;c=3</p>`,
+			`<p><a href="https://e.com/?a=1&amp;amp;b=2&amp;#38;c=3" rel="noopener noreferrer">https://e.com/?a=1&amp;amp;b=2&amp;#38;c=3</a></p>`},
+		{`see https://e.com/?a=1&amp;amp;.`,
+			`see <a href="https://e.com/?a=1&amp;amp;" rel="noopener noreferrer">https://e.com/?a=1&amp;amp;</a>.`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+		if got := sanitizeTokens(t, tt.in, p); got != tt.want {
+			t.Errorf("tokens %s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLinkify_LinkText(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	p.LinkifyText = htmlsanitizer.ShortLinkText(20)
+	in := "see https://www.example.com/ and https://example.com/docs/guides/install?os=linux"
+	want := `see <a href="https://www.example.com/" rel="noopener noreferrer">example.com</a> and ` +
+		`<a href="https://example.com/docs/guides/install?os=linux" rel="noopener noreferrer">example.com/docs/gu…</a>`
+	got, err := htmlsanitizer.Sanitize(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestShortLinkText(t *testing.T) {
+	short := htmlsanitizer.ShortLinkText(12)
+	for in, want := range map[string]string{
+		"https://example.com/":                 "example.com",
+		"http://www.ex.com/a/":                 "ex.com/a",
+		"https://a-rather-long-host.example/":  "a-rather-long-host.example",
+		"https://a-rather-long-host.example/x": "a-rather-long-host.example…",
+		"https://ex.com/path/to/page":          "ex.com/path…",
+		"https://ex.com/?q=1":                  "ex.com/?q=1",
+	} {
+		if got := short(in); got != want {
+			t.Errorf("ShortLinkText(12)(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
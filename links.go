@@ -0,0 +1,107 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Link describes an anchor that survived sanitization.
+type Link struct {
+	// Href is the href attribute as it appears in the sanitized output.
+	Href string
+
+	// URL is Href resolved against the base URL passed to
+	// ExtractLinks. It equals Href when no base URL was given.
+	URL string
+
+	// Text is the anchor's text content with whitespace collapsed.
+	Text string
+
+	// Rel is the anchor's rel attribute, if any.
+	Rel string
+
+	// External reports whether the link leaves the site: it points to
+	// another host, or uses a scheme other than http or https.
+	External bool
+}
+
+// ExtractLinks sanitizes htmlStr with p and returns the anchors in the
+// output, in document order. Links are classified as internal or
+// external relative to baseURL; when baseURL is empty, only relative
+// links are internal. If p is nil, the default policy is used.
+func ExtractLinks(htmlStr string, p *Policy, baseURL string) ([]Link, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	var base *url.URL
+	if baseURL != "" {
+		var err error
+		if base, err = url.Parse(baseURL); err != nil {
+			return nil, err
+		}
+	}
+	root, err := sanitizeTree(context.Background(), strings.NewReader(htmlStr), compilePolicy(p), nil)
+	if err != nil {
+		return nil, err
+	}
+	var links []Link
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			if href := GetAttr(n, "href"); href != "" {
+				links = append(links, newLink(n, href, base))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return links, nil
+}
+
+func newLink(n *html.Node, href string, base *url.URL) Link {
+	l := Link{
+		Href: href,
+		URL:  href,
+		Text: strings.Join(strings.Fields(textContent(n)), " "),
+		Rel:  GetAttr(n, "rel"),
+	}
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		l.External = true
+		return l
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+		l.URL = u.String()
+	}
+	switch {
+	case u.Scheme == "" && u.Host == "":
+		l.External = false
+	case u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https":
+		l.External = true
+	default:
+		l.External = base == nil || !strings.EqualFold(u.Hostname(), base.Hostname())
+	}
+	return l
+}
+
+// textContent returns the concatenated text of n and its descendants.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
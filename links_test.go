@@ -0,0 +1,37 @@
+package htmlsanitizer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestExtractLinks(t *testing.T) {
+	input := `<p><a href="/about">About
+		us</a> <a href="https://Example.com/x" rel="me">home</a>
+		<a href="https://other.example/">other</a> <a href="mailto:a@example.com">mail</a>
+		<a href="javascript:alert(1)">bad</a> <a href="#top">top</a></p>`
+	got, err := htmlsanitizer.ExtractLinks(input, nil, "https://example.com/blog/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []htmlsanitizer.Link{
+		{Href: "/about", URL: "https://example.com/about", Text: "About us"},
+		{Href: "https://Example.com/x", URL: "https://Example.com/x", Text: "home", Rel: "me"},
+		{Href: "https://other.example/", URL: "https://other.example/", Text: "other", External: true},
+		{Href: "mailto:a@example.com", URL: "mailto:a@example.com", Text: "mail", External: true},
+		{Href: "#top", URL: "https://example.com/blog/#top", Text: "top"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	got, err = htmlsanitizer.ExtractLinks(`<a href="/a">a</a><a href="https://example.com/">b</a>`, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].External || !got[1].External {
+		t.Errorf("without base: %+v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Logger receives notable events from sanitizing: limits hit, large
+// removals, and, at debug level, input the parser had to repair.
+// *slog.Logger implements it.
+type Logger interface {
+	Enabled(ctx context.Context, level slog.Level) bool
+	LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr)
+}
+
+// largeRemoval is the number of removed elements and attributes from
+// which a call is logged as a large removal.
+const largeRemoval = 10
+
+type documentIDKey struct{}
+
+// WithDocumentID returns a context carrying id, which is added to every
+// event logged for calls made with it, so that events can be traced to
+// the document concerned.
+func WithDocumentID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, documentIDKey{}, id)
+}
+
+// DocumentID returns the id stored in ctx by WithDocumentID.
+func DocumentID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(documentIDKey{}).(string)
+	return id, ok
+}
+
+// SanitizeContext is like Sanitize, but events for Policy.Logger are
+// logged with ctx, and with the document id it carries, if any.
+func SanitizeContext(ctx context.Context, htmlStr string, p *Policy) (string, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	return sanitizeString(ctx, htmlStr, compilePolicy(p), nil)
+}
+
+// logEvent logs msg with attrs to the policy's Logger, if any.
+func logEvent(ctx context.Context, p *Policy, level slog.Level, msg string, attrs ...slog.Attr) {
+	if p.Logger == nil || !p.Logger.Enabled(ctx, level) {
+		return
+	}
+	if id, ok := DocumentID(ctx); ok {
+		attrs = append(attrs, slog.String("document_id", id))
+	}
+	if p.Version != "" {
+		attrs = append(attrs, slog.String("policy_version", p.Version))
+	}
+	p.Logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logReport logs the limits hit and large removals recorded in rep.
+func logReport(ctx context.Context, p *Policy, rep *Report) {
+	depth, nesting := 0, 0
+	for _, issue := range rep.Issues {
+		switch {
+		case errors.Is(issue.Err, ErrDepthExceeded):
+			depth++
+		case errors.Is(issue.Err, ErrNestingTooDeep):
+			nesting++
+		}
+	}
+	if depth > 0 {
+		logEvent(ctx, p, slog.LevelWarn, "htmlsanitizer: limit hit",
+			slog.String("limit", "MaxDepth"), slog.Int("elements", depth))
+	}
+	if nesting > 0 {
+		logEvent(ctx, p, slog.LevelWarn, "htmlsanitizer: limit hit",
+			slog.String("limit", "MaxNestingDepth"), slog.Int("elements", nesting))
+	}
+	if rep.RemovedElements+rep.RemovedAttributes >= largeRemoval {
+		logEvent(ctx, p, slog.LevelInfo, "htmlsanitizer: large removal",
+			slog.Int("removed_elements", rep.RemovedElements),
+			slog.Int("removed_attributes", rep.RemovedAttributes))
+	}
+}
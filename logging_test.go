@@ -0,0 +1,44 @@
+package htmlsanitizer_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	p := htmlsanitizer.DefaultPolicy()
+	p.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p.MaxDepth = 2
+	ctx := htmlsanitizer.WithDocumentID(context.Background(), "doc-42")
+	input := `<div><div><div>deep</div></div></div><p>unclosed` + strings.Repeat(`<font>x</font>`, 10)
+	if _, err := htmlsanitizer.SanitizeContext(ctx, input, p); err != nil {
+		t.Fatal(err)
+	}
+	log := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="htmlsanitizer: parse recovery" line=1`,
+		`level=WARN msg="htmlsanitizer: limit hit" limit=MaxDepth elements=1 document_id=doc-42`,
+		`level=INFO msg="htmlsanitizer: large removal" removed_elements=11 removed_attributes=0 document_id=doc-42`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log lacks %q:\n%s", want, log)
+		}
+	}
+
+	p = p.Clone()
+	buf.Reset()
+	p.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	p.MaxOutputLength = 5
+	if _, err := htmlsanitizer.Sanitize(`<b>too long</b>`, p); err == nil {
+		t.Fatal("no error")
+	}
+	if log := buf.String(); !strings.Contains(log, "limit=MaxOutputLength") || strings.Contains(log, "DEBUG") {
+		t.Errorf("log:\n%s", log)
+	}
+}
@@ -0,0 +1,120 @@
+package htmlsanitizer
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// mailtoFields lists the mailto: fields kept by ValidateMailto. Other
+// fields name arbitrary mail headers and are removed.
+var mailtoFields = map[string]bool{
+	"to":      true,
+	"cc":      true,
+	"bcc":     true,
+	"subject": true,
+	"body":    true,
+}
+
+func (p *Policy) checksMailto() bool {
+	return p.ValidateMailto || p.StripMailtoCopies ||
+		p.MaxMailtoSubjectLength > 0 || p.MaxMailtoBodyLength > 0
+}
+
+// checkMailto applies the mailto: rules of the policy to u, parsed from
+// raw. It returns raw unchanged unless fields had to be removed, in
+// which case the URL is rebuilt from the remaining fields.
+func (c *compiledPolicy) checkMailto(raw string, u *url.URL) (string, bool) {
+	p := c.p
+	to, err := url.PathUnescape(u.Opaque)
+	if err != nil {
+		return "", false
+	}
+	if p.ValidateMailto && !validAddressList(to) {
+		return "", false
+	}
+
+	type field struct{ key, val string }
+	var fields []field
+	changed := false
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		key, err1 := url.QueryUnescape(k)
+		val, err2 := url.PathUnescape(v)
+		if err1 != nil || err2 != nil {
+			return "", false
+		}
+		key = strings.ToLower(key)
+		switch {
+		case p.ValidateMailto && !mailtoFields[key]:
+			changed = true
+			continue
+		case p.StripMailtoCopies && (key == "cc" || key == "bcc"):
+			changed = true
+			continue
+		}
+		switch key {
+		case "subject":
+			if p.MaxMailtoSubjectLength > 0 && len(val) > p.MaxMailtoSubjectLength {
+				return "", false
+			}
+		case "body":
+			if p.MaxMailtoBodyLength > 0 && len(val) > p.MaxMailtoBodyLength {
+				return "", false
+			}
+		case "to", "cc", "bcc":
+			if p.ValidateMailto && !validAddressList(val) {
+				return "", false
+			}
+		}
+		// Line breaks belong only in the body; anywhere else they
+		// inject headers into the message.
+		if p.ValidateMailto && key != "body" && strings.ContainsAny(val, "\r\n") {
+			return "", false
+		}
+		fields = append(fields, field{key, val})
+	}
+	if !changed {
+		return raw, true
+	}
+
+	var b strings.Builder
+	b.WriteString("mailto:")
+	b.WriteString(u.Opaque)
+	for i, f := range fields {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(mailtoEscape(f.key))
+		b.WriteByte('=')
+		b.WriteString(mailtoEscape(f.val))
+	}
+	return b.String(), true
+}
+
+// validAddressList reports whether s is empty or a comma-separated list
+// of bare e-mail addresses.
+func validAddressList(s string) bool {
+	if strings.TrimSpace(s) == "" {
+		return true
+	}
+	for _, addr := range strings.Split(s, ",") {
+		addr = strings.TrimSpace(addr)
+		a, err := mail.ParseAddress(addr)
+		if err != nil || a.Name != "" || a.Address != addr {
+			return false
+		}
+	}
+	return true
+}
+
+// mailtoEscape percent-encodes s for a mailto: field. RFC 6068 reads
+// '+' literally, so spaces are encoded as %20.
+func mailtoEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,61 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_ValidateMailto(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.ValidateMailto = true
+	tests := []struct {
+		href string
+		keep bool
+	}{
+		{"mailto:alice@example.com", true},
+		{"mailto:alice@example.com,bob@example.com?subject=Hi%20there", true},
+		{"mailto:?subject=hello", true},
+		{"mailto:not-an-address", false},
+		{"mailto:Alice%20%3Calice@example.com%3E", false},
+		{"mailto:alice@example.com?subject=x%0D%0ABcc:%20all@example.com", false},
+		{"mailto:alice@example.com?cc=bogus", false},
+		{"mailto:alice@example.com?body=line%0D%0Aline", true},
+	}
+	for _, tt := range tests {
+		if got := hrefKept(t, p, tt.href); got != tt.keep {
+			t.Errorf("%s: kept=%v want %v", tt.href, got, tt.keep)
+		}
+	}
+}
+
+func TestSanitize_MailtoRewrite(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.ValidateMailto = true
+	p.StripMailtoCopies = true
+	input := `<a href="mailto:alice@example.com?subject=Hi%20there&amp;bcc=eve@example.com&amp;x-mailer=spam&amp;body=a+b">x</a>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a href="mailto:alice@example.com?subject=Hi%20there&amp;body=a%2Bb">x</a>`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_MailtoLengthLimits(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxMailtoSubjectLength = 10
+	p.MaxMailtoBodyLength = 100
+	if !hrefKept(t, p, "mailto:a@example.com?subject=short&body=short") {
+		t.Error("short mailto dropped")
+	}
+	if hrefKept(t, p, "mailto:a@example.com?subject=far%20too%20long%20a%20subject") {
+		t.Error("long subject kept")
+	}
+	if hrefKept(t, p, "mailto:a@example.com?body="+strings.Repeat("x", 101)) {
+		t.Error("long body kept")
+	}
+}
@@ -0,0 +1,51 @@
+package htmlsanitizer
+
+import "strings"
+
+const (
+	markerPrefix = "<!--htmlsanitizer:"
+	markerSuffix = "-->"
+)
+
+// marker returns the comment embedded by Policy.EmbedMarker.
+func marker(c *compiledPolicy) string {
+	return markerPrefix + c.policyFingerprint() + markerSuffix
+}
+
+// MarkerFingerprint returns the policy fingerprint recorded in the
+// marker comment at the start of htmlStr, if there is one.
+func MarkerFingerprint(htmlStr string) (string, bool) {
+	s := strings.TrimLeft(htmlStr, " \t\r\n")
+	if !strings.HasPrefix(s, markerPrefix) {
+		return "", false
+	}
+	s = s[len(markerPrefix):]
+	end := strings.Index(s, markerSuffix)
+	if end < 0 {
+		return "", false
+	}
+	return s[:end], true
+}
+
+// NeedsResanitize reports whether htmlStr should be sanitized again
+// with p: either it carries no marker, or the marker was written by a
+// policy whose fingerprint differs from p's, which includes a policy
+// with another Version. It does not parse htmlStr, but it fingerprints
+// p on every call; to check stored content on every read, use the
+// NeedsResanitize method of a Sanitizer, which fingerprints its policy
+// once.
+func NeedsResanitize(htmlStr string, p *Policy) bool {
+	if p == nil {
+		p = nilPolicy()
+	}
+	fp, ok := MarkerFingerprint(htmlStr)
+	return !ok || fp != p.Fingerprint()
+}
+
+// NeedsResanitize is like the package-level NeedsResanitize with the
+// policy of s. It is cheap enough to call on every read of stored
+// content.
+func (s *Sanitizer) NeedsResanitize(htmlStr string) bool {
+	fp, ok := MarkerFingerprint(htmlStr)
+	return !ok || fp != s.c.policyFingerprint()
+}
@@ -0,0 +1,82 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestEmbedMarker_RoundTrip(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.EmbedMarker = true
+	got, err := htmlsanitizer.Sanitize(`<b>hi</b>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(got, "<b>hi</b>") {
+		t.Errorf("marker should precede content: %q", got)
+	}
+	if fp, ok := htmlsanitizer.MarkerFingerprint(got); !ok || fp != p.Fingerprint() {
+		t.Errorf("marker fingerprint %q, %v; want %q", fp, ok, p.Fingerprint())
+	}
+	if htmlsanitizer.NeedsResanitize(got, p) {
+		t.Errorf("content sanitized with p should not need re-sanitizing")
+	}
+
+	// Re-sanitizing replaces the marker rather than stacking another.
+	again, err := htmlsanitizer.Sanitize(got, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != got {
+		t.Errorf("re-sanitizing changed output: %q -> %q", got, again)
+	}
+}
+
+func TestNeedsResanitize_PolicyChanged(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.EmbedMarker = true
+	got, err := htmlsanitizer.Sanitize(`<b>hi</b>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.AllowedTags = append(p.AllowedTags, "mark")
+	if !htmlsanitizer.NeedsResanitize(got, p) {
+		t.Errorf("policy change should require re-sanitizing")
+	}
+	if !htmlsanitizer.NeedsResanitize(`<b>no marker</b>`, p) {
+		t.Errorf("content without a marker should require re-sanitizing")
+	}
+}
+
+func TestNeedsResanitize_VersionChanged(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.EmbedMarker = true
+	p.Version = "1"
+	got, err := htmlsanitizer.Sanitize(`<b>hi</b>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Version = "2" // say, after a change to one of its Transformers
+	if !htmlsanitizer.NeedsResanitize(got, p) {
+		t.Errorf("content cleaned under Version 1 should need re-sanitizing under Version 2")
+	}
+}
+
+func TestSanitizer_NeedsResanitize(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.EmbedMarker = true
+	s := htmlsanitizer.NewSanitizer(p)
+	got, err := s.Sanitize(`<b>hi</b>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NeedsResanitize(got) || !s.NeedsResanitize(`<b>hi</b>`) {
+		t.Errorf("NeedsResanitize disagrees with the marker in %q", got)
+	}
+	// The fingerprint is computed once, not on every check.
+	if n := testing.AllocsPerRun(100, func() { s.NeedsResanitize(got) }); n != 0 {
+		t.Errorf("NeedsResanitize allocates %v times per call", n)
+	}
+}
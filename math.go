@@ -0,0 +1,128 @@
+package htmlsanitizer
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// mathMLTags are the presentation MathML elements. annotation-xml is
+// left out: its content is parsed as HTML and is a known mutation XSS
+// vector.
+var mathMLTags = []string{
+	"math", "semantics", "annotation",
+	"mrow", "mi", "mo", "mn", "ms", "mtext", "mspace",
+	"msub", "msup", "msubsup", "munder", "mover", "munderover",
+	"mmultiscripts", "mprescripts", "none",
+	"mfrac", "msqrt", "mroot", "mstyle", "mpadded", "mphantom",
+	"menclose", "merror", "mtable", "mtr", "mtd", "mlabeledtr",
+}
+
+// mathMLAttrs are the presentation attributes kept on MathML elements.
+var mathMLAttrs = []string{
+	"mathvariant", "displaystyle", "scriptlevel",
+	"stretchy", "fence", "separator", "symmetric", "largeop", "movablelimits",
+	"accent", "accentunder", "form", "lspace", "rspace", "minsize", "maxsize",
+	"linethickness", "notation", "width", "height", "depth",
+	"columnalign", "columnspacing", "columnlines", "rowalign", "rowspacing", "rowlines",
+	"frame", "framespacing", "equalrows", "equalcolumns",
+}
+
+// mathStyleProps are the CSS properties KaTeX sets inline, all of which
+// take lengths.
+var mathStyleProps = map[string]bool{
+	"height": true, "width": true, "min-width": true, "max-width": true,
+	"top": true, "vertical-align": true, "border-bottom-width": true,
+	"margin-left": true, "margin-right": true, "padding-left": true,
+}
+
+// mathLength matches a plain CSS length.
+var mathLength = regexp.MustCompile(`^-?(?:\d+|\d*\.\d+)(?:em|ex|px|%)?$`)
+
+// MathPolicy returns a Policy that keeps the output of server-side
+// KaTeX and MathJax rendering on top of DefaultPolicy:
+//
+//   - KaTeX's HTML rendering: span classes, aria-hidden, inline
+//     styles limited to lengths on the box-model properties KaTeX
+//     uses, and the SVG paths it draws for radicals and arrows
+//   - MathML, including the TeX source in <annotation>
+//   - MathJax's <mjx-container>, reduced to its assistive MathML,
+//     since the CHTML and SVG glyphs depend on MathJax's stylesheet
+//     and fonts
+//
+// Scripts, event handlers, and other styles are removed as usual.
+func MathPolicy() *Policy {
+	p := DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, mathMLTags...)
+	p.AllowedTags = append(p.AllowedTags, "svg", "path", "line", "mjx-container")
+	for _, tag := range mathMLTags {
+		p.AllowedAttributes[tag] = mathMLAttrs
+	}
+	p.AllowedAttributes["math"] = append([]string{"xmlns", "display"}, mathMLAttrs...)
+	p.AllowedAttributes["annotation"] = []string{"encoding"}
+	p.AllowedAttributes["span"] = []string{"style", "aria-hidden"}
+	p.AllowedAttributes["svg"] = []string{"xmlns", "width", "height", "viewBox", "preserveAspectRatio", "style"}
+	p.AllowedAttributes["path"] = []string{"d"}
+	p.AllowedAttributes["line"] = []string{"x1", "y1", "x2", "y2", "stroke-width"}
+	p.AllowedAttributes["mjx-container"] = []string{"display"}
+	p.DisallowedActions = map[string]DisallowedAction{"annotation-xml": DisallowedStrip}
+	p.AttrTransformers = []AttrTransformer{mathStyle}
+	p.Transformers = []Transformer{mathJaxFallback}
+	return p
+}
+
+// mathStyle keeps only the length declarations of style attributes
+// that MathPolicy allows.
+func mathStyle(tag, key, val string) (string, bool) {
+	if key != "style" {
+		return val, true
+	}
+	var decls []string
+	for _, decl := range strings.Split(val, ";") {
+		prop, value, ok := strings.Cut(decl, ":")
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.TrimSpace(value)
+		if ok && mathStyleProps[prop] && mathLength.MatchString(value) {
+			decls = append(decls, prop+":"+value)
+		}
+	}
+	if len(decls) == 0 {
+		return "", false
+	}
+	return strings.Join(decls, ";"), true
+}
+
+// mathJaxFallback replaces the content of an <mjx-container> with the
+// MathML from its <mjx-assistive-mml>, and removes containers without
+// one.
+func mathJaxFallback(n *html.Node) *html.Node {
+	if n.Data != "mjx-container" {
+		return n
+	}
+	var math *html.Node
+	var find func(*html.Node)
+	find = func(c *html.Node) {
+		for c = c.FirstChild; c != nil && math == nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == "math" {
+				math = c
+			} else {
+				find(c)
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil && math == nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "mjx-assistive-mml" {
+			find(c)
+		}
+	}
+	if math == nil {
+		return nil
+	}
+	math.Parent.RemoveChild(math)
+	for c := n.FirstChild; c != nil; c = n.FirstChild {
+		n.RemoveChild(c)
+	}
+	n.AppendChild(math)
+	return n
+}
@@ -0,0 +1,48 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestMathPolicy_KaTeX(t *testing.T) {
+	input := `<span class="katex"><span class="katex-mathml"><math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><mrow><msqrt><mi>x</mi></msqrt></mrow><annotation encoding="application/x-tex">\sqrt{x}</annotation></semantics></math></span>` +
+		`<span class="katex-html" aria-hidden="true"><span class="strut" style="height:1.04em;vertical-align:-0.24em;background:url(x)"></span>` +
+		`<svg xmlns="http://www.w3.org/2000/svg" width="400em" height="1.08em" viewBox="0 0 400000 1080" onload="alert(1)"><path d="M95,702"></path></svg></span></span>`
+	want := `<span class="katex"><span class="katex-mathml"><math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><mrow><msqrt><mi>x</mi></msqrt></mrow><annotation encoding="application/x-tex">\sqrt{x}</annotation></semantics></math></span>` +
+		`<span class="katex-html" aria-hidden="true"><span class="strut" style="height:1.04em;vertical-align:-0.24em"></span>` +
+		`<svg xmlns="http://www.w3.org/2000/svg" width="400em" height="1.08em" viewBox="0 0 400000 1080"><path d="M95,702"></path></svg></span></span>`
+	got, err := htmlsanitizer.Sanitize(input, htmlsanitizer.MathPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestMathPolicy_MathJax(t *testing.T) {
+	input := `<mjx-container class="MathJax" jax="CHTML" display="true"><mjx-math class="MJX-TEX"><mjx-mi><mjx-c class="mjx-c1D465"></mjx-c></mjx-mi></mjx-math>` +
+		`<mjx-assistive-mml display="block"><math display="block"><mi>x</mi></math></mjx-assistive-mml></mjx-container>` +
+		`<mjx-container><mjx-math></mjx-math></mjx-container>`
+	want := `<mjx-container class="MathJax" display="true"><math display="block"><mi>x</mi></math></mjx-container>`
+	got, err := htmlsanitizer.Sanitize(input, htmlsanitizer.MathPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestMathPolicy_BlocksScripts(t *testing.T) {
+	input := `<math><mi onclick="x()">x</mi><annotation-xml encoding="text/html"><img src=x onerror=alert(1)></annotation-xml></math><script>alert(1)</script>`
+	got, err := htmlsanitizer.Sanitize(input, htmlsanitizer.MathPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<math><mi>x</mi></math>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package htmlsanitizer
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultLinkifyImageExtensions and DefaultLinkifyVideoExtensions are
+// used when Policy.LinkifyImageExtensions and
+// Policy.LinkifyVideoExtensions are nil.
+var (
+	DefaultLinkifyImageExtensions = []string{"avif", "gif", "jpeg", "jpg", "png", "webp"}
+	DefaultLinkifyVideoExtensions = []string{"mp4", "ogv", "webm"}
+)
+
+// linkifyImageExtensions returns the effective LinkifyImageExtensions.
+func (p *Policy) linkifyImageExtensions() []string {
+	if p.LinkifyImageExtensions == nil {
+		return DefaultLinkifyImageExtensions
+	}
+	return p.LinkifyImageExtensions
+}
+
+// linkifyVideoExtensions returns the effective LinkifyVideoExtensions.
+func (p *Policy) linkifyVideoExtensions() []string {
+	if p.LinkifyVideoExtensions == nil {
+		return DefaultLinkifyVideoExtensions
+	}
+	return p.LinkifyVideoExtensions
+}
+
+// onOwnLine reports whether text[start:end] has only spaces and tabs
+// between it and the line breaks, or the ends of text, around it.
+func onOwnLine(text string, start, end int) bool {
+	before := strings.TrimRight(text[:start], " \t")
+	after := strings.TrimLeft(text[end:], " \t")
+	return (before == "" || strings.HasSuffix(before, "\n")) &&
+		(after == "" || after[0] == '\n' || after[0] == '\r')
+}
+
+// embedMedia returns the element that embeds raw, a URL found by
+// Linkify, if it is an image or video on an allowed host that the
+// policy accepts as the src of an <img> or <video>.
+func (c *compiledPolicy) embedMedia(raw string) (textLink, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || !c.mediaHostAllowed(u.Hostname()) {
+		return textLink{}, false
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(u.Path), "."))
+	var tag string
+	switch {
+	case ext == "":
+		return textLink{}, false
+	case c.imageExts[ext]:
+		tag = "img"
+	case c.videoExts[ext]:
+		tag = "video"
+	default:
+		return textLink{}, false
+	}
+	if !c.allowsEmbed(tag) {
+		return textLink{}, false
+	}
+	src, ok := c.checkURL(tag, "src", raw)
+	if !ok {
+		return textLink{}, false
+	}
+	attrs := []html.Attribute{{Key: "src", Val: src}}
+	if tag == "img" {
+		attrs = append(attrs, html.Attribute{Key: "alt"})
+	} else {
+		attrs = append(attrs, html.Attribute{Key: "controls"})
+	}
+	return textLink{embed: tag, attrs: attrs}, true
+}
+
+// mediaHostAllowed reports whether host is in LinkifyMediaHosts, or a
+// subdomain of one of them, or whether the list is empty.
+func (c *compiledPolicy) mediaHostAllowed(host string) bool {
+	if len(c.p.LinkifyMediaHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, h := range c.p.LinkifyMediaHosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsEmbed reports whether the policy allows tag with a src, as
+// elements generated from text are.
+func (c *compiledPolicy) allowsEmbed(tag string) bool {
+	return c.allowedTags[tag] && c.attrAllowed("src", tag)
+}
@@ -0,0 +1,63 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestLinkify_Media(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, "video")
+	p.AllowedAttributes["video"] = []string{"src", "controls"}
+	p.Linkify = true
+	p.LinkifyMedia = true
+	p.LinkifyMediaHosts = []string{"media.example.com"}
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"image", "<p>https://media.example.com/a/cat.PNG</p>",
+			`<p><img src="https://media.example.com/a/cat.PNG" alt="" /></p>`},
+		{"video on its own line", "look:\nhttps://cdn.media.example.com/clip.mp4\nnice",
+			"look:\n<video src=\"https://cdn.media.example.com/clip.mp4\" controls></video>\nnice"},
+		{"inline", "<p>see https://media.example.com/cat.png here</p>",
+			`<p>see <a href="https://media.example.com/cat.png" rel="noopener noreferrer">https://media.example.com/cat.png</a> here</p>`},
+		{"other host", "<p>https://other.example/cat.png</p>",
+			`<p><a href="https://other.example/cat.png" rel="noopener noreferrer">https://other.example/cat.png</a></p>`},
+		{"not media", "<p>https://media.example.com/page.html</p>",
+			`<p><a href="https://media.example.com/page.html" rel="noopener noreferrer">https://media.example.com/page.html</a></p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlsanitizer.Sanitize(tt.in, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+			q := p.Clone()
+			q.PreserveFormatting = true
+			if got, _ := htmlsanitizer.Sanitize(tt.in, q); got != tt.want {
+				t.Errorf("PreserveFormatting: got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkify_MediaNotAllowed(t *testing.T) {
+	p := htmlsanitizer.StrictPolicy()
+	p.AllowedTags = append(p.AllowedTags, "a")
+	p.AllowedAttributes["a"] = []string{"href"}
+	p.Linkify = true
+	p.LinkifyMedia = true
+	got, err := htmlsanitizer.Sanitize("https://example.com/clip.webm", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a href="https://example.com/clip.webm" rel="noopener noreferrer">https://example.com/clip.webm</a>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
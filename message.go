@@ -0,0 +1,48 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMessageTooLarge is returned by MessageSanitizer for messages
+// longer than its size limit.
+var ErrMessageTooLarge = errors.New("htmlsanitizer: message exceeds size limit")
+
+// MessageSanitizer sanitizes the individual messages of streaming
+// protocols, such as chat messages sent over a WebSocket or the data of
+// server-sent events. Each message is sanitized on its own, with a
+// policy compiled once, and messages without markup are returned
+// without being parsed. A MessageSanitizer is safe for concurrent use.
+type MessageSanitizer struct {
+	c       *compiledPolicy
+	maxSize int
+}
+
+// NewMessageSanitizer returns a MessageSanitizer applying a copy of p
+// to messages of at most maxSize bytes; zero means no limit. If p is
+// nil, the default policy is used.
+func NewMessageSanitizer(p *Policy, maxSize int) *MessageSanitizer {
+	if p == nil {
+		p = nilPolicy()
+	}
+	return &MessageSanitizer{c: compilePolicy(clonePolicy(p)), maxSize: maxSize}
+}
+
+// Sanitize sanitizes msg, or fails with ErrMessageTooLarge.
+func (m *MessageSanitizer) Sanitize(msg string) (string, error) {
+	if m.maxSize > 0 && len(msg) > m.maxSize {
+		return "", ErrMessageTooLarge
+	}
+	return sanitizeString(context.Background(), msg, m.c, nil)
+}
+
+// SanitizeBytes is like Sanitize for the []byte payloads of WebSocket
+// libraries.
+func (m *MessageSanitizer) SanitizeBytes(msg []byte) ([]byte, error) {
+	out, err := m.Sanitize(string(msg))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
@@ -0,0 +1,68 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestMessageSanitizer(t *testing.T) {
+	m := htmlsanitizer.NewMessageSanitizer(nil, 64)
+	got, err := m.Sanitize(`hi <img src=x onerror=alert(1)>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `hi <img src="x" />`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	b, err := m.SanitizeBytes([]byte(`<b>bold</b>`))
+	if err != nil || string(b) != `<b>bold</b>` {
+		t.Errorf("SanitizeBytes = %q, %v", b, err)
+	}
+	if _, err := m.Sanitize(string(make([]byte, 65))); !errors.Is(err, htmlsanitizer.ErrMessageTooLarge) {
+		t.Errorf("oversized: err = %v", err)
+	}
+}
+
+// Plain-text messages skip the parser; their output must not differ
+// from parsing them.
+func TestMessageSanitizer_PlainTextMatchesParser(t *testing.T) {
+	linkify := htmlsanitizer.DefaultPolicy()
+	linkify.Linkify = true
+	limited := htmlsanitizer.DefaultPolicy()
+	limited.MaxOutputLength = 8
+	policies := map[string]*htmlsanitizer.Policy{"default": nil, "linkify": linkify, "limited": limited}
+	inputs := []string{
+		"", " ", "hello", "  leading", "\n\ttrailing \n", "a > b \"q\" 'x'", "x\fy",
+		"\xff\xfe invalid", "café \U0001F600", "line\r\nbreak", "nul\x00byte",
+		"see https://go.dev now", "fish & chips", "1 < 2", "too long for the limit",
+	}
+	for name, p := range policies {
+		m := htmlsanitizer.NewMessageSanitizer(p, 0)
+		for _, in := range inputs {
+			want, wantErr := htmlsanitizer.Sanitize(in, p)
+			got, err := m.Sanitize(in)
+			if got != want || (err == nil) != (wantErr == nil) {
+				t.Errorf("%s: Sanitize(%q) = %q, %v; parser gives %q, %v", name, in, got, err, want, wantErr)
+			}
+		}
+	}
+}
+
+func BenchmarkMessageSanitizer(b *testing.B) {
+	m := htmlsanitizer.NewMessageSanitizer(nil, 4096)
+	for _, bm := range []struct{ name, msg string }{
+		{"PlainText", "sounds good, see you at 5 -> usual place?"},
+		{"Markup", "sounds <b>good</b>, see you at 5 -> usual place?"},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := m.Sanitize(bm.msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
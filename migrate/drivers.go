@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// ErrMissingColumn is returned by the Sources for a record, or a CSV
+// header, that lacks the ID or HTML column.
+var ErrMissingColumn = errors.New("migrate: missing column")
+
+// NewCSVSource returns a Source that reads records from CSV with a
+// header row, taking their ID and HTML from the columns named idColumn
+// and htmlColumn.
+func NewCSVSource(r io.Reader, idColumn, htmlColumn string) (Source, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	s := &csvSource{r: cr, id: slices.Index(header, idColumn), html: slices.Index(header, htmlColumn)}
+	if s.id < 0 || s.html < 0 {
+		return nil, fmt.Errorf("%w in CSV header: want %q and %q", ErrMissingColumn, idColumn, htmlColumn)
+	}
+	return s, nil
+}
+
+type csvSource struct {
+	r        *csv.Reader
+	id, html int
+}
+
+func (s *csvSource) Next(ctx context.Context) (Record, error) {
+	row, err := s.r.Read()
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{ID: row[s.id], HTML: row[s.html]}, nil
+}
+
+// NewNDJSONSource returns a Source that reads records from
+// newline-delimited JSON objects, taking their ID and HTML from the
+// fields named idField and htmlField. Numeric IDs are read as their
+// decimal text; blank lines are skipped.
+func NewNDJSONSource(r io.Reader, idField, htmlField string) Source {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxNDJSONLine)
+	return &ndjsonSource{sc: sc, id: idField, html: htmlField}
+}
+
+// maxNDJSONLine bounds the length of an NDJSON record.
+const maxNDJSONLine = 64 << 20
+
+type ndjsonSource struct {
+	sc       *bufio.Scanner
+	id, html string
+	line     int
+}
+
+func (s *ndjsonSource) Next(ctx context.Context) (Record, error) {
+	for s.sc.Scan() {
+		s.line++
+		line := bytes.TrimSpace(s.sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return Record{}, fmt.Errorf("migrate: line %d: %w", s.line, err)
+		}
+		var rec Record
+		if id, ok := fields[s.id]; !ok {
+			return Record{}, fmt.Errorf("%w %q on line %d", ErrMissingColumn, s.id, s.line)
+		} else if err := json.Unmarshal(id, &rec.ID); err != nil {
+			var n json.Number
+			if json.Unmarshal(id, &n) != nil {
+				return Record{}, fmt.Errorf("migrate: line %d: %q is neither a string nor a number", s.line, s.id)
+			}
+			rec.ID = n.String()
+		}
+		if html, ok := fields[s.html]; !ok {
+			return Record{}, fmt.Errorf("%w %q on line %d", ErrMissingColumn, s.html, s.line)
+		} else if err := json.Unmarshal(html, &rec.HTML); err != nil {
+			return Record{}, fmt.Errorf("migrate: line %d: %q: %w", s.line, s.html, err)
+		}
+		return rec, nil
+	}
+	if err := s.sc.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// NewRowsSource returns a Source that reads records from rows, a
+// database cursor whose first two columns are the ID and the HTML. It
+// closes rows when they are exhausted. To resume a large table without
+// reading past the migrated rows, query them in key order and wrap the
+// source in a Seeker that queries again after the checkpointed key.
+func NewRowsSource(rows *sql.Rows) Source {
+	return &rowsSource{rows: rows}
+}
+
+type rowsSource struct {
+	rows *sql.Rows
+}
+
+func (s *rowsSource) Next(ctx context.Context) (Record, error) {
+	if !s.rows.Next() {
+		if err := s.rows.Err(); err != nil {
+			return Record{}, err
+		}
+		if err := s.rows.Close(); err != nil {
+			return Record{}, err
+		}
+		return Record{}, io.EOF
+	}
+	var rec Record
+	var html sql.NullString
+	if err := s.rows.Scan(&rec.ID, &html); err != nil {
+		return Record{}, err
+	}
+	rec.HTML = html.String
+	return rec, nil
+}
+
+// NewNDJSONSink returns a Sink that writes results to w as
+// newline-delimited JSON objects with the fields id, html, changed,
+// removed_elements, removed_attributes, issues, warnings, held, and,
+// for failed records, error.
+func NewNDJSONSink(w io.Writer) Sink {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &ndjsonSink{w: bw, enc: enc}
+}
+
+type ndjsonSink struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// resultJSON is the form of a Result in NDJSON output.
+type resultJSON struct {
+	ID                string   `json:"id"`
+	HTML              string   `json:"html"`
+	Changed           bool     `json:"changed"`
+	RemovedElements   int      `json:"removed_elements"`
+	RemovedAttributes int      `json:"removed_attributes"`
+	Issues            []string `json:"issues,omitempty"`
+	Warnings          []string `json:"warnings,omitempty"`
+	Held              bool     `json:"held,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+func (s *ndjsonSink) Write(ctx context.Context, r Result) error {
+	out := resultJSON{ID: r.ID, HTML: r.HTML, Changed: r.Changed}
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	}
+	if rep := r.Report; rep != nil {
+		out.RemovedElements, out.RemovedAttributes = rep.RemovedElements, rep.RemovedAttributes
+		out.Warnings, out.Held = rep.Warnings, rep.Held
+		for _, issue := range rep.Issues {
+			out.Issues = append(out.Issues, issue.Error())
+		}
+	}
+	return s.enc.Encode(out)
+}
+
+func (s *ndjsonSink) Flush() error {
+	return s.w.Flush()
+}
+
+// NewCSVSink returns a Sink that writes results to w as CSV with the
+// columns id, html, changed, removed_elements, removed_attributes,
+// issues, and error, preceded by a header row unless header is false,
+// as it is when appending to the output of an interrupted run.
+func NewCSVSink(w io.Writer, header bool) Sink {
+	return &csvSink{w: csv.NewWriter(w), header: header}
+}
+
+type csvSink struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (s *csvSink) Write(ctx context.Context, r Result) error {
+	if s.header {
+		s.header = false
+		if err := s.w.Write([]string{"id", "html", "changed", "removed_elements", "removed_attributes", "issues", "error"}); err != nil {
+			return err
+		}
+	}
+	var removedElements, removedAttrs, issues int
+	if rep := r.Report; rep != nil {
+		removedElements, removedAttrs, issues = rep.RemovedElements, rep.RemovedAttributes, len(rep.Issues)
+	}
+	var errText string
+	if r.Err != nil {
+		errText = r.Err.Error()
+	}
+	return s.w.Write([]string{
+		r.ID, r.HTML, strconv.FormatBool(r.Changed),
+		strconv.Itoa(removedElements), strconv.Itoa(removedAttrs), strconv.Itoa(issues), errText,
+	})
+}
+
+func (s *csvSink) Flush() error {
+	s.w.Flush()
+	return s.w.Error()
+}
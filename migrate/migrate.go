@@ -0,0 +1,267 @@
+// Package migrate runs one-time cleanups of stored content with an
+// htmlsanitizer policy. Run reads records from a Source, such as a CSV
+// or NDJSON export or a database cursor, sanitizes their HTML, and
+// writes each result with a report of what changed to a Sink. Progress
+// is saved to a Checkpoint, so that a run that is interrupted resumes
+// where it stopped instead of starting over:
+//
+//	src := migrate.NewNDJSONSource(in, "id", "body")
+//	sink := migrate.NewNDJSONSink(out)
+//	progress, err := migrate.Run(ctx, src, sink, migrate.Options{
+//		Policy:     htmlsanitizer.DefaultPolicy(),
+//		Checkpoint: migrate.FileCheckpoint("posts.checkpoint"),
+//	})
+//
+// Results are written at least once: those written after the last
+// checkpoint of an interrupted run are written again when it resumes,
+// so consumers should keep the last result for each ID.
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+// DefaultCheckpointEvery is the number of records between checkpoints
+// when Options.CheckpointEvery is zero.
+const DefaultCheckpointEvery = 100
+
+// Record is a piece of content to migrate.
+type Record struct {
+	// ID identifies the record in its source, such as a primary key.
+	ID string
+
+	// HTML is the content to sanitize.
+	HTML string
+}
+
+// Result is a migrated record.
+type Result struct {
+	ID string
+
+	// HTML is the sanitized content, or "" if sanitizing failed.
+	HTML string
+
+	// Changed reports whether HTML differs from the input.
+	Changed bool
+
+	// Report describes what sanitizing removed. It is nil if
+	// sanitizing failed.
+	Report *htmlsanitizer.Report
+
+	// Err is the error sanitizing failed with, such as a limit of the
+	// policy that the record exceeds, or nil.
+	Err error
+}
+
+// Source yields the records to migrate, in the same order on every
+// run. It is the driver interface for the formats and databases that
+// content is read from.
+type Source interface {
+	// Next returns the next record, or io.EOF after the last one.
+	Next(ctx context.Context) (Record, error)
+}
+
+// Seeker is implemented by Sources that can resume after the record
+// with a given ID without reading the records before it, such as
+// database cursors that query by key. Run reads past the records of
+// other Sources.
+type Seeker interface {
+	SeekAfter(ctx context.Context, id string) error
+}
+
+// Sink receives the results of a run.
+type Sink interface {
+	Write(ctx context.Context, r Result) error
+}
+
+// Flusher is implemented by Sinks that buffer their output. Run flushes
+// them before every checkpoint, so that a checkpoint never covers
+// results that were lost.
+type Flusher interface {
+	Flush() error
+}
+
+// Progress describes how far a run has got.
+type Progress struct {
+	// Processed counts the records read, including those of earlier
+	// runs that this one resumed.
+	Processed int `json:"processed"`
+
+	// Changed and Failed count the records whose HTML the policy
+	// changed and those it could not sanitize.
+	Changed int `json:"changed"`
+	Failed  int `json:"failed"`
+
+	// LastID is the ID of the last record read.
+	LastID string `json:"last_id"`
+}
+
+// Checkpoint stores the Progress of a run.
+type Checkpoint interface {
+	// Load returns the saved progress, or the zero Progress if there
+	// is none.
+	Load() (Progress, error)
+
+	// Save replaces the saved progress.
+	Save(Progress) error
+}
+
+// FileCheckpoint returns a Checkpoint that keeps progress as JSON in
+// the file at path. Saving writes a temporary file and renames it over
+// path, so that a crash leaves the previous checkpoint intact.
+func FileCheckpoint(path string) Checkpoint {
+	return fileCheckpoint(path)
+}
+
+type fileCheckpoint string
+
+func (f fileCheckpoint) Load() (Progress, error) {
+	var p Progress
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	err = json.Unmarshal(data, &p)
+	return p, err
+}
+
+func (f fileCheckpoint) Save(p Progress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(string(f)), filepath.Base(string(f))+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
+
+// Options configure Run.
+type Options struct {
+	// Policy is applied to every record. If it is nil, the default
+	// policy is used.
+	Policy *htmlsanitizer.Policy
+
+	// Checkpoint, if set, is loaded to resume an earlier run and saved
+	// as the run proceeds and when it ends.
+	Checkpoint Checkpoint
+
+	// CheckpointEvery is the number of records between checkpoints.
+	// Zero means DefaultCheckpointEvery.
+	CheckpointEvery int
+
+	// Progress, if set, is called after every checkpoint, and at the
+	// end of the run, whether or not Checkpoint is set.
+	Progress func(Progress)
+}
+
+// Run migrates the records of src into dst and returns the progress
+// made. Records that cannot be sanitized are written with their error
+// and counted as failed; errors reading src or writing dst stop the
+// run. A run that stops, or whose ctx is canceled, saves a checkpoint
+// of the records written so far before it returns.
+func Run(ctx context.Context, src Source, dst Sink, opts Options) (Progress, error) {
+	var progress Progress
+	if opts.Checkpoint != nil {
+		var err error
+		if progress, err = opts.Checkpoint.Load(); err != nil {
+			return progress, err
+		}
+		if err := resume(ctx, src, progress); err != nil {
+			return progress, err
+		}
+	}
+	every := opts.CheckpointEvery
+	if every <= 0 {
+		every = DefaultCheckpointEvery
+	}
+	s := htmlsanitizer.NewSanitizer(opts.Policy)
+
+	saved := progress.Processed
+	checkpoint := func() error {
+		if f, ok := dst.(Flusher); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+		if opts.Checkpoint != nil && progress.Processed != saved {
+			if err := opts.Checkpoint.Save(progress); err != nil {
+				return err
+			}
+			saved = progress.Processed
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, errors.Join(err, checkpoint())
+		}
+		rec, err := src.Next(ctx)
+		if err == io.EOF {
+			return progress, checkpoint()
+		}
+		if err != nil {
+			return progress, errors.Join(err, checkpoint())
+		}
+		res := Result{ID: rec.ID}
+		if res.HTML, res.Report, res.Err = s.SanitizeWithReport(rec.HTML); res.Err != nil {
+			res.HTML, res.Report = "", nil
+			progress.Failed++
+		} else if res.Changed = res.HTML != rec.HTML; res.Changed {
+			progress.Changed++
+		}
+		if err := dst.Write(ctx, res); err != nil {
+			return progress, errors.Join(err, checkpoint())
+		}
+		progress.Processed++
+		progress.LastID = rec.ID
+		if progress.Processed%every == 0 {
+			if err := checkpoint(); err != nil {
+				return progress, err
+			}
+		}
+	}
+}
+
+// resume positions src after the records that progress covers.
+func resume(ctx context.Context, src Source, progress Progress) error {
+	if progress.Processed == 0 {
+		return nil
+	}
+	if s, ok := src.(Seeker); ok {
+		return s.SeekAfter(ctx, progress.LastID)
+	}
+	for i := 0; i < progress.Processed; i++ {
+		if _, err := src.Next(ctx); err == io.EOF {
+			return fmt.Errorf("migrate: source ended before the %d records of the checkpoint", progress.Processed)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"github.com/njchilds90/htmlsanitizer/migrate"
+)
+
+const input = `{"id": 1, "body": "<p>one</p>"}
+{"id": 2, "body": "<p onclick=\"x()\">two</p><script>x()</script>"}
+
+{"id": "three", "body": "<b>three</b>"}
+`
+
+// failingSink fails on the record with the ID fail.
+type failingSink struct {
+	results []migrate.Result
+	fail    string
+}
+
+func (s *failingSink) Write(ctx context.Context, r migrate.Result) error {
+	if r.ID == s.fail {
+		return errors.New("disk full")
+	}
+	s.results = append(s.results, r)
+	return nil
+}
+
+func TestRun_NDJSON(t *testing.T) {
+	var out bytes.Buffer
+	progress, err := migrate.Run(context.Background(),
+		migrate.NewNDJSONSource(strings.NewReader(input), "id", "body"),
+		migrate.NewNDJSONSink(&out), migrate.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (migrate.Progress{Processed: 3, Changed: 1, LastID: "three"}); progress != want {
+		t.Errorf("progress = %+v, want %+v", progress, want)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines:\n%s", len(lines), out.String())
+	}
+	var second struct {
+		ID                string `json:"id"`
+		HTML              string `json:"html"`
+		Changed           bool   `json:"changed"`
+		RemovedElements   int    `json:"removed_elements"`
+		RemovedAttributes int    `json:"removed_attributes"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != "2" || second.HTML != "<p>two</p>" || !second.Changed ||
+		second.RemovedElements != 1 || second.RemovedAttributes != 1 {
+		t.Errorf("second result = %+v", second)
+	}
+}
+
+func TestRun_CSV(t *testing.T) {
+	in := "id,body\n1,<p>one</p>\n2,\"<i onclick=\"\"x\"\">two</i>\"\n"
+	src, err := migrate.NewCSVSource(strings.NewReader(in), "id", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := migrate.Run(context.Background(), src, migrate.NewCSVSink(&out, true), migrate.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,html,changed,removed_elements,removed_attributes,issues,error\n" +
+		"1,<p>one</p>,false,0,0,0,\n" +
+		"2,<i>two</i>,true,0,1,0,\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	if _, err := migrate.NewCSVSource(strings.NewReader("key,html\n"), "id", "body"); !errors.Is(err, migrate.ErrMissingColumn) {
+		t.Errorf("missing columns: err = %v, want ErrMissingColumn", err)
+	}
+}
+
+func TestRun_Failed(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxInputSize = 12
+	sink := &failingSink{}
+	progress, err := migrate.Run(context.Background(),
+		migrate.NewNDJSONSource(strings.NewReader(input), "id", "body"),
+		sink, migrate.Options{Policy: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Failed != 1 || sink.results[1].Err == nil || sink.results[1].HTML != "" {
+		t.Errorf("progress = %+v, second result = %+v", progress, sink.results[1])
+	}
+}
+
+func TestRun_Resume(t *testing.T) {
+	checkpoint := migrate.FileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+	opts := migrate.Options{Checkpoint: checkpoint, CheckpointEvery: 1}
+
+	sink := &failingSink{fail: "three"}
+	_, err := migrate.Run(context.Background(), migrate.NewNDJSONSource(strings.NewReader(input), "id", "body"), sink, opts)
+	if err == nil {
+		t.Fatal("Run succeeded despite the failing sink")
+	}
+	saved, err := checkpoint.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Processed != 2 || saved.LastID != "2" {
+		t.Fatalf("checkpoint = %+v, want 2 records up to ID 2", saved)
+	}
+
+	sink = &failingSink{}
+	progress, err := migrate.Run(context.Background(), migrate.NewNDJSONSource(strings.NewReader(input), "id", "body"), sink, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.results) != 1 || sink.results[0].ID != "three" {
+		t.Errorf("resumed run wrote %+v, want only record three", sink.results)
+	}
+	if progress.Processed != 3 || progress.Changed != 1 {
+		t.Errorf("progress = %+v", progress)
+	}
+
+	// A source shorter than the checkpoint is not the one it was
+	// taken from.
+	short := migrate.NewNDJSONSource(strings.NewReader(`{"id": 1, "body": ""}`), "id", "body")
+	if _, err := migrate.Run(context.Background(), short, sink, opts); err == nil {
+		t.Error("Run resumed a source shorter than the checkpoint")
+	}
+}
@@ -0,0 +1,49 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// idRefAttrs are the attributes holding space-separated lists of ids.
+var idRefAttrs = map[string]bool{
+	"for": true, "headers": true, "aria-labelledby": true,
+	"aria-describedby": true, "aria-controls": true, "aria-owns": true,
+	"aria-activedescendant": true, "aria-details": true, "aria-errormessage": true,
+	"aria-flowto": true, "list": true,
+}
+
+// namespaceAttrs applies Policy.Namespace to attrs in place.
+func (c *compiledPolicy) namespaceAttrs(attrs []html.Attribute) []html.Attribute {
+	prefix := c.p.Namespace
+	if prefix == "" {
+		return attrs
+	}
+	for i, a := range attrs {
+		switch key := strings.ToLower(a.Key); {
+		case key == "id":
+			attrs[i].Val = withPrefix(prefix, a.Val)
+		case key == "class" || idRefAttrs[key]:
+			names := strings.Fields(a.Val)
+			for j, name := range names {
+				names[j] = withPrefix(prefix, name)
+			}
+			attrs[i].Val = strings.Join(names, " ")
+		case key == "href" || key == "usemap":
+			if frag, ok := strings.CutPrefix(a.Val, "#"); ok && frag != "" {
+				attrs[i].Val = "#" + withPrefix(prefix, frag)
+			}
+		}
+	}
+	return attrs
+}
+
+// withPrefix returns name with prefix added, unless it already starts
+// with it, so that sanitizing twice does not prefix twice.
+func withPrefix(prefix, name string) string {
+	if name == "" || strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
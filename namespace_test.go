@@ -0,0 +1,34 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestNamespace(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Namespace = "uc-"
+	p.AllowedAttributes["td"] = append(p.AllowedAttributes["td"], "headers")
+	input := `<h2 id="intro" class="big  red">Intro</h2><a href="#intro">up</a> <a href="https://example.com/#x">out</a>` +
+		`<table><tr><th id="h">H</th></tr><tr><td headers="h uc-k">1</td></tr></table><p class="uc-done">x</p>`
+	want := `<h2 id="uc-intro" class="uc-big uc-red">Intro</h2><a href="#uc-intro">up</a> <a href="https://example.com/#x">out</a>` +
+		`<table><tbody><tr><th id="uc-h">H</th></tr><tr><td headers="uc-h uc-k">1</td></tr></tbody></table><p class="uc-done">x</p>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	again, err := htmlsanitizer.Sanitize(got, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != got {
+		t.Errorf("not idempotent: %q", again)
+	}
+	if got := sanitizeTokens(t, `<a href="#top" id="a">x</a>`, p); got != `<a href="#uc-top" id="uc-a">x</a>` {
+		t.Errorf("tokens: got %q", got)
+	}
+}
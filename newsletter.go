@@ -0,0 +1,127 @@
+package htmlsanitizer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// emailUnsupportedTags are elements that Gmail or Outlook drop or fail
+// to render.
+var emailUnsupportedTags = map[string]bool{
+	"style": true, "link": true, "script": true,
+	"form": true, "input": true, "button": true, "select": true, "textarea": true,
+	"video": true, "audio": true, "svg": true, "canvas": true,
+	"iframe": true, "object": true, "embed": true,
+}
+
+// emailLayoutTags are block containers that Outlook lays out poorly.
+var emailLayoutTags = map[string]bool{
+	"div": true, "section": true, "article": true, "header": true,
+	"footer": true, "nav": true, "aside": true, "main": true,
+}
+
+// emailUnsupportedCSS are CSS fragments Outlook ignores.
+var emailUnsupportedCSS = []string{
+	"position", "float", "display:flex", "display:grid", "background-image",
+}
+
+// NewsletterPolicy returns a Policy that prepares HTML for sending as
+// e-mail. Layout is restricted to tables and simple blocks, with other
+// containers unwrapped. The class attribute is replaced by an inline
+// style built from classStyles, which maps class names to trusted CSS
+// declarations; class names match case-insensitively. Classes not in
+// the map are dropped, as are style attributes in the input.
+//
+// Run it through SanitizeWithReport to receive warnings about input
+// that Gmail or Outlook would strip or render differently.
+func NewsletterPolicy(classStyles map[string]string) *Policy {
+	styles := make(map[string]string, len(classStyles))
+	for name, css := range classStyles {
+		styles[strings.ToLower(name)] = css
+	}
+	return &Policy{
+		AllowedTags: []string{
+			"table", "thead", "tbody", "tfoot", "tr", "td", "th",
+			"h1", "h2", "h3", "h4", "p", "br", "hr",
+			"a", "img", "span",
+			"b", "strong", "i", "em", "u", "s",
+			"ul", "ol", "li", "blockquote",
+		},
+		AllowedAttributes: map[string][]string{
+			"table": {"width", "align", "border", "cellpadding", "cellspacing", "bgcolor", "role"},
+			"td":    {"width", "height", "align", "valign", "colspan", "rowspan", "bgcolor"},
+			"th":    {"width", "height", "align", "valign", "colspan", "rowspan", "bgcolor"},
+			"img":   {"src", "alt", "width", "height", "border"},
+			"a":     {"href", "title", "target"},
+			"*":     {"class", "dir", "lang"},
+		},
+		AllowedSchemes:    []string{"http", "https", "mailto"},
+		DisallowedActions: map[string]DisallowedAction{"*": DisallowedUnwrap},
+		Transformers:      []Transformer{inlineClasses(styles)},
+		Warn:              emailWarnings(styles),
+	}
+}
+
+// inlineClasses returns a Transformer that replaces the class attribute
+// with the styles its classes map to. The keys of classStyles are lower
+// case.
+func inlineClasses(classStyles map[string]string) Transformer {
+	return func(n *html.Node) *html.Node {
+		class := GetAttr(n, "class")
+		if class == "" {
+			return n
+		}
+		RemoveAttr(n, "class")
+		var decls []string
+		for _, name := range strings.Fields(class) {
+			if css := strings.Trim(strings.TrimSpace(classStyles[strings.ToLower(name)]), ";"); css != "" {
+				decls = append(decls, css)
+			}
+		}
+		if len(decls) > 0 {
+			SetAttr(n, "style", strings.Join(decls, "; "))
+		}
+		return n
+	}
+}
+
+// emailWarnings returns a Policy.Warn function reporting constructs that
+// common e-mail clients strip or mis-render.
+func emailWarnings(classStyles map[string]string) func(*html.Node) []string {
+	return func(n *html.Node) []string {
+		tag := strings.ToLower(n.Data)
+		var warnings []string
+		switch {
+		case emailUnsupportedTags[tag] && (isVoidElement(tag) || slices.Contains(DefaultDropContentTags, tag)):
+			warnings = append(warnings, fmt.Sprintf("<%s> is not supported by Gmail or Outlook and was removed", tag))
+		case emailUnsupportedTags[tag]:
+			// NewsletterPolicy unwraps every other disallowed element.
+			warnings = append(warnings, fmt.Sprintf("<%s> is not supported by Gmail or Outlook and was unwrapped, keeping its content", tag))
+		case emailLayoutTags[tag]:
+			warnings = append(warnings, fmt.Sprintf("<%s> was unwrapped; Outlook needs table-based layout", tag))
+		case tag == "img" && GetAttr(n, "width") == "":
+			warnings = append(warnings, "<img> without a width attribute is shown at full size in Outlook")
+		}
+		if GetAttr(n, "style") != "" {
+			warnings = append(warnings, fmt.Sprintf("style attribute on <%s> was removed; use a mapped class", tag))
+		}
+		for _, name := range strings.Fields(GetAttr(n, "class")) {
+			css := strings.ToLower(strings.Join(strings.Fields(classStyles[strings.ToLower(name)]), ""))
+			var bad []string
+			for _, prop := range emailUnsupportedCSS {
+				if strings.Contains(css, prop) {
+					bad = append(bad, prop)
+				}
+			}
+			if len(bad) > 0 {
+				sort.Strings(bad)
+				warnings = append(warnings, fmt.Sprintf("class %q uses %s, which Outlook ignores", name, strings.Join(bad, ", ")))
+			}
+		}
+		return warnings
+	}
+}
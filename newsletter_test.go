@@ -0,0 +1,42 @@
+package htmlsanitizer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestNewsletterPolicy(t *testing.T) {
+	p := htmlsanitizer.NewsletterPolicy(map[string]string{
+		"btn":  "background:#06c; color:#fff;",
+		"big":  "font-size:20px",
+		"side": "float: left",
+	})
+	input := `<div><table width="600" style="position:absolute"><tr><td class="btn big unknown">` +
+		`<a href="https://example.com/">Buy</a></td></tr></table>` +
+		`<form><input name="q"></form><video>Watch</video><script>x()</script><img src="https://example.com/a.png"><p class="side">x</p></div>`
+	got, rep, err := htmlsanitizer.SanitizeWithReport(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<table width="600"><tbody><tr><td style="background:#06c; color:#fff; font-size:20px">` +
+		`<a href="https://example.com/">Buy</a></td></tr></tbody></table>` +
+		`Watch<img src="https://example.com/a.png" /><p style="float: left">x</p>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	wantWarnings := []string{
+		"<div> was unwrapped; Outlook needs table-based layout",
+		"style attribute on <table> was removed; use a mapped class",
+		"<form> is not supported by Gmail or Outlook and was unwrapped, keeping its content",
+		"<input> is not supported by Gmail or Outlook and was removed",
+		"<video> is not supported by Gmail or Outlook and was unwrapped, keeping its content",
+		"<script> is not supported by Gmail or Outlook and was removed",
+		"<img> without a width attribute is shown at full size in Outlook",
+		`class "side" uses float, which Outlook ignores`,
+	}
+	if !reflect.DeepEqual(rep.Warnings, wantWarnings) {
+		t.Errorf("warnings:\ngot  %q\nwant %q", rep.Warnings, wantWarnings)
+	}
+}
@@ -0,0 +1,139 @@
+package htmlsanitizer
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNotAllowed is the kind of the Issue returned by NodeBuilder when a
+// node it is asked to build or place is not allowed by the policy.
+var ErrNotAllowed = errors.New("not allowed by policy")
+
+// NodeBuilder constructs and places nodes for Transformers, checking
+// each step against a policy, so that a transformer cannot introduce an
+// element or attribute that would then be silently removed, or content
+// that is not escaped. Failures are reported as an *Issue whose Err is
+// ErrNotAllowed or ErrURLRejected.
+//
+// A NodeBuilder checks against p as it was when NewNodeBuilder was
+// called. It is safe for concurrent use.
+type NodeBuilder struct {
+	c *compiledPolicy
+}
+
+// NewNodeBuilder returns a NodeBuilder for p. If p is nil,
+// the default policy is used.
+func NewNodeBuilder(p *Policy) *NodeBuilder {
+	if p == nil {
+		p = nilPolicy()
+	}
+	return &NodeBuilder{c: compilePolicy(clonePolicy(p))}
+}
+
+// NewElement returns a new element with the given attributes. It fails
+// if the policy does not allow the tag or one of the attributes, or if
+// a URL attribute does not validate. Attribute values are normalized as
+// the sanitizer would normalize them.
+func (b *NodeBuilder) NewElement(tag string, attrs ...html.Attribute) (*html.Node, error) {
+	tag = strings.ToLower(tag)
+	if _, raw := b.c.rawText[tag]; raw || !b.c.allowedTags[tag] {
+		return nil, &Issue{Err: ErrNotAllowed, Element: tag}
+	}
+	n := &html.Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag))}
+	for _, a := range attrs {
+		rep := &Report{}
+		kept := b.c.filterAttrs(tag, []html.Attribute{a}, rep)
+		switch {
+		case len(rep.Issues) > 0:
+			return nil, rep.Issues[0]
+		case len(kept) == 0:
+			return nil, &Issue{Err: ErrNotAllowed, Element: tag, Attribute: a.Key}
+		}
+		n.Attr = append(n.Attr, kept[0])
+	}
+	return n, nil
+}
+
+// NewText returns a text node holding s. Text is always escaped when it
+// is rendered, so s cannot introduce markup.
+func (b *NodeBuilder) NewText(s string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: s}
+}
+
+// AppendChild adds child, which must not be in a tree, as the last
+// child of parent. It fails if the policy's ContextRules or
+// AllowedParents do not allow child inside parent.
+func (b *NodeBuilder) AppendChild(parent, child *html.Node) error {
+	if err := b.checkPlacement(child, parent); err != nil {
+		return err
+	}
+	parent.AppendChild(child)
+	return nil
+}
+
+// Wrap moves n into wrapper, an element built by NewElement that is not
+// in a tree, and puts wrapper in the place of n. It fails if the policy
+// does not allow wrapper where n is, or n inside wrapper.
+func (b *NodeBuilder) Wrap(n, wrapper *html.Node) error {
+	if wrapper.Type != html.ElementNode || !b.c.allowedTags[strings.ToLower(wrapper.Data)] {
+		return &Issue{Err: ErrNotAllowed, Element: strings.ToLower(wrapper.Data)}
+	}
+	if n.Parent != nil {
+		if err := b.checkPlacement(wrapper, n.Parent); err != nil {
+			return err
+		}
+	}
+	if err := b.checkPlacement(n, wrapper); err != nil {
+		return err
+	}
+	if n.Parent != nil {
+		n.Parent.InsertBefore(wrapper, n)
+		n.Parent.RemoveChild(n)
+	}
+	wrapper.AppendChild(n)
+	return nil
+}
+
+// Unwrap replaces n with its children. Removing an element cannot
+// introduce anything the policy disallows, so Unwrap does not fail. A
+// Transformer that unwraps the node it was given should return nil;
+// the children it promoted are still sanitized. TokenSanitizer runs
+// transformers before an element's children have been read, so there
+// returning nil drops them instead.
+func (b *NodeBuilder) Unwrap(n *html.Node) {
+	if n.Parent != nil {
+		unwrap(n)
+	}
+}
+
+// checkPlacement reports whether n, if it is an element, may be a child
+// of parent under the policy's context rules.
+func (b *NodeBuilder) checkPlacement(n, parent *html.Node) error {
+	if n.Type != html.ElementNode {
+		return nil
+	}
+	tag := strings.ToLower(n.Data)
+	if !b.c.contextAllowed(tag, b.ancestorTags(parent)) {
+		return &Issue{Err: ErrNotAllowed, Element: tag}
+	}
+	return nil
+}
+
+// ancestorTags returns the tags of n and its ancestors that the policy
+// allows, outermost first, stopping at <body>. During the walk these
+// are the elements that will be kept around n.
+func (b *NodeBuilder) ancestorTags(n *html.Node) []string {
+	var tags []string
+	for ; n != nil && n.DataAtom != atom.Body; n = n.Parent {
+		if tag := strings.ToLower(n.Data); n.Type == html.ElementNode && b.c.allowedTags[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	for i, j := 0, len(tags)-1; i < j; i, j = i+1, j-1 {
+		tags[i], tags[j] = tags[j], tags[i]
+	}
+	return tags
+}
@@ -0,0 +1,97 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestNodeBuilder_NewElement(t *testing.T) {
+	b := htmlsanitizer.NewNodeBuilder(nil)
+	a, err := b.NewElement("A", html.Attribute{Key: "href", Val: "https://example.com/"}, html.Attribute{Key: "title", Val: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Data != "a" || htmlsanitizer.GetAttr(a, "href") != "https://example.com/" || len(a.Attr) != 2 {
+		t.Errorf("got <%s %v>", a.Data, a.Attr)
+	}
+
+	for _, tc := range []struct {
+		tag   string
+		attr  html.Attribute
+		err   error
+		issue string
+	}{
+		{tag: "script", err: htmlsanitizer.ErrNotAllowed, issue: "htmlsanitizer: <script>: not allowed by policy"},
+		{tag: "a", attr: html.Attribute{Key: "onclick", Val: "bad()"}, err: htmlsanitizer.ErrNotAllowed, issue: "htmlsanitizer: <a onclick>: not allowed by policy"},
+		{tag: "a", attr: html.Attribute{Key: "href", Val: "javascript:alert(1)"}, err: htmlsanitizer.ErrURLRejected, issue: `htmlsanitizer: <a href>: URL rejected: "javascript:alert(1)"`},
+	} {
+		var attrs []html.Attribute
+		if tc.attr.Key != "" {
+			attrs = append(attrs, tc.attr)
+		}
+		_, err := b.NewElement(tc.tag, attrs...)
+		if !errors.Is(err, tc.err) || err.Error() != tc.issue {
+			t.Errorf("NewElement(%q, %v) = %v, want %s", tc.tag, tc.attr, err, tc.issue)
+		}
+	}
+}
+
+func TestNodeBuilder_Placement(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.ContextRules = map[string]htmlsanitizer.ContextRule{"ul": {Children: []string{"li"}}}
+	b := htmlsanitizer.NewNodeBuilder(p)
+	ul, _ := b.NewElement("ul")
+	li, _ := b.NewElement("li")
+	para, _ := b.NewElement("p")
+	if err := b.AppendChild(ul, li); err != nil {
+		t.Errorf("li in ul: %v", err)
+	}
+	if err := b.AppendChild(ul, para); !errors.Is(err, htmlsanitizer.ErrNotAllowed) {
+		t.Errorf("p in ul: err = %v, want ErrNotAllowed", err)
+	}
+	if err := b.AppendChild(li, b.NewText("<b>")); err != nil {
+		t.Errorf("text in li: %v", err)
+	}
+	div, _ := b.NewElement("div")
+	if err := b.Wrap(li, div); !errors.Is(err, htmlsanitizer.ErrNotAllowed) {
+		t.Errorf("div in ul: err = %v, want ErrNotAllowed", err)
+	}
+	if li.Parent != ul {
+		t.Error("failed Wrap moved the node")
+	}
+}
+
+func TestNodeBuilder_InTransformers(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	b := htmlsanitizer.NewNodeBuilder(p)
+	p.Transformers = []htmlsanitizer.Transformer{
+		func(n *html.Node) *html.Node {
+			switch n.Data {
+			case "img":
+				fig, _ := b.NewElement("figure", html.Attribute{Key: "class", Val: "photo"})
+				caption, _ := b.NewElement("figcaption")
+				if err := b.Wrap(n, fig); err != nil {
+					t.Error(err)
+				}
+				_ = b.AppendChild(caption, b.NewText(htmlsanitizer.GetAttr(n, "alt")+" <3"))
+				_ = b.AppendChild(fig, caption)
+			case "span":
+				b.Unwrap(n)
+				return nil
+			}
+			return n
+		},
+	}
+	got, err := htmlsanitizer.Sanitize(`<p><img src="/a.png" alt="A"><span>keep <script>x</script><b>this</b></span></p>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p><figure class="photo"><img src="/a.png" alt="A" /><figcaption>A &lt;3</figcaption></figure>keep <b>this</b></p>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
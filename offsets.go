@@ -0,0 +1,218 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// OffsetMap translates byte offsets between an input document and the
+// output of SanitizeWithOffsets. Only text content is mapped: offsets
+// inside markup, or inside text the policy removed, have no
+// counterpart.
+type OffsetMap struct {
+	spans []offsetSpan // ordered by both In and Out
+}
+
+// offsetSpan pairs a run of input bytes with the output bytes it
+// became. Offsets inside a span of equal lengths map one to one;
+// inside an entity or escape they map to the span's start.
+type offsetSpan struct {
+	In, InLen, Out, OutLen int
+}
+
+// Output returns the output offset for the input offset in. ok is false
+// if in lies outside the text that was kept; off is then the output
+// offset at which the following kept text starts.
+func (m *OffsetMap) Output(in int) (off int, ok bool) {
+	return m.translate(in, func(s offsetSpan) (int, int, int, int) {
+		return s.In, s.InLen, s.Out, s.OutLen
+	})
+}
+
+// Input returns the input offset for the output offset out. ok is false
+// if out lies inside markup; off is then the input offset at which the
+// following text starts.
+func (m *OffsetMap) Input(out int) (off int, ok bool) {
+	return m.translate(out, func(s offsetSpan) (int, int, int, int) {
+		return s.Out, s.OutLen, s.In, s.InLen
+	})
+}
+
+func (m *OffsetMap) translate(x int, view func(offsetSpan) (int, int, int, int)) (int, bool) {
+	i := sort.Search(len(m.spans), func(i int) bool {
+		from, n, _, _ := view(m.spans[i])
+		return from+n >= x
+	})
+	if i == len(m.spans) {
+		if i == 0 {
+			return 0, false
+		}
+		_, _, to, n := view(m.spans[i-1])
+		return to + n, false
+	}
+	from, n, to, tn := view(m.spans[i])
+	switch {
+	case x < from:
+		return to, false
+	case x == from+n:
+		return to + tn, true
+	case n == tn:
+		return to + x - from, true
+	}
+	return to, true
+}
+
+// add appends a span, merging it into the previous one when both map
+// one to one and are contiguous on both sides.
+func (m *OffsetMap) add(s offsetSpan) {
+	if k := len(m.spans) - 1; k >= 0 {
+		last := &m.spans[k]
+		if last.InLen == last.OutLen && s.InLen == s.OutLen &&
+			last.In+last.InLen == s.In && last.Out+last.OutLen == s.Out {
+			last.InLen += s.InLen
+			last.OutLen += s.OutLen
+			return
+		}
+	}
+	m.spans = append(m.spans, s)
+}
+
+// textUnit is a piece of a text token: a character or character
+// reference in the source and the text it decodes to.
+type textUnit struct {
+	raw, rawLen int // offset in the document and length of the source
+	dec, decLen int // offset and length in the decoded text
+}
+
+// textUnits splits the source raw of a text token, found at offset at
+// in the document, into units of its decoded text. If the two cannot be
+// matched up, the whole token is one unit.
+func textUnits(raw, text string, at int) []textUnit {
+	var units []textUnit
+	r, d := 0, 0
+	for r < len(raw) && d < len(text) {
+		n, m := 1, 1
+		switch {
+		case raw[r] == '&':
+			n, m = 0, 0
+			if k := strings.IndexByte(raw[r:min(len(raw), r+40)], ';'); k > 0 {
+				ref := raw[r : r+k+1]
+				if dec := html.UnescapeString(ref); dec != ref && strings.HasPrefix(text[d:], dec) {
+					n, m = len(ref), len(dec)
+				}
+			}
+			if n == 0 && text[d] == '&' {
+				n, m = 1, 1
+			}
+		case strings.HasPrefix(raw[r:], "\r\n") && text[d] == '\n':
+			n = 2
+		default:
+			_, size := utf8.DecodeRuneInString(raw[r:])
+			if strings.HasPrefix(text[d:], raw[r:r+size]) {
+				n, m = size, size
+			} else if _, tsize := utf8.DecodeRuneInString(text[d:]); raw[r] == 0 {
+				m = tsize // NUL becomes U+FFFD
+			} else {
+				n = 0
+			}
+		}
+		if n == 0 {
+			break
+		}
+		units = append(units, textUnit{raw: at + r, rawLen: n, dec: d, decLen: m})
+		r, d = r+n, d+m
+	}
+	if r != len(raw) || d != len(text) {
+		return []textUnit{{raw: at, rawLen: len(raw), dec: 0, decLen: len(text)}}
+	}
+	return units
+}
+
+// SanitizeWithOffsets sanitizes htmlStr with p at the token level, as
+// SanitizeTokens does, and returns an OffsetMap relating its text to
+// the text of the output. Highlighting and annotation systems that
+// store ranges against the original submission use it to find the
+// same ranges in the sanitized render. If p is nil, the default policy is
+// used.
+func SanitizeWithOffsets(htmlStr string, p *Policy) (string, *OffsetMap, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	var buf, scratch bytes.Buffer
+	s := NewTokenSanitizer(p)
+	if p.EmbedMarker {
+		buf.WriteString(marker(s.c))
+	}
+	m := &OffsetMap{}
+	z := html.NewTokenizer(strings.NewReader(htmlStr))
+
+	var text string // decoded text of the current input text token
+	var units []textUnit
+	cursor := 0 // decoded bytes of text already emitted
+	emit := func(tok html.Token) error {
+		if tok.Type != html.TextToken {
+			writeToken(&buf, tok)
+			return nil
+		}
+		at := strings.Index(text[cursor:], tok.Data)
+		if tok.Data == "" || at < 0 {
+			// Text made up by the sanitizer, such as escaped tags.
+			escapeText(&buf, tok.Data)
+			return nil
+		}
+		start, end := cursor+at, cursor+at+len(tok.Data)
+		cursor = end
+		for _, u := range units {
+			if u.dec+u.decLen <= start || u.dec >= end {
+				continue
+			}
+			lo, hi := max(u.dec, start), min(u.dec+u.decLen, end)
+			scratch.Reset()
+			escapeText(&scratch, text[lo:hi])
+			span := offsetSpan{In: u.raw, InLen: u.rawLen, Out: buf.Len(), OutLen: scratch.Len()}
+			if lo != u.dec || hi != u.dec+u.decLen {
+				// Part of a unit: map the part to its start.
+				span.InLen = 0
+			}
+			m.add(span)
+			buf.Write(scratch.Bytes())
+		}
+		return nil
+	}
+
+	pos := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return "", nil, err
+			}
+			text, units = "", nil
+			if err := s.Close(emit); err != nil {
+				return "", nil, err
+			}
+			break
+		}
+		raw := string(z.Raw())
+		tok := s.names.token(z, tt)
+		if tok.Type == html.TextToken {
+			text, units, cursor = tok.Data, textUnits(raw, tok.Data, pos), 0
+		} else {
+			text, units, cursor = "", nil, 0
+		}
+		pos += len(raw)
+		if err := s.Token(tok, emit); err != nil {
+			return "", nil, err
+		}
+	}
+	out := buf.String()
+	if p.MaxOutputLength > 0 && len(out) > p.MaxOutputLength {
+		return "", nil, ErrOutputTooLarge
+	}
+	return out, m, nil
+}
@@ -0,0 +1,47 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitizeWithOffsets(t *testing.T) {
+	input := `<div onclick="x()"><p>Tom &amp; <i>Jerry</i><script>gone()</script> caf&eacute; &lt;3</p></div>`
+	out, m, err := htmlsanitizer.SanitizeWithOffsets(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<div><p>Tom &amp; <i>Jerry</i> café &lt;3</p></div>`; out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+	for _, word := range []string{"Tom", "Jerry", " caf", "3"} {
+		in := strings.Index(input, word)
+		got, ok := m.Output(in)
+		if want := strings.Index(out, word); !ok || got != want {
+			t.Errorf("Output(%d) for %q = %d, %v; want %d", in, word, got, ok, want)
+		}
+		back, ok := m.Input(strings.Index(out, word))
+		if !ok || back != in {
+			t.Errorf("Input for %q = %d, %v; want %d", word, back, ok, in)
+		}
+	}
+
+	// A range ending after an entity ends after its output.
+	end := strings.Index(input, "; <i>") + 1
+	if got, ok := m.Output(end); !ok || got != strings.Index(out, "; <i>")+1 {
+		t.Errorf("Output(%d) = %d, %v", end, got, ok)
+	}
+	// Inside an entity maps to its start.
+	if got, _ := m.Output(strings.Index(input, "eacute")); got != strings.Index(out, "é") {
+		t.Errorf("inside entity mapped to %d", got)
+	}
+	// Removed content has no counterpart.
+	if got, ok := m.Output(strings.Index(input, "gone")); ok || got != strings.Index(out, " café") {
+		t.Errorf("removed text mapped to %d, %v", got, ok)
+	}
+	if _, ok := m.Input(strings.Index(out, "<i>") + 1); ok {
+		t.Error("markup offset mapped")
+	}
+}
@@ -0,0 +1,108 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ErrInvalidBaseURL is returned by Sanitize for a WithBaseURL option
+// whose URL is not absolute.
+var ErrInvalidBaseURL = errors.New("htmlsanitizer: base URL must be absolute")
+
+// Option sets a parameter of a single Sanitize call, such as a request's
+// context or base URL, so that per-request values do not require
+// copying and modifying a shared Policy.
+type Option func(*callOptions)
+
+// callOptions are the parameters set by Options.
+type callOptions struct {
+	ctx      context.Context
+	base     *url.URL
+	err      error // from an invalid option
+	report   *Report
+	maxBytes int
+}
+
+// WithContext sets the context passed to Policy.Logger and
+// Policy.Tracer.
+func WithContext(ctx context.Context) Option {
+	return func(o *callOptions) { o.ctx = ctx }
+}
+
+// WithBaseURL resolves the relative URLs kept in the output against
+// base, which must be an absolute URL with a host, such as the address
+// of the page the content came from. Fragment-only references such as
+// "#top" are left relative.
+func WithBaseURL(base string) Option {
+	return func(o *callOptions) {
+		u, err := url.Parse(base)
+		if err != nil || !u.IsAbs() || u.Host == "" {
+			o.err = ErrInvalidBaseURL
+			return
+		}
+		o.base = u
+	}
+}
+
+// WithReport fills in *rep with a Report describing the call, as
+// SanitizeWithReport returns.
+func WithReport(rep *Report) Option {
+	return func(o *callOptions) { o.report = rep }
+}
+
+// WithMaxBytes rejects input longer than n bytes with an
+// InputLimitError, as Policy.MaxInputSize does. If the policy sets a
+// lower MaxInputSize, that limit applies.
+func WithMaxBytes(n int) Option {
+	return func(o *callOptions) { o.maxBytes = n }
+}
+
+// sanitizeWithOptions implements Sanitize for calls with options. The
+// options are applied to a shallow copy of p, so p itself is never
+// modified.
+func sanitizeWithOptions(htmlStr string, p *Policy, opts []Option) (string, error) {
+	o := callOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.err != nil {
+		return "", o.err
+	}
+	if o.report != nil {
+		*o.report = Report{PolicyVersion: p.Version, PolicyFingerprint: p.Fingerprint()}
+	}
+	lowerLimit := o.maxBytes > 0 && (p.MaxInputSize <= 0 || o.maxBytes < p.MaxInputSize)
+	if lowerLimit || o.base != nil {
+		q := *p
+		if lowerLimit {
+			q.MaxInputSize = o.maxBytes
+		}
+		if o.base != nil {
+			q.PostTransformers = append(slices.Clip(p.PostTransformers), resolveURLs(o.base))
+		}
+		p = &q
+	}
+	return sanitizeString(o.ctx, htmlStr, compilePolicy(p), o.report)
+}
+
+// resolveURLs returns a post-transformer that resolves the relative
+// URL attributes of the output against base.
+func resolveURLs(base *url.URL) func(root *html.Node) {
+	return func(root *html.Node) {
+		walkElements(root, func(n *html.Node) {
+			for i, a := range n.Attr {
+				if !isURLAttr(a.Key) || a.Val == "" || strings.HasPrefix(a.Val, "#") {
+					continue
+				}
+				if u, err := url.Parse(strings.TrimSpace(a.Val)); err == nil && !u.IsAbs() {
+					n.Attr[i].Val = base.ResolveReference(u).String()
+				}
+			}
+		})
+	}
+}
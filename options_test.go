@@ -0,0 +1,94 @@
+package htmlsanitizer_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_WithBaseURL(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	in := `<a href="/docs">docs</a> <a href="#top">top</a> <a href="https://example.org/">ext</a> <img src="img/a.png">`
+	got, err := htmlsanitizer.Sanitize(in, p, htmlsanitizer.WithBaseURL("https://example.com/blog/post"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a href="https://example.com/docs">docs</a> <a href="#top">top</a> <a href="https://example.org/">ext</a> <img src="https://example.com/blog/img/a.png" />`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if len(p.PostTransformers) != 0 {
+		t.Error("WithBaseURL modified the policy")
+	}
+
+	if _, err := htmlsanitizer.Sanitize(in, p, htmlsanitizer.WithBaseURL("/relative")); !errors.Is(err, htmlsanitizer.ErrInvalidBaseURL) {
+		t.Errorf("relative base: err = %v, want ErrInvalidBaseURL", err)
+	}
+}
+
+func TestSanitize_WithReport(t *testing.T) {
+	var rep htmlsanitizer.Report
+	_, err := htmlsanitizer.Sanitize(`<b onclick="x()">hi</b><script>x()</script>`, nil, htmlsanitizer.WithReport(&rep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.RemovedElements != 1 || rep.RemovedAttributes != 1 {
+		t.Errorf("report = %+v", rep)
+	}
+	if rep.PolicyFingerprint != htmlsanitizer.DefaultPolicy().Fingerprint() {
+		t.Error("report lacks the policy fingerprint")
+	}
+}
+
+func TestSanitize_WithMaxBytes(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	in := strings.Repeat("<b>x</b>", 10)
+	_, err := htmlsanitizer.Sanitize(in, p, htmlsanitizer.WithMaxBytes(16))
+	var lim *htmlsanitizer.InputLimitError
+	if !errors.As(err, &lim) || lim.Max != 16 {
+		t.Errorf("err = %v, want InputLimitError with Max 16", err)
+	}
+	if p.MaxInputSize != 0 {
+		t.Error("WithMaxBytes modified the policy")
+	}
+
+	// A lower limit in the policy wins.
+	p.MaxInputSize = 8
+	if _, err := htmlsanitizer.Sanitize(in, p, htmlsanitizer.WithMaxBytes(1000)); !errors.As(err, &lim) || lim.Max != 8 {
+		t.Errorf("err = %v, want InputLimitError with Max 8", err)
+	}
+}
+
+func TestSanitize_WithContext(t *testing.T) {
+	type key struct{}
+	var got any
+	h := &ctxHandler{fn: func(ctx context.Context) { got = ctx.Value(key{}) }}
+	p := htmlsanitizer.DefaultPolicy()
+	p.Logger = slog.New(h)
+	p.MaxDepth = 1 // logged when hit
+	ctx := context.WithValue(context.Background(), key{}, "request-1")
+	if _, err := htmlsanitizer.Sanitize(`<b><i>x</i></b>`, p, htmlsanitizer.WithContext(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	if got != "request-1" {
+		t.Errorf("logger saw context value %v", got)
+	}
+}
+
+// ctxHandler is a slog.Handler that passes the context of each record
+// to fn.
+type ctxHandler struct {
+	fn func(context.Context)
+}
+
+func (h *ctxHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *ctxHandler) Handle(ctx context.Context, _ slog.Record) error {
+	h.fn(ctx)
+	return nil
+}
+func (h *ctxHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *ctxHandler) WithGroup(string) slog.Handler      { return h }
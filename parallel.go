@@ -0,0 +1,74 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// cleanParallel splits the children of body into contiguous groups and
+// cleans each group on its own goroutine. Each group is detached into
+// its own container while it is cleaned, so no goroutine touches a node
+// another one owns, and the results are reattached in document order.
+// The resulting tree is identical to a sequential clean. If rep is
+// non-nil, each group records into its own report and the results are
+// merged. Every goroutine stops early once ctx is done.
+func cleanParallel(ctx context.Context, c *compiledPolicy, body *html.Node, rep *Report) {
+	var nodes []*html.Node
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		nodes = append(nodes, n)
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(nodes) {
+		workers = len(nodes)
+	}
+	if workers <= 1 {
+		cl := &cleaner{c: c, report: rep, ctx: ctx}
+		cl.cleanChildren(body, 1)
+		return
+	}
+
+	size := (len(nodes) + workers - 1) / workers
+	groups := make([]*html.Node, 0, workers)
+	reports := make([]*Report, 0, workers)
+	for lo := 0; lo < len(nodes); lo += size {
+		hi := lo + size
+		if hi > len(nodes) {
+			hi = len(nodes)
+		}
+		group := &html.Node{Type: html.DocumentNode}
+		for _, n := range nodes[lo:hi] {
+			body.RemoveChild(n)
+			group.AppendChild(n)
+		}
+		groups = append(groups, group)
+		if rep != nil {
+			reports = append(reports, &Report{})
+		} else {
+			reports = append(reports, nil)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		wg.Add(1)
+		go func(group *html.Node, rep *Report) {
+			defer wg.Done()
+			cl := &cleaner{c: c, report: rep, ctx: ctx}
+			cl.cleanChildren(group, 1)
+		}(group, reports[i])
+	}
+	wg.Wait()
+
+	for i, group := range groups {
+		for n := group.FirstChild; n != nil; n = group.FirstChild {
+			group.RemoveChild(n)
+			body.AppendChild(n)
+		}
+		if rep != nil {
+			rep.merge(reports[i], c.p.maxPayloads())
+		}
+	}
+}
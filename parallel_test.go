@@ -0,0 +1,35 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_ParallelMatchesSequential(t *testing.T) {
+	input := strings.Repeat(`<p>Hello <b>world</b> <script>bad()</script></p><div onclick="x">text</div>loose `, 500)
+	want, err := htmlsanitizer.Sanitize(input, htmlsanitizer.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := htmlsanitizer.DefaultPolicy()
+	p.Parallel = true
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("parallel output differs from sequential output")
+	}
+}
+
+func BenchmarkSanitize_Parallel(b *testing.B) {
+	input := strings.Repeat(`<p>Hello <b>world</b> <script>bad()</script> <a href="http://x.com">link</a></p>`, 10000)
+	p := htmlsanitizer.DefaultPolicy()
+	p.Parallel = true
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = htmlsanitizer.Sanitize(input, p)
+	}
+}
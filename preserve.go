@@ -0,0 +1,125 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// sanitizePreserving implements Policy.PreserveFormatting. It runs the
+// token-level sanitizer over data and serializes the result, reusing
+// the author's spelling wherever it round-trips to the same tokens. It
+// reports false if the parser would rearrange table markup in data,
+// which the tokens cannot reproduce, for the caller to sanitize the
+// tree instead.
+func sanitizePreserving(data []byte, c *compiledPolicy) (string, bool, error) {
+	p := c.p
+	var buf bytes.Buffer
+	if p.EmbedMarker {
+		buf.WriteString(marker(c))
+	}
+	s := &TokenSanitizer{c: c}
+	z := html.NewTokenizer(bytes.NewReader(data))
+	var in html.Token
+	var raw []byte
+	emit := func(tok html.Token) error {
+		switch {
+		case tok.Type == html.TextToken && in.Type == html.TextToken && tok.Data == in.Data && p.copiesReferences() && rawTextSafe(raw, in.Data):
+			buf.Write(raw)
+		case (tok.Type == html.StartTagToken || tok.Type == html.SelfClosingTagToken || tok.Type == html.EndTagToken) &&
+			in.Type == tok.Type && in.Data == tok.Data:
+			tok.Data = rawTagName(raw, tok.Data)
+			writeToken(&buf, tok)
+		default:
+			writeToken(&buf, tok)
+		}
+		return nil
+	}
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return "", false, err
+			}
+			in = html.Token{}
+			if err := s.Close(emit); err != nil {
+				return "", false, err
+			}
+			break
+		}
+		// Copy the source first: reading the token decodes and
+		// lower-cases the tokenizer's buffer in place.
+		raw = append(raw[:0], z.Raw()...)
+		in = s.names.token(z, tt)
+		if rearranged(s.stack, in) {
+			return "", false, nil
+		}
+		if err := s.Token(in, emit); err != nil {
+			return "", false, err
+		}
+	}
+	out := applyEntities(buf.String(), p)
+	if p.MaxOutputLength > 0 && len(out) > p.MaxOutputLength {
+		return "", false, ErrOutputTooLarge
+	}
+	return out, true, nil
+}
+
+// tableParents maps the table elements to the elements they must be
+// opened in for the parser to leave them in place.
+var tableParents = map[string][]string{
+	"caption": {"table"}, "colgroup": {"table"}, "col": {"table", "colgroup"},
+	"tbody": {"table"}, "thead": {"table"}, "tfoot": {"table"},
+	"tr": {"table", "tbody", "thead", "tfoot"}, "td": {"tr"}, "th": {"tr"},
+}
+
+// rearranged reports whether the parser would move tok, read inside
+// the open elements stack, elsewhere in the tree: content directly
+// inside a table, section, or row, which it moves before the table,
+// and table elements outside their parents, around which it inserts
+// or closes elements.
+func rearranged(stack []openElement, tok html.Token) bool {
+	parent := ""
+	if len(stack) > 0 {
+		parent = stack[len(stack)-1].tag
+	}
+	tag := ""
+	if tok.Type == html.StartTagToken || tok.Type == html.SelfClosingTagToken {
+		tag = strings.ToLower(tok.Data)
+		if parents, ok := tableParents[tag]; ok {
+			return !slices.Contains(parents, parent)
+		}
+	}
+	switch parent {
+	case "table", "tbody", "thead", "tfoot", "tr":
+	default:
+		return false
+	}
+	switch {
+	case tok.Type == html.TextToken:
+		return strings.Trim(tok.Data, " \t\n\f\r") != ""
+	case tag == "script" || tag == "style" || tag == "template":
+		return false
+	}
+	return tag != ""
+}
+
+// rawTextSafe reports whether raw, the source of a text token, can be
+// copied to the output as it is: it decodes to the same text and
+// contains nothing that could start markup.
+func rawTextSafe(raw []byte, text string) bool {
+	return bytes.IndexByte(raw, '<') < 0 && html.UnescapeString(string(raw)) == text
+}
+
+// rawTagName returns the tag name as spelled in raw, the source of a
+// start or end tag, if it matches name apart from case.
+func rawTagName(raw []byte, name string) string {
+	s := strings.TrimPrefix(strings.TrimPrefix(string(raw), "<"), "/")
+	if len(s) >= len(name) && strings.EqualFold(s[:len(name)], name) {
+		return s[:len(name)]
+	}
+	return name
+}
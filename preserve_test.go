@@ -0,0 +1,36 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_PreserveFormatting(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.PreserveFormatting = true
+	tests := []struct{ in, want string }{
+		{"<P Class=\"x\">Caf&eacute; &amp; bar&nbsp;</P>\n\n<table>\n  <tr><td>1</td></tr>\n</table>",
+			"<P class=\"x\">Caf&eacute; &amp; bar&nbsp;</P>\n\n<table>\n  <tr><td>1</td></tr>\n</table>"},
+		{`<DIV onclick="x()">a <SCRIPT>evil()</SCRIPT>b</DIV>`, `<DIV>a b</DIV>`},
+		{`<b>AT&T 1 > 0</b>`, `<b>AT&T 1 > 0</b>`},
+		{`<blink>x</blink>`, `&lt;blink&gt;x&lt;/blink&gt;`},
+		{`<p>open`, `<p>open</p>`},
+		// Implied end tags close paragraphs and list items.
+		{`<div><p>a<p>b</div>`, `<div><p>a</p><p>b</p></div>`},
+		{`<UL><LI>a<LI>b</UL>`, `<UL><LI>a</li><LI>b</li></UL>`},
+		{`<p>a<ul><li>b</ul>`, `<p>a</p><ul><li>b</li></ul>`},
+		// Table content the parser moves is sanitized as a tree.
+		{`<table><b>x</b><tr><td>1</td></tr></table>`, `<b>x</b><table><tbody><tr><td>1</td></tr></tbody></table>`},
+		{`<table><tr><td>1<td>2</table>`, `<table><tbody><tr><td>1</td><td>2</td></tr></tbody></table>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%q:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package htmlsanitizer
+
+import (
+	"net/url"
+
+	"golang.org/x/net/html"
+)
+
+// DocsPolicy returns a Policy for documentation and wiki content. It
+// extends DefaultPolicy with definition lists, the kbd/samp/var family,
+// heading permalinks, and the footnote markup produced by common
+// Markdown renderers:
+//
+//	<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>
+//	<li id="fn:1">… <a href="#fnref:1" class="footnote-backref" role="doc-backlink">↩</a></li>
+//
+// Add Footnotes to PostTransformers to drop dangling references and
+// prefix the footnote ids.
+func DocsPolicy() *Policy {
+	p := DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags,
+		"dl", "dt", "dd",
+		"var", "mark", "small", "wbr",
+		"nav", "aside",
+	)
+	p.AllowedAttributes["a"] = []string{"href", "title", "target", "rel", "role", "aria-hidden"}
+	p.AllowedAttributes["ol"] = []string{"start", "reversed", "type"}
+	p.AllowedAttributes["div"] = []string{"role"}
+	p.AllowedAttributes["section"] = []string{"role"}
+	return p
+}
+
+// ChatPolicy returns a Policy for short chat messages: a handful of
+// inline tags, block quotes, and code, with other markup unwrapped,
+// automatic linking of URLs outside code, and a 16 KiB output limit.
+// Images are allowed only when their src is an http(s) URL on one of
+// imageHosts; with no hosts, images are removed. Set Mentions and
+// Hashtags on the result to link @name and #tag references.
+func ChatPolicy(imageHosts ...string) *Policy {
+	p := &Policy{
+		AllowedTags: []string{
+			"b", "strong", "i", "em", "u", "s", "del",
+			"code", "pre", "blockquote", "br", "p", "span", "a",
+		},
+		AllowedAttributes: map[string][]string{
+			// rel and class keep the links that Linkify and Mentions
+			// add when stored messages are sanitized again.
+			"a": {"href", "title", "rel", "class"},
+		},
+		AllowedSchemes:    []string{"http", "https", "mailto"},
+		DisallowedActions: map[string]DisallowedAction{"*": DisallowedUnwrap},
+		Linkify:           true,
+		MaxDepth:          8,
+		MaxOutputLength:   16 << 10,
+	}
+	if len(imageHosts) > 0 {
+		p.AllowedTags = append(p.AllowedTags, "img")
+		p.AllowedAttributes["img"] = []string{"src", "alt", "width", "height"}
+		p.URLPolicy = imageHostPolicy(sliceToSet(imageHosts))
+		p.Transformers = []Transformer{dropImageWithoutSrc}
+	}
+	return p
+}
+
+// imageHostPolicy restricts img src URLs to a set of hosts.
+type imageHostPolicy map[string]bool
+
+func (h imageHostPolicy) Validate(tag, attr string, u *url.URL) Decision {
+	if tag != "img" {
+		return Defer
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || !h[u.Hostname()] {
+		return Deny
+	}
+	return Defer
+}
+
+// dropImageWithoutSrc removes images whose src did not survive.
+func dropImageWithoutSrc(n *html.Node) *html.Node {
+	if n.Data == "img" && GetAttr(n, "src") == "" {
+		return nil
+	}
+	return n
+}
+
+// EPUBPolicy returns a Policy for the XHTML content documents of EPUB
+// publications, for platforms ingesting publisher-provided books. Input
+// is parsed and rendered as XHTML. Every element may carry epub:type
+// and DPUB-ARIA roles, so footnotes, asides, and navigation keep their
+// structural semantics; ruby annotations are kept. Links and images
+// must point inside the publication: URLs with a scheme or host are
+// dropped, along with images left without a source, so the content
+// loads nothing external. Images are clamped to 2048 pixels, and other
+// markup is unwrapped.
+func EPUBPolicy() *Policy {
+	p := DocsPolicy()
+	p.XHTML = true
+	p.AllowedTags = append(p.AllowedTags, "ruby", "rt", "rp", "hgroup")
+	p.AllowedAttributes["*"] = []string{"id", "class", "lang", "xml:lang", "dir", "title", "epub:type", "role"}
+	p.AllowedAttributes["a"] = []string{"href", "title"}
+	p.AllowedAttributes["img"] = []string{"src", "alt", "width", "height"}
+	p.AllowedSchemes = nil
+	p.URLPolicy = internalOnly{}
+	p.RejectSchemeRelativeURLs = true
+	p.DisallowedActions = map[string]DisallowedAction{"*": DisallowedUnwrap}
+	p.MaxImageWidth = 2048
+	p.MaxImageHeight = 2048
+	p.Transformers = []Transformer{dropImageWithoutSrc}
+	return p
+}
+
+// internalOnly allows only relative URLs, which resolve inside the
+// document's own container.
+type internalOnly struct{}
+
+func (internalOnly) Validate(tag, attr string, u *url.URL) Decision {
+	if u.Scheme != "" || u.Host != "" {
+		return Deny
+	}
+	return Defer
+}
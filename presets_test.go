@@ -0,0 +1,117 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestDocsPolicy(t *testing.T) {
+	tests := []string{
+		`<dl><dt>term</dt><dd>definition</dd></dl>`,
+		`<p>Press <kbd>Ctrl</kbd>+<kbd>C</kbd>; set <var>n</var>, see <samp>ok</samp>.</p>`,
+		`<h2 id="install"><a href="#install" class="anchor" aria-hidden="true">#</a>Install</h2>`,
+		`<p>Text<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></p>` +
+			`<div class="footnotes" role="doc-endnotes"><hr /><ol><li id="fn:1"><p>Note ` +
+			`<a href="#fnref:1" class="footnote-backref" role="doc-backlink">↩</a></p></li></ol></div>`,
+	}
+	p := htmlsanitizer.DocsPolicy()
+	for _, in := range tests {
+		got, err := htmlsanitizer.Sanitize(in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != in {
+			t.Errorf("got  %q\nwant %q", got, in)
+		}
+	}
+
+	got, err := htmlsanitizer.Sanitize(`<a href="javascript:x()" role="doc-noteref">1</a>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<a role="doc-noteref">1</a>`; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestChatPolicy(t *testing.T) {
+	p := htmlsanitizer.ChatPolicy("media.example.com")
+	tests := []struct{ in, want string }{
+		{`<b>hi</b> <img src="https://media.example.com/a.png" alt="a">`,
+			`<b>hi</b> <img src="https://media.example.com/a.png" alt="a" />`},
+		{`<img src="https://tracker.example/p.gif">x`, `x`},
+		{`<img src="/local.png">x`, `x`},
+		{`<h1>big</h1> see https://go.dev`,
+			`big see <a href="https://go.dev" rel="noopener noreferrer">https://go.dev</a>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+
+	got, err := htmlsanitizer.Sanitize(`<img src="https://media.example.com/a.png">`, htmlsanitizer.ChatPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("image kept without image hosts: %q", got)
+	}
+
+	if _, err := htmlsanitizer.Sanitize(strings.Repeat("<b>spam</b>", 2000), p); err != htmlsanitizer.ErrOutputTooLarge {
+		t.Errorf("err = %v, want ErrOutputTooLarge", err)
+	}
+}
+
+func TestChatPolicy_Idempotent(t *testing.T) {
+	p := htmlsanitizer.ChatPolicy()
+	p.Mentions = func(name string) (string, bool) { return "/u/" + name, true }
+	once, err := htmlsanitizer.Sanitize(`hi @ann, see https://go.dev`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(once, `class="mention"`) || !strings.Contains(once, `rel="noopener noreferrer"`) {
+		t.Fatalf("links not added: %q", once)
+	}
+	twice, err := htmlsanitizer.Sanitize(once, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if twice != once {
+		t.Errorf("sanitizing again changed the output:\n%q\n%q", once, twice)
+	}
+}
+
+func TestEPUBPolicy(t *testing.T) {
+	p := htmlsanitizer.EPUBPolicy()
+	tests := []struct{ in, want string }{
+		{`<section xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" epub:type="chapter" xml:lang="ja">` +
+			`<p>See<a epub:type="noteref" href="#fn1">1</a></p><aside epub:type="footnote" id="fn1" role="doc-footnote">Note</aside></section>`,
+			`<section xmlns:epub="http://www.idpf.org/2007/ops" epub:type="chapter" xml:lang="ja">` +
+				`<p>See<a epub:type="noteref" href="#fn1">1</a></p><aside epub:type="footnote" id="fn1" role="doc-footnote">Note</aside></section>`},
+		{`<p><a href="ch2.xhtml#s">next</a> <a href="https://example.com/">web</a> <a href="//cdn.example/x">cdn</a></p>`,
+			`<p><a href="ch2.xhtml#s">next</a> <a>web</a> <a>cdn</a></p>`},
+		{`<p><img src="images/map.png" width="4096" height="2048" alt="map"/><img src="http://example.com/t.png"/></p>`,
+			`<p><img src="images/map.png" width="2048" height="1024" alt="map"/></p>`},
+		{`<p><ruby>漢<rt>kan</rt></ruby> <font>old</font><iframe src="x.xhtml"/></p>`,
+			`<p><ruby>漢<rt>kan</rt></ruby> old</p>`},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.Sanitize(tt.in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+	if _, err := htmlsanitizer.Sanitize(`<p>unclosed`, p); err == nil {
+		t.Error("malformed XHTML accepted")
+	}
+}
@@ -0,0 +1,170 @@
+package htmlsanitizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ProfanityOptions configure MaskProfanity.
+type ProfanityOptions struct {
+	// Remove deletes matched words, with one adjoining space, instead
+	// of masking them.
+	Remove bool
+
+	// Mask replaces every character of a masked word. Zero means '*'.
+	Mask rune
+
+	// KeepFirst leaves the first character of masked words visible,
+	// as in "d***".
+	KeepFirst bool
+
+	// Leet also matches words spelled with digits and symbols in place
+	// of letters, such as "d4mn" and "@ss".
+	Leet bool
+}
+
+// leetLetters maps the digits and symbols of leet speak to the letters
+// they stand for.
+var leetLetters = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+}
+
+// MaskProfanity returns a text transformer for Policy.TextTransformers
+// that masks the words of text found in words. Words match whole,
+// ignoring case, accents, and compatibility variants such as
+// full-width letters, so "Dämn" and "ＤＡＭＮ" both match "damn".
+func MaskProfanity(words []string, opts ProfanityOptions) func(text string) string {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		if w = foldWord(strings.TrimSpace(w), false); w != "" {
+			set[w] = true
+		}
+	}
+	if opts.Mask == 0 {
+		opts.Mask = '*'
+	}
+	return func(text string) string {
+		return maskWords(text, set, opts)
+	}
+}
+
+// maskWords masks or removes the words of text that are in set.
+func maskWords(text string, set map[string]bool, opts ProfanityOptions) string {
+	var b strings.Builder
+	last := 0 // end of the text already copied to b
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isProfanityRune(r, opts.Leet) {
+			i += size
+			continue
+		}
+		end := i + size
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isProfanityRune(r, opts.Leet) {
+				break
+			}
+			end += size
+		}
+		start, stop, ok := matchWord(text[i:end], set, opts.Leet)
+		if !ok {
+			i = end
+			continue
+		}
+		start, stop = i+start, i+stop
+		if b.Len() == 0 {
+			b.Grow(len(text))
+		}
+		if opts.Remove {
+			start, stop = removalSpan(text, start, stop, last)
+			b.WriteString(text[last:start])
+		} else {
+			b.WriteString(text[last:start])
+			for j, r := range text[start:stop] {
+				if j == 0 && opts.KeepFirst {
+					b.WriteRune(r)
+				} else {
+					b.WriteRune(opts.Mask)
+				}
+			}
+		}
+		last = stop
+		i = end
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// matchWord reports whether word, or word without the leet symbols it
+// begins or ends with, is in set, and returns the byte range that
+// matched.
+func matchWord(word string, set map[string]bool, leet bool) (start, end int, ok bool) {
+	if set[foldWord(word, leet)] {
+		return 0, len(word), true
+	}
+	if !leet {
+		return 0, 0, false
+	}
+	// Punctuation such as "!" may end a sentence rather than stand
+	// for a letter.
+	trimmed := strings.TrimFunc(word, isLeetSymbol)
+	if trimmed == "" || trimmed == word {
+		return 0, 0, false
+	}
+	for _, s := range []string{strings.TrimRightFunc(word, isLeetSymbol), strings.TrimLeftFunc(word, isLeetSymbol), trimmed} {
+		if set[foldWord(s, leet)] {
+			start = strings.Index(word, s)
+			return start, start + len(s), true
+		}
+	}
+	return 0, 0, false
+}
+
+// removalSpan widens [start, end) to take one adjoining space with the
+// word: the one after it, or else the one before it if that has not
+// been copied yet, which is the case from last on.
+func removalSpan(text string, start, end, last int) (int, int) {
+	if r, size := utf8.DecodeRuneInString(text[end:]); size > 0 && unicode.IsSpace(r) {
+		return start, end + size
+	}
+	if r, size := utf8.DecodeLastRuneInString(text[:start]); size > 0 && start-size >= last && unicode.IsSpace(r) {
+		return start - size, end
+	}
+	return start, end
+}
+
+// foldWord returns the form of word that is looked up: lower-cased,
+// compatibility-decomposed with its combining marks dropped, and, for
+// leet, with digits and symbols read as letters.
+func foldWord(word string, leet bool) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(word) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if l, ok := leetLetters[r]; ok && leet {
+			r = l
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// isProfanityRune reports whether r can be part of a word.
+func isProfanityRune(r rune, leet bool) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) ||
+		leet && isLeetSymbol(r)
+}
+
+// isLeetSymbol reports whether r is a leet symbol other than a digit.
+func isLeetSymbol(r rune) bool {
+	_, ok := leetLetters[r]
+	return ok && !unicode.IsDigit(r)
+}
@@ -0,0 +1,56 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestMaskProfanity(t *testing.T) {
+	words := []string{"damn", "heck"}
+	tests := []struct {
+		name string
+		opts htmlsanitizer.ProfanityOptions
+		in   string
+		want string
+	}{
+		{"mask", htmlsanitizer.ProfanityOptions{}, "Damn it, what the HECK.", "**** it, what the ****."},
+		{"whole words", htmlsanitizer.ProfanityOptions{}, "damnation heckle", "damnation heckle"},
+		{"accents and width", htmlsanitizer.ProfanityOptions{}, "Dämn ｄａｍｎ", "**** ****"},
+		{"keep first", htmlsanitizer.ProfanityOptions{KeepFirst: true, Mask: '#'}, "damn", "d###"},
+		{"remove", htmlsanitizer.ProfanityOptions{Remove: true}, "well damn it damn", "well it"},
+		{"remove before punctuation", htmlsanitizer.ProfanityOptions{Remove: true}, "oh damn.", "oh."},
+		{"no leet", htmlsanitizer.ProfanityOptions{}, "d4mn h3ck", "d4mn h3ck"},
+		{"leet", htmlsanitizer.ProfanityOptions{Leet: true}, "d4mn h3ck! @", "**** ****! @"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlsanitizer.MaskProfanity(words, tt.opts)(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize_TextTransformers(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.TextTransformers = append(p.TextTransformers,
+		htmlsanitizer.MaskProfanity([]string{"damn"}, htmlsanitizer.ProfanityOptions{}))
+	const in = `<p><b>damn &lt;</b> <a title="damn">ok</a></p>`
+	const want = `<p><b>**** &lt;</b> <a title="damn">ok</a></p>`
+	got, err := htmlsanitizer.Sanitize(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	q := p.Clone()
+	q.PreserveFormatting = true
+	if got, _ := htmlsanitizer.Sanitize(in, q); got != want {
+		t.Errorf("PreserveFormatting: got  %q\nwant %q", got, want)
+	}
+	if got, _ := htmlsanitizer.Sanitize("damn", p); got != "****" {
+		t.Errorf("plain text: got %q", got)
+	}
+}
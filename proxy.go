@@ -0,0 +1,95 @@
+package htmlsanitizer
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ErrUnsupportedEncoding is returned by the function from
+// SanitizeResponse for an HTML response whose Content-Encoding it cannot
+// decode.
+var ErrUnsupportedEncoding = errors.New("htmlsanitizer: unsupported Content-Encoding")
+
+// SanitizeResponse returns a function for the ModifyResponse field of
+// an httputil.ReverseProxy that sanitizes HTML responses with p, for
+// gateways that pass on HTML from third parties. Responses declared as
+// text/html or application/xhtml+xml are sanitized, and so are those
+// with a missing or unparseable Content-Type, which browsers and
+// net/http would sniff as HTML. Other responses are left alone. Every
+// response gets X-Content-Type-Options: nosniff, so that browsers take
+// the declared type at its word.
+//
+// The body is decoded from its declared or detected charset and a gzip
+// Content-Encoding, and is sent on as uncompressed UTF-8 with its
+// Content-Type, Content-Length, and ETag headers updated. The function
+// fails, and the proxy answers with its ErrorHandler, for encodings it
+// cannot decode and for errors from sanitizing, so that unsanitized HTML
+// never passes through. If p is nil, the default policy is used.
+func SanitizeResponse(p *Policy) func(*http.Response) error {
+	if p == nil {
+		p = nilPolicy()
+	}
+	c := compilePolicy(clonePolicy(p))
+	return func(resp *http.Response) error {
+		resp.Header.Set("X-Content-Type-Options", "nosniff")
+		contentType := resp.Header.Get("Content-Type")
+		if !sniffsAsHTML(contentType) {
+			return nil
+		}
+		body := io.Reader(resp.Body)
+		defer resp.Body.Close()
+		switch enc := strings.ToLower(resp.Header.Get("Content-Encoding")); enc {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				return err
+			}
+			body = zr
+		default:
+			return ErrUnsupportedEncoding
+		}
+		utf8Body, err := charset.NewReader(body, contentType)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		if resp.Request != nil {
+			ctx = resp.Request.Context()
+		}
+		out, err := sanitize(ctx, utf8Body, c, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(strings.NewReader(out))
+		resp.ContentLength = int64(len(out))
+		resp.Header.Set("Content-Type", "text/html; charset=utf-8")
+		resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("ETag")
+		return nil
+	}
+}
+
+// sniffsAsHTML reports whether a response with the Content-Type header
+// contentType may be rendered as HTML: it declares an HTML or XHTML
+// type, or none that can be parsed.
+func sniffsAsHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && mediaType == "" {
+		return true
+	}
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return true
+	}
+	return false
+}
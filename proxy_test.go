@@ -0,0 +1,128 @@
+package htmlsanitizer_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func proxyGet(t *testing.T, upstream http.HandlerFunc) *http.Response {
+	t.Helper()
+	origin := httptest.NewServer(upstream)
+	t.Cleanup(origin.Close)
+	target, _ := url.Parse(origin.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ModifyResponse = htmlsanitizer.SanitizeResponse(nil)
+	front := httptest.NewServer(proxy)
+	t.Cleanup(front.Close)
+	resp, err := http.Get(front.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestSanitizeResponse(t *testing.T) {
+	resp := proxyGet(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("<p onclick=\"x()\">caf\xe9</p><script>alert(1)</script>"))
+	})
+	body, _ := io.ReadAll(resp.Body)
+	if want := "<p>café</p>"; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := resp.Header.Get("Content-Length"); got != strconv.Itoa(len(body)) {
+		t.Errorf("Content-Length = %q, body is %d bytes", got, len(body))
+	}
+	if resp.Header.Get("ETag") != "" {
+		t.Error("ETag of the unsanitized body kept")
+	}
+}
+
+func TestSanitizeResponse_Gzip(t *testing.T) {
+	var zipped bytes.Buffer
+	zw := gzip.NewWriter(&zipped)
+	zw.Write([]byte(`<b>bold</b><img src="javascript:x">`))
+	zw.Close()
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": {"text/html"}, "Content-Encoding": {"gzip"}},
+		Body:   io.NopCloser(&zipped),
+	}
+	if err := htmlsanitizer.SanitizeResponse(nil)(resp); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if want := `<b>bold</b><img />`; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if resp.Header.Get("Content-Encoding") != "" || resp.ContentLength != int64(len(body)) {
+		t.Errorf("headers = %v, ContentLength = %d", resp.Header, resp.ContentLength)
+	}
+
+	resp = &http.Response{
+		Header: http.Header{"Content-Type": {"text/html"}, "Content-Encoding": {"br"}},
+		Body:   io.NopCloser(strings.NewReader("...")),
+	}
+	if err := htmlsanitizer.SanitizeResponse(nil)(resp); !errors.Is(err, htmlsanitizer.ErrUnsupportedEncoding) {
+		t.Errorf("br: err = %v", err)
+	}
+}
+
+func TestSanitizeResponse_NotHTML(t *testing.T) {
+	resp := proxyGet(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"html": "<script>"}`))
+	})
+	body, _ := io.ReadAll(resp.Body)
+	if want := `{"html": "<script>"}`; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q", got)
+	}
+}
+
+func TestSanitizeResponse_NoContentType(t *testing.T) {
+	resp := proxyGet(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = nil // keep net/http from sniffing one
+		w.Write([]byte(`<p>hi</p><img src=x onerror="alert(1)"><script>alert(2)</script>`))
+	})
+	body, _ := io.ReadAll(resp.Body)
+	if want := `<p>hi</p><img src="x" />`; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q", got)
+	}
+
+	for _, contentType := range []string{"application/xhtml+xml; charset=utf-8", "text/html; ;;bogus"} {
+		resp := &http.Response{
+			Header: http.Header{"Content-Type": {contentType}},
+			Body:   io.NopCloser(strings.NewReader(`<b>b</b><script>x</script>`)),
+		}
+		if err := htmlsanitizer.SanitizeResponse(nil)(resp); err != nil {
+			t.Fatal(err)
+		}
+		if body, _ := io.ReadAll(resp.Body); string(body) != `<b>b</b>` {
+			t.Errorf("%s: body = %q", contentType, body)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package htmlsanitizer
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// EditorialPolicy returns DefaultPolicy with NormalizeQuotes set and
+// runs of two or more images grouped into galleries, for publishing
+// platforms that accept content from rich-text editors.
+func EditorialPolicy() *Policy {
+	p := DefaultPolicy()
+	p.AllowedAttributes["figure"] = []string{"data-gallery-size"}
+	p.NormalizeQuotes = true
+	p.GalleryMinImages = 2
+	return p
+}
+
+// normalizeQuotes applies Policy.NormalizeQuotes to the blockquotes
+// below root. It does nothing unless the policy allows figure and
+// figcaption.
+func (c *compiledPolicy) normalizeQuotes(root *html.Node) {
+	if !c.allowedTags["figure"] || !c.allowedTags["figcaption"] {
+		return
+	}
+	var quotes []*html.Node
+	walkElements(root, func(n *html.Node) {
+		if n.Data == "blockquote" && n.Namespace == "" {
+			quotes = append(quotes, n)
+		}
+	})
+	for _, q := range quotes {
+		c.normalizeQuote(q)
+	}
+}
+
+// normalizeQuote moves the attribution of the blockquote q, a <footer>
+// or <cite> that ends it, into a <figcaption> following q in a
+// <figure class="quote">. q's figure is reused if q is alone in it.
+func (c *compiledPolicy) normalizeQuote(q *html.Node) {
+	attribution := lastElementChild(q)
+	if attribution == nil || attribution.Data != "footer" && attribution.Data != "cite" ||
+		!onlySpace(attribution.NextSibling, nil) || onlySpace(q.FirstChild, attribution) {
+		return
+	}
+	fig := q.Parent
+	if fig.Type != html.ElementNode || fig.Data != "figure" || firstElementChild(fig) != q || lastElementChild(fig) != q {
+		fig = &html.Node{Type: html.ElementNode, Data: "figure", DataAtom: atom.Figure}
+		q.Parent.InsertBefore(fig, q)
+		q.Parent.RemoveChild(q)
+		fig.AppendChild(q)
+	}
+	if c.attrAllowed("class", "figure") {
+		addClass(fig, withPrefix(c.p.Namespace, "quote"))
+	}
+
+	caption := &html.Node{Type: html.ElementNode, Data: "figcaption", DataAtom: atom.Figcaption}
+	q.RemoveChild(attribution)
+	if attribution.Data == "footer" {
+		for n := attribution.FirstChild; n != nil; n = attribution.FirstChild {
+			attribution.RemoveChild(n)
+			caption.AppendChild(n)
+		}
+	} else {
+		caption.AppendChild(attribution)
+	}
+	// Drop the whitespace the attribution leaves at the end of q.
+	for n := q.LastChild; n != nil && n.Type == html.TextNode && strings.TrimSpace(n.Data) == ""; n = q.LastChild {
+		q.RemoveChild(n)
+	}
+	fig.InsertBefore(caption, q.NextSibling)
+}
+
+// addClass adds name to the class list of n unless it is there.
+func addClass(n *html.Node, name string) {
+	classes := strings.Fields(GetAttr(n, "class"))
+	if slices.Contains(classes, name) {
+		return
+	}
+	SetAttr(n, "class", strings.Join(append(classes, name), " "))
+}
+
+// firstElementChild and lastElementChild return the first and last
+// element children of n, or nil.
+func firstElementChild(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			return c
+		}
+	}
+	return nil
+}
+
+func lastElementChild(n *html.Node) *html.Node {
+	for c := n.LastChild; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// onlySpace reports whether the siblings from n up to end are all
+// whitespace text.
+func onlySpace(n, end *html.Node) bool {
+	for s := n; s != end; s = s.NextSibling {
+		if s.Type != html.TextNode || strings.TrimSpace(s.Data) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package htmlsanitizer_test
+
+import (
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestNormalizeQuotes(t *testing.T) {
+	p := htmlsanitizer.EditorialPolicy()
+	tests := []struct {
+		name, in, want string
+	}{
+		{"wordpress",
+			`<blockquote class="wp-block-quote"><p>Less is more.</p><cite>Mies</cite></blockquote>`,
+			`<figure class="quote"><blockquote class="wp-block-quote"><p>Less is more.</p></blockquote><figcaption><cite>Mies</cite></figcaption></figure>`},
+		{"bootstrap",
+			"<blockquote cite=\"https://example.com/talk\"><p>Hi.</p>\n<footer>Ann in <cite>Talk</cite></footer>\n</blockquote>",
+			`<figure class="quote"><blockquote cite="https://example.com/talk"><p>Hi.</p></blockquote><figcaption>Ann in <cite>Talk</cite></figcaption></figure>`},
+		{"pullquote reuses figure",
+			`<figure class="wp-block-pullquote"><blockquote><p>Go.</p><cite>Rob</cite></blockquote></figure>`,
+			`<figure class="wp-block-pullquote quote"><blockquote><p>Go.</p></blockquote><figcaption><cite>Rob</cite></figcaption></figure>`},
+		{"no attribution", `<blockquote><p>Plain.</p></blockquote>`, `<blockquote><p>Plain.</p></blockquote>`},
+		{"cite not last", `<blockquote><cite>Work</cite> says so.</blockquote>`, `<blockquote><cite>Work</cite> says so.</blockquote>`},
+		{"only attribution", `<blockquote><cite>Work</cite></blockquote>`, `<blockquote><cite>Work</cite></blockquote>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlsanitizer.Sanitize(tt.in, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize_CiteURL(t *testing.T) {
+	got, err := htmlsanitizer.Sanitize(`<blockquote cite="javascript:alert(1)">x</blockquote><q cite="https://example.com/">y</q>`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<blockquote>x</blockquote><q cite="https://example.com/">y</q>`
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package htmlsanitizer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var registry = struct {
+	sync.RWMutex
+	policies map[string]*Policy
+}{
+	policies: map[string]*Policy{
+		"default":   DefaultPolicy(),
+		"strict":    StrictPolicy(),
+		"docs":      DocsPolicy(),
+		"chat":      ChatPolicy(),
+		"math":      MathPolicy(),
+		"epub":      EPUBPolicy(),
+		"editorial": EditorialPolicy(),
+	},
+}
+
+// RegisterPolicy makes p available to PolicyByName under name. Names
+// are case-insensitive. Registering a name again replaces the previous
+// policy, so applications can override the built-in presets. It panics
+// if name is empty or p is nil.
+//
+// A registered policy is shared by every caller of PolicyByName and
+// must not be modified afterwards.
+func RegisterPolicy(name string, p *Policy) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		panic("htmlsanitizer: RegisterPolicy with empty name")
+	}
+	if p == nil {
+		panic("htmlsanitizer: RegisterPolicy with nil policy " + name)
+	}
+	registry.Lock()
+	registry.policies[name] = p
+	registry.Unlock()
+}
+
+// PolicyByName returns the policy registered under name. The built-in
+// presets are registered as "default", "strict", "docs", "chat",
+// "math", "epub", and "editorial". The returned policy is shared and
+// must not be modified.
+func PolicyByName(name string) (*Policy, bool) {
+	registry.RLock()
+	p, ok := registry.policies[strings.ToLower(strings.TrimSpace(name))]
+	registry.RUnlock()
+	return p, ok
+}
+
+// PolicyNames returns the names of all registered policies in sorted
+// order.
+func PolicyNames() []string {
+	registry.RLock()
+	names := make([]string, 0, len(registry.policies))
+	for name := range registry.policies {
+		names = append(names, name)
+	}
+	registry.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// defaultPolicy holds the policy set by SetDefaultPolicy, if any.
+var defaultPolicy atomic.Pointer[Policy]
+
+// SetDefaultPolicy makes a copy of p the policy that the functions and
+// constructors of this package use when they are passed a nil policy,
+// so that an application can configure it once at startup instead of
+// passing a policy at every call site. Passing nil restores
+// DefaultPolicy.
+//
+// It is safe to call while other goroutines sanitize: each call uses
+// either the old or the new policy, never a mix of the two. Sanitizers
+// and other values constructed with a nil policy keep the policy that
+// was the default when they were constructed. The "default" policy of
+// PolicyByName is not affected.
+func SetDefaultPolicy(p *Policy) {
+	if p != nil {
+		p = clonePolicy(p)
+	}
+	defaultPolicy.Store(p)
+}
+
+// GetDefaultPolicy returns a copy of the policy used in place of a nil
+// policy: the one set by SetDefaultPolicy, or else DefaultPolicy().
+func GetDefaultPolicy() *Policy {
+	return clonePolicy(nilPolicy())
+}
+
+// builtinDefault is the DefaultPolicy used in place of a nil policy
+// until SetDefaultPolicy is called.
+var builtinDefault = sync.OnceValue(DefaultPolicy)
+
+// nilPolicy returns the policy used in place of a nil policy. It is
+// shared and must not be modified.
+func nilPolicy() *Policy {
+	if p := defaultPolicy.Load(); p != nil {
+		return p
+	}
+	return builtinDefault()
+}
@@ -0,0 +1,94 @@
+package htmlsanitizer_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestPolicyByName_BuiltIns(t *testing.T) {
+	for _, name := range []string{"default", "strict", " Default "} {
+		if _, ok := htmlsanitizer.PolicyByName(name); !ok {
+			t.Errorf("PolicyByName(%q) not found", name)
+		}
+	}
+	if _, ok := htmlsanitizer.PolicyByName("nope"); ok {
+		t.Error("unknown name found")
+	}
+}
+
+func TestRegisterPolicy(t *testing.T) {
+	p := &htmlsanitizer.Policy{AllowedTags: []string{"b"}}
+	htmlsanitizer.RegisterPolicy("Test-Bold", p)
+	got, ok := htmlsanitizer.PolicyByName("test-bold")
+	if !ok || got != p {
+		t.Fatalf("PolicyByName = %p, %v; want %p", got, ok, p)
+	}
+	found := false
+	for _, name := range htmlsanitizer.PolicyNames() {
+		found = found || name == "test-bold"
+	}
+	if !found {
+		t.Error("PolicyNames does not list test-bold")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterPolicy with nil policy did not panic")
+		}
+	}()
+	htmlsanitizer.RegisterPolicy("nil", nil)
+}
+
+func TestSetDefaultPolicy(t *testing.T) {
+	t.Cleanup(func() { htmlsanitizer.SetDefaultPolicy(nil) })
+
+	p := &htmlsanitizer.Policy{AllowedTags: []string{"b"}, StripDisallowed: true}
+	htmlsanitizer.SetDefaultPolicy(p)
+	p.AllowedTags[0] = "i" // SetDefaultPolicy keeps a copy
+
+	got, err := htmlsanitizer.Sanitize(`<b>bold</b> <i>italic</i>`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<b>bold</b> "; got != want {
+		t.Errorf("Sanitize with nil policy = %q, want %q", got, want)
+	}
+	if fp := htmlsanitizer.GetDefaultPolicy().Fingerprint(); fp != (&htmlsanitizer.Policy{AllowedTags: []string{"b"}, StripDisallowed: true}).Fingerprint() {
+		t.Error("GetDefaultPolicy does not return the policy set")
+	}
+
+	htmlsanitizer.SetDefaultPolicy(nil)
+	if htmlsanitizer.GetDefaultPolicy().Fingerprint() != htmlsanitizer.DefaultPolicy().Fingerprint() {
+		t.Error("SetDefaultPolicy(nil) did not restore DefaultPolicy")
+	}
+}
+
+func TestSetDefaultPolicy_Concurrent(t *testing.T) {
+	t.Cleanup(func() { htmlsanitizer.SetDefaultPolicy(nil) })
+	strict := htmlsanitizer.StrictPolicy()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				htmlsanitizer.SetDefaultPolicy(strict)
+				htmlsanitizer.SetDefaultPolicy(nil)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				got, err := htmlsanitizer.Sanitize(`<b>x</b>`, nil)
+				if err != nil || (got != "<b>x</b>" && got != "x") {
+					t.Errorf("Sanitize = %q, %v", got, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,82 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// bufferPool holds output buffers, so that rendering reuses their
+// capacity instead of growing a fresh buffer for every call.
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledBuffer keeps unusually large buffers from being pinned in
+// the pool.
+const maxPooledBuffer = 1 << 20
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// Render writes n and its descendants to w the way Sanitize serializes
+// its output: attribute values double-quoted and escaped, void elements
+// self-closed, comments and doctypes left out. It does not sanitize; use
+// it on trees the sanitizer produced, such as the root handed to
+// PostTransformers. When w is a *bytes.Buffer with enough capacity,
+// Render does not allocate.
+func Render(w io.Writer, n *html.Node) error {
+	if buf, ok := w.(*bytes.Buffer); ok {
+		render(buf, n, nil)
+		return nil
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	render(buf, n, nil)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// render serializes a cleaned node and its descendants into buf. c is
+// the policy that cleaned them, or nil if it is not known.
+func render(buf *bytes.Buffer, n *html.Node, c *compiledPolicy) {
+	switch n.Type {
+	case html.TextNode:
+		escapeText(buf, n.Data)
+
+	case html.ElementNode:
+		buf.WriteByte('<')
+		buf.WriteString(n.Data)
+		for _, a := range n.Attr {
+			writeAttr(buf, a)
+		}
+		if c.selfCloses(n) {
+			buf.WriteString(" />")
+			return
+		}
+		buf.WriteByte('>')
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			render(buf, child, c)
+		}
+		buf.WriteString("</")
+		buf.WriteString(n.Data)
+		buf.WriteByte('>')
+
+	case html.CommentNode, html.DoctypeNode:
+		// never emitted
+
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			render(buf, child, c)
+		}
+	}
+}
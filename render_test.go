@@ -0,0 +1,78 @@
+package htmlsanitizer_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+// renderInput is a sanitized document with a mix of elements,
+// attributes, escaped text, and void elements.
+var renderInput = strings.Repeat(`<p class="intro" id="p1">Hello <b>world</b> &amp; <a href="https://example.com/?a=1&amp;b=2" title="say &#34;hi&#34;">link</a><br /><img src="/a.png" alt="a" /></p>`, 50)
+
+func parseFragment(tb testing.TB, s string) *html.Node {
+	tb.Helper()
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	root := &html.Node{Type: html.DocumentNode}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	return root
+}
+
+func TestRender(t *testing.T) {
+	root := parseFragment(t, renderInput)
+	var buf bytes.Buffer
+	if err := htmlsanitizer.Render(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != renderInput {
+		t.Errorf("Render changed sanitized output:\n got  %.120q\n want %.120q", buf.String(), renderInput)
+	}
+
+	var sb strings.Builder
+	if err := htmlsanitizer.Render(&sb, root); err != nil || sb.String() != renderInput {
+		t.Errorf("Render to strings.Builder: %v", err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		_ = htmlsanitizer.Render(&buf, root)
+	})
+	if allocs != 0 {
+		t.Errorf("Render allocated %v times per run, want 0", allocs)
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	root := parseFragment(b, renderInput)
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	b.SetBytes(int64(len(renderInput)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		_ = htmlsanitizer.Render(&buf, root)
+	}
+}
+
+func BenchmarkSanitizeTokens(b *testing.B) {
+	p := htmlsanitizer.DefaultPolicy()
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	b.SetBytes(int64(len(renderInput)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		z := html.NewTokenizer(strings.NewReader(renderInput))
+		_ = htmlsanitizer.SanitizeTokens(z, p, htmlsanitizer.TokenWriter(&buf))
+	}
+}
@@ -0,0 +1,83 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"strings"
+)
+
+// Report describes what a sanitization pass did to its input.
+type Report struct {
+	// PolicyVersion is the Version of the policy that produced the
+	// output.
+	PolicyVersion string
+
+	// PolicyFingerprint is the Fingerprint of the policy that produced
+	// the output.
+	PolicyFingerprint string
+
+	// RemovedElements counts disallowed elements that were stripped
+	// or escaped.
+	RemovedElements int
+
+	// RemovedAttributes counts attributes dropped by the attribute
+	// allow-lists or by URL validation.
+	RemovedAttributes int
+
+	// Language is the canonical language tag reported by
+	// Policy.DetectLanguage, or "" if detection is off or failed.
+	Language string
+
+	// Issues lists the recoverable problems the pass repaired, in
+	// document order. See SanitizeLenient.
+	Issues []*Issue
+
+	// Warnings collects the messages returned by Policy.Warn, in
+	// document order.
+	Warnings []string
+
+	// Payloads holds the hostile content removed from the input, in
+	// document order, when Policy.Forensics is set. PayloadsDropped
+	// counts those left out once Policy.MaxPayloads was reached.
+	Payloads        []Payload
+	PayloadsDropped int
+
+	// Held reports whether Policy.ContentFilter asked for the content
+	// to be held for review.
+	Held bool
+
+	lenient bool // record MaxOutputLength as an issue instead of failing
+}
+
+// SanitizeWithReport is like Sanitize but also returns a Report
+// describing the pass.
+func SanitizeWithReport(htmlStr string, p *Policy) (string, *Report, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	rep := &Report{
+		PolicyVersion:     p.Version,
+		PolicyFingerprint: p.Fingerprint(),
+	}
+	out, err := sanitize(context.Background(), strings.NewReader(htmlStr), compilePolicy(p), rep)
+	if err != nil {
+		return "", nil, err
+	}
+	return out, rep, nil
+}
+
+// merge adds the counters of o to r and appends its issues, warnings,
+// and payloads. Payloads beyond limit are counted as dropped.
+func (r *Report) merge(o *Report, limit int) {
+	r.RemovedElements += o.RemovedElements
+	r.RemovedAttributes += o.RemovedAttributes
+	r.Issues = append(r.Issues, o.Issues...)
+	r.Warnings = append(r.Warnings, o.Warnings...)
+	r.PayloadsDropped += o.PayloadsDropped
+	for _, pl := range o.Payloads {
+		if len(r.Payloads) < limit {
+			r.Payloads = append(r.Payloads, pl)
+		} else {
+			r.PayloadsDropped++
+		}
+	}
+}
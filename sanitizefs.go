@@ -0,0 +1,107 @@
+package htmlsanitizer
+
+import (
+	"io/fs"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// OutputFunc receives the result of sanitizing one file for SanitizeFS:
+// the file's path in the file system and its sanitized content, or the
+// error reading or sanitizing it. Returning an error stops SanitizeFS.
+type OutputFunc func(name, sanitized string, err error) error
+
+// SanitizeFS sanitizes every regular file of fsys whose path matches
+// glob, on as many goroutines as GOMAXPROCS, and passes each result to
+// out, for example to clean archived or third-party HTML while building
+// a static site:
+//
+//	err := htmlsanitizer.SanitizeFS(os.DirFS("archive"), "*.html", p,
+//		func(name, sanitized string, err error) error {
+//			if err != nil {
+//				return fmt.Errorf("%s: %w", name, err)
+//			}
+//			return os.WriteFile(filepath.Join("public", name), []byte(sanitized), 0o644)
+//		})
+//
+// The glob has the syntax of path.Match and is matched against the
+// slash-separated path of each file, or against its base name if glob
+// holds no slash, so that "*.html" matches at any depth. Errors reading
+// or sanitizing a file go to out, which decides whether to go on; out
+// is called from one goroutine at a time, in no particular order.
+// SanitizeFS returns the first error from out or from walking fsys. If
+// p is nil, the default policy is used.
+func SanitizeFS(fsys fs.FS, glob string, p *Policy, out OutputFunc) error {
+	if _, err := path.Match(glob, ""); err != nil {
+		return err
+	}
+	s := NewSanitizer(p)
+	names := make(chan string)
+	done := make(chan struct{})
+	var (
+		mu       sync.Mutex
+		firstErr error
+		stop     sync.Once
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		stop.Do(func() {
+			firstErr = err
+			close(done)
+		})
+	}
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				var sanitized string
+				data, err := fs.ReadFile(fsys, name)
+				if err == nil {
+					sanitized, err = s.Sanitize(string(data))
+				}
+				mu.Lock()
+				select {
+				case <-done:
+				default:
+					if err := out(name, sanitized, err); err != nil {
+						fail(err)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	walkErr := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || !matchGlob(glob, name) {
+			return nil
+		}
+		select {
+		case names <- name:
+			return nil
+		case <-done:
+			return fs.SkipAll
+		}
+	})
+	close(names)
+	wg.Wait()
+	if walkErr != nil && firstErr == nil {
+		return walkErr
+	}
+	return firstErr
+}
+
+// matchGlob reports whether name matches glob as SanitizeFS describes.
+func matchGlob(glob, name string) bool {
+	if !strings.Contains(glob, "/") {
+		name = path.Base(name)
+	}
+	ok, _ := path.Match(glob, name)
+	return ok
+}
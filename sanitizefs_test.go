@@ -0,0 +1,90 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitizeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":         {Data: []byte(`<p>home</p><script>x</script>`)},
+		"posts/a.html":       {Data: []byte(`<p onclick="x()">a</p>`)},
+		"posts/old/b.html":   {Data: []byte(`<b>b</b>`)},
+		"posts/notes.txt":    {Data: []byte(`<script>x</script>`)},
+		"posts/big.html":     {Data: []byte(strings.Repeat("<p>x</p>", 100))},
+		"assets/style.css":   {Data: []byte(`p {}`)},
+		"posts/old/c.html/x": {Data: []byte(`<i>nested</i>`)},
+	}
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxInputSize = 200
+	got := make(map[string]string)
+	err := htmlsanitizer.SanitizeFS(fsys, "*.html", p, func(name, sanitized string, err error) error {
+		if err != nil {
+			sanitized = "error"
+		}
+		got[name] = sanitized
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"index.html":       `<p>home</p>`,
+		"posts/a.html":     `<p>a</p>`,
+		"posts/old/b.html": `<b>b</b>`,
+		"posts/big.html":   "error",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v", got)
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %q, want %q", name, got[name], w)
+		}
+	}
+}
+
+func TestSanitizeFS_Glob(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.html":       {Data: []byte(`a`)},
+		"posts/b.html": {Data: []byte(`b`)},
+		"posts/c.htm":  {Data: []byte(`c`)},
+	}
+	var names []string
+	err := htmlsanitizer.SanitizeFS(fsys, "posts/*.htm*", nil, func(name, _ string, err error) error {
+		names = append(names, name)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "posts/b.html" || names[1] != "posts/c.htm" {
+		t.Errorf("names = %v", names)
+	}
+	if err := htmlsanitizer.SanitizeFS(fsys, "[", nil, nil); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("bad glob: err = %v", err)
+	}
+}
+
+func TestSanitizeFS_Stop(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, c := range "abcdefghijklmnopqrstuvwxyz" {
+		fsys[string(c)+".html"] = &fstest.MapFile{Data: []byte(`<p>x</p>`)}
+	}
+	errStop := errors.New("stop")
+	calls := 0
+	err := htmlsanitizer.SanitizeFS(fsys, "*.html", nil, func(string, string, error) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want errStop after 1", err, calls)
+	}
+}
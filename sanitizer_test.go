@@ -1,6 +1,7 @@
 package htmlsanitizer_test
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -59,10 +60,10 @@ func TestSanitize_AllowedTagPreserved(t *testing.T) {
 
 func TestSanitize_StripDisallowed(t *testing.T) {
 	p := &htmlsanitizer.Policy{
-		AllowedTags:     []string{"p"},
+		AllowedTags:       []string{"p"},
 		AllowedAttributes: map[string][]string{},
-		AllowedSchemes:  []string{"https"},
-		StripDisallowed: true,
+		AllowedSchemes:    []string{"https"},
+		StripDisallowed:   true,
 	}
 	input := `<p>keep</p><div>gone</div>`
 	got, err := htmlsanitizer.Sanitize(input, p)
@@ -79,10 +80,10 @@ func TestSanitize_StripDisallowed(t *testing.T) {
 
 func TestSanitize_EscapeDisallowed(t *testing.T) {
 	p := &htmlsanitizer.Policy{
-		AllowedTags:     []string{"p"},
+		AllowedTags:       []string{"p"},
 		AllowedAttributes: map[string][]string{},
-		AllowedSchemes:  []string{"https"},
-		StripDisallowed: false,
+		AllowedSchemes:    []string{"https"},
+		StripDisallowed:   false,
 	}
 	input := `<p>keep</p><div>escaped</div>`
 	got, err := htmlsanitizer.Sanitize(input, p)
@@ -122,6 +123,42 @@ func TestSanitize_MaxDepth(t *testing.T) {
 	}
 }
 
+func TestSanitize_MaxNestingDepth(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxNestingDepth = 3
+	got, rep, err := htmlsanitizer.SanitizeWithReport(`<div><div><div><div>x<b>y</b></div>z</div></div></div>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<div><div><div>z</div></div></div>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(rep.Issues) != 1 || !errors.Is(rep.Issues[0], htmlsanitizer.ErrNestingTooDeep) {
+		t.Errorf("issues = %v, want one ErrNestingTooDeep", rep.Issues)
+	}
+
+	// The default limit applies even without MaxDepth, and pathological
+	// nesting is cut off rather than overflowing the stack.
+	deep := strings.Repeat("<div>", 3000) + "x"
+	got, err = htmlsanitizer.Sanitize(deep, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(got, "<div>"); n != htmlsanitizer.DefaultMaxNestingDepth {
+		t.Errorf("kept %d levels, want %d", n, htmlsanitizer.DefaultMaxNestingDepth)
+	}
+
+	p = p.Clone()
+	p.MaxNestingDepth = -1
+	got, err = htmlsanitizer.Sanitize(strings.Repeat("<div>", 600)+"x", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(got, "<div>"); n != 600 {
+		t.Errorf("without a limit kept %d levels, want 600", n)
+	}
+}
+
 func TestSanitize_Transformer(t *testing.T) {
 	p := htmlsanitizer.DefaultPolicy()
 	p.Transformers = []htmlsanitizer.Transformer{
@@ -170,6 +207,81 @@ func TestSanitize_TransformerNilRemovesNode(t *testing.T) {
 	}
 }
 
+func TestSanitize_TransformerInsertion(t *testing.T) {
+	el := func(tag string, attrs ...html.Attribute) *html.Node {
+		return &html.Node{Type: html.ElementNode, Data: tag, Attr: attrs}
+	}
+	text := func(s string) *html.Node { return &html.Node{Type: html.TextNode, Data: s} }
+
+	p := htmlsanitizer.DefaultPolicy()
+	p.StripDisallowed = true
+	p.Transformers = []htmlsanitizer.Transformer{
+		func(n *html.Node) *html.Node {
+			switch n.Data {
+			case "h2":
+				a := el("a", html.Attribute{Key: "href", Val: "#top"}, html.Attribute{Key: "onclick", Val: "bad()"})
+				a.AppendChild(text("<#>"))
+				htmlsanitizer.InsertAfter(n, a)
+				script := el("script")
+				script.AppendChild(text("alert(1)"))
+				htmlsanitizer.InsertBefore(n, script)
+			case "img":
+				fig := el("figure")
+				htmlsanitizer.InsertBefore(n, fig)
+				n.Parent.RemoveChild(n)
+				fig.AppendChild(n)
+				caption := el("figcaption")
+				caption.AppendChild(text(htmlsanitizer.GetAttr(n, "alt")))
+				fig.AppendChild(caption)
+			case "b":
+				// Wrapped in an element the policy strips, <b> goes too.
+				wrapper := el("marquee")
+				htmlsanitizer.InsertBefore(n, wrapper)
+				n.Parent.RemoveChild(n)
+				wrapper.AppendChild(n)
+			}
+			return n
+		},
+	}
+	input := `<h2>Title</h2><p><img src="/a.png" alt="A &amp; B"> after <b>gone</b></p>`
+	want := `<h2>Title</h2><a href="#top">&lt;#&gt;</a><p><figure><img src="/a.png" alt="A &amp; B" /><figcaption>A &amp; B</figcaption></figure> after </p>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if got := sanitizeTokens(t, input, p); got != want {
+		t.Errorf("tokens: got  %q\nwant %q", got, want)
+	}
+}
+
+func TestSanitize_PreFilterTransformers(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.PreFilterTransformers = []htmlsanitizer.Transformer{
+		func(n *html.Node) *html.Node {
+			if n.Data == "img" {
+				htmlsanitizer.SetAttr(n, "src", htmlsanitizer.GetAttr(n, "data-src"))
+				htmlsanitizer.SetAttr(n, "onerror", "bad()")
+			}
+			return n
+		},
+	}
+	input := `<img data-src="https://example.com/a.png" alt="a"><img data-src="javascript:alert(1)">`
+	want := `<img alt="a" src="https://example.com/a.png" /><img />`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if got := sanitizeTokens(t, input, p); got != want {
+		t.Errorf("tokens: got  %q\nwant %q", got, want)
+	}
+}
+
 func TestSanitize_Linkify(t *testing.T) {
 	p := htmlsanitizer.DefaultPolicy()
 	p.Linkify = true
@@ -197,6 +309,23 @@ func TestStripTags(t *testing.T) {
 	}
 }
 
+func TestStripTagsMarkdown(t *testing.T) {
+	input := "<p>*bold* _it_ `code` [link](http://x) a\\b ~s~</p>\n<p># not a heading</p>\n<p>1. not a list</p>"
+	got, err := htmlsanitizer.StripTagsMarkdown(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\\*bold\\* \\_it\\_ \\`code\\` \\[link\\](http://x) a\\\\b \\~s\\~\n\\# not a heading\n1\\. not a list"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// A literal "&copy;" must not render as ©.
+	if got, _ := htmlsanitizer.StripTagsMarkdown("<p>&amp;copy;</p>"); got != `\&copy;` {
+		t.Errorf("got %q, want %q", got, `\&copy;`)
+	}
+}
+
 func TestSanitizeReader(t *testing.T) {
 	input := `<b>hello</b><script>bad</script>`
 	r := strings.NewReader(input)
@@ -249,8 +378,203 @@ func TestStrictPolicy_StripsDivs(t *testing.T) {
 func BenchmarkSanitize(b *testing.B) {
 	input := strings.Repeat(`<p>Hello <b>world</b> <script>bad()</script> <a href="http://x.com">link</a></p>`, 100)
 	p := htmlsanitizer.DefaultPolicy()
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		_, _ = htmlsanitizer.Sanitize(input, p)
 	}
 }
+
+func BenchmarkSanitize_PlainText(b *testing.B) {
+	input := strings.Repeat("Great post, thanks -> I'll try this tonight. ", 10)
+	p := htmlsanitizer.DefaultPolicy()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = htmlsanitizer.Sanitize(input, p)
+	}
+}
+
+// Sanitize skips the parser for plain text; SanitizeReader always
+// parses, so the two must agree.
+func TestSanitize_PlainText(t *testing.T) {
+	small := htmlsanitizer.DefaultPolicy()
+	small.MaxInputSize = 10
+	marked := htmlsanitizer.DefaultPolicy()
+	marked.EmbedMarker = true
+	inputs := []string{
+		"", "  \n", "just text", "\t indented", "a > b, \"quoted\" and 'single'",
+		"crlf\r\nline", "nul\x00", "fish &amp; chips", "<b>x</b>", "longer than ten bytes",
+	}
+	for _, p := range []*htmlsanitizer.Policy{htmlsanitizer.DefaultPolicy(), small, marked} {
+		for _, in := range inputs {
+			got, err := htmlsanitizer.Sanitize(in, p)
+			want, wantErr := htmlsanitizer.SanitizeReader(strings.NewReader(in), p)
+			if got != want || (err == nil) != (wantErr == nil) {
+				t.Errorf("Sanitize(%q) = %q, %v; parser gives %q, %v", in, got, err, want, wantErr)
+			}
+		}
+	}
+}
+
+func TestSanitize_PostTransformers(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	seen := map[string]bool{}
+	p.PostTransformers = []func(root *html.Node){
+		// Drop duplicate ids, keeping the first occurrence.
+		func(root *html.Node) {
+			var walk func(*html.Node)
+			walk = func(n *html.Node) {
+				if id := htmlsanitizer.GetAttr(n, "id"); id != "" {
+					if seen[id] {
+						htmlsanitizer.RemoveAttr(n, "id")
+					}
+					seen[id] = true
+				}
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+			}
+			walk(root)
+		},
+	}
+	input := `<p id="a">one</p><script>x</script><div><p id="a">two</p></div>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p id="a">one</p><div><p>two</p></div>`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_DisallowedActions(t *testing.T) {
+	p := &htmlsanitizer.Policy{
+		AllowedTags:       []string{"p", "b"},
+		AllowedAttributes: map[string][]string{},
+		DisallowedActions: map[string]htmlsanitizer.DisallowedAction{
+			"font":   htmlsanitizer.DisallowedUnwrap,
+			"span":   htmlsanitizer.DisallowedUnwrap,
+			"iframe": htmlsanitizer.DisallowedStrip,
+		},
+	}
+	input := `<p><font color="red">red <b>bold</b></font> <span>s</span><iframe>f</iframe><blink>x</blink></p>`
+	want := `<p>red <b>bold</b> s&lt;blink&gt;x&lt;/blink&gt;</p>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	// The token API applies the same actions.
+	var sb strings.Builder
+	z := html.NewTokenizer(strings.NewReader(input))
+	if err := htmlsanitizer.SanitizeTokens(z, p, htmlsanitizer.TokenWriter(&sb)); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != want {
+		t.Errorf("tokens: got %q want %q", sb.String(), want)
+	}
+}
+
+func TestSanitize_DisallowedActionsWildcard(t *testing.T) {
+	p := htmlsanitizer.StrictPolicy()
+	p.DisallowedActions = map[string]htmlsanitizer.DisallowedAction{
+		"*":     htmlsanitizer.DisallowedUnwrap,
+		"style": htmlsanitizer.DisallowedUnwrap,
+	}
+	got, err := htmlsanitizer.Sanitize(`<div><b>kept</b> text</div><style>p{}</style>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `<b>kept</b> text` {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSanitize_DropContentTagsInEscapeMode(t *testing.T) {
+	input := `<p>a</p><style>p{color:red}</style><noscript>enable js</noscript><iframe>frame</iframe><blink>shown</blink>`
+	got, err := htmlsanitizer.Sanitize(input, htmlsanitizer.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"color", "enable js", "frame", "noscript"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("%q leaked into output: %s", leaked, got)
+		}
+	}
+	if !strings.Contains(got, "shown") {
+		t.Errorf("content of other escaped tags should survive: %s", got)
+	}
+}
+
+func TestSanitize_DropContentTagsCustom(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.DropContentTags = []string{"blink"}
+	got, err := htmlsanitizer.Sanitize(`<blink>gone</blink><noscript>kept</noscript>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `&lt;noscript&gt;kept&lt;/noscript&gt;` {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSanitize_RawTextActions(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, "textarea", "xmp")
+	p.RawTextActions = map[string]htmlsanitizer.DisallowedAction{
+		"textarea": htmlsanitizer.DisallowedUnwrap,
+		"xmp":      htmlsanitizer.DisallowedStrip,
+		"title":    htmlsanitizer.DisallowedEscape,
+	}
+	input := `<textarea><img src=x onerror=alert(1)></textarea><xmp><b>x</b></xmp><title>t</title>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `&lt;img src=x onerror=alert(1)&gt;&lt;title&gt;t&lt;/title&gt;`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSanitize_RawTextCode(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Linkify = true
+	p.RawTextActions = map[string]htmlsanitizer.DisallowedAction{
+		"script": htmlsanitizer.DisallowedCode,
+		"style":  htmlsanitizer.DisallowedCode,
+	}
+	input := `<p>Try:</p><script type="module">if (a < b) fetch("https://example.com")</script>` +
+		`<style>p { color: red }</style><script type="application/ld+json">{}</script>`
+	want := `<p>Try:</p><pre><code class="language-javascript">if (a &lt; b) fetch("https://example.com")</code></pre>` +
+		`<pre><code class="language-css">p { color: red }</code></pre><pre><code class="language-json">{}</code></pre>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if got := sanitizeTokens(t, input, p); got != want {
+		t.Errorf("tokens: got  %q\nwant %q", got, want)
+	}
+}
+
+func TestSanitize_RawTextDefaults(t *testing.T) {
+	input := `<xmp><b>shown as text</b></xmp><noembed><p>fallback</p></noembed>`
+	got, err := htmlsanitizer.Sanitize(input, htmlsanitizer.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `&lt;b&gt;shown as text&lt;/b&gt;`
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got := sanitizeTokens(t, input, htmlsanitizer.DefaultPolicy()); got != want {
+		t.Errorf("tokens: got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,276 @@
+// Package sanitizertest provides helpers for table-driven golden tests
+// of htmlsanitizer policies.
+//
+// Golden cases live in a directory as pairs of files named
+// NAME.input.html and NAME.expected.html. Run sanitizes every input with
+// a policy and compares the canonical form of the result with the
+// expected file, reporting a line diff on mismatch:
+//
+//	func TestPolicy(t *testing.T) {
+//		sanitizertest.Run(t, "testdata", mypkg.Policy())
+//	}
+//
+// Run the tests with -sanitizertest.update to rewrite the expected
+// files from the current output.
+//
+// CheckSnapshot guards a larger corpus against behavior changes across
+// upgrades by comparing hashes of the outputs with a stored snapshot.
+package sanitizertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"golang.org/x/net/html"
+)
+
+const (
+	inputSuffix    = ".input.html"
+	expectedSuffix = ".expected.html"
+)
+
+var update = flag.Bool("sanitizertest.update", false, "rewrite golden expected files")
+
+// Case is a single golden test case.
+type Case struct {
+	// Name is the file name without the .input.html suffix.
+	Name string
+
+	// Input is the HTML passed to the sanitizer.
+	Input string
+
+	// Expected is the expected sanitized output. It is empty if the
+	// expected file does not exist yet.
+	Expected string
+
+	// ExpectedPath is the path of the expected file.
+	ExpectedPath string
+}
+
+// LoadCases reads every NAME.input.html file in dir together with its
+// NAME.expected.html counterpart. Cases are returned sorted by name. A
+// missing expected file is not an error; its Expected field is empty.
+func LoadCases(dir string) ([]Case, error) {
+	inputs, err := filepath.Glob(filepath.Join(dir, "*"+inputSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(inputs)
+	cases := make([]Case, 0, len(inputs))
+	for _, in := range inputs {
+		input, err := os.ReadFile(in)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(in), inputSuffix)
+		c := Case{
+			Name:         name,
+			Input:        string(input),
+			ExpectedPath: filepath.Join(dir, name+expectedSuffix),
+		}
+		expected, err := os.ReadFile(c.ExpectedPath)
+		switch {
+		case err == nil:
+			c.Expected = string(expected)
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Run loads the golden cases in dir and checks each one in a subtest
+// named after the case. With -sanitizertest.update, the expected files
+// are rewritten instead.
+func Run(t *testing.T, dir string, p *htmlsanitizer.Policy) {
+	t.Helper()
+	cases, err := LoadCases(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatalf("no %s files found in %s", inputSuffix, dir)
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			Check(t, c, p)
+		})
+	}
+}
+
+// Check sanitizes c.Input with p and compares the result with
+// c.Expected after normalizing both.
+func Check(t testing.TB, c Case, p *htmlsanitizer.Policy) {
+	t.Helper()
+	out, err := htmlsanitizer.Sanitize(c.Input, p)
+	if err != nil {
+		t.Fatalf("%s: sanitize: %v", c.Name, err)
+	}
+	got := Normalize(out)
+	if *update {
+		if err := os.WriteFile(c.ExpectedPath, []byte(got+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want := Normalize(c.Expected)
+	if got != want {
+		t.Errorf("%s: output does not match %s (-want +got):\n%s", c.Name, c.ExpectedPath, Diff(want, got))
+	}
+}
+
+// CheckSnapshot takes a Snapshot of corpus, which maps document names
+// to HTML, with p and compares it with the snapshot stored as JSON at
+// path, failing the test with the names of the documents whose output
+// changed. It is meant to catch behavior changes after upgrading
+// htmlsanitizer or golang.org/x/net. With -sanitizertest.update, or if
+// path does not exist yet, the snapshot is written instead.
+func CheckSnapshot(t testing.TB, path string, p *htmlsanitizer.Policy, corpus map[string]string) {
+	t.Helper()
+	got := htmlsanitizer.TakeSnapshot(p, corpus)
+	data, err := os.ReadFile(path)
+	if *update || errors.Is(err, os.ErrNotExist) {
+		data, err := json.MarshalIndent(got, "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want htmlsanitizer.Snapshot
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	diff := want.Diff(got)
+	if diff.Empty() {
+		return
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "output differs from snapshot %s", path)
+	if diff.PolicyChanged {
+		msg.WriteString(" (the policy changed)")
+	}
+	for _, m := range sortedKeys(diff.Versions) {
+		fmt.Fprintf(&msg, "\n%s: %s -> %s", m, diff.Versions[m][0], diff.Versions[m][1])
+	}
+	for _, list := range []struct {
+		label string
+		names []string
+	}{{"changed", diff.Changed}, {"added", diff.Added}, {"removed", diff.Removed}} {
+		if len(list.names) > 0 {
+			fmt.Fprintf(&msg, "\n%s: %s", list.label, strings.Join(list.names, ", "))
+		}
+	}
+	t.Error(msg.String())
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Normalize returns a canonical form of an HTML fragment so that
+// outputs differing only in attribute order, attribute quoting, tag
+// case, entity spelling, or whitespace between and around words compare
+// equal. Each tag and text run starts on a new line, which keeps diffs
+// readable.
+func Normalize(s string) string {
+	var buf bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(strings.TrimSpace(s)))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				buf.WriteString(z.Err().Error())
+			}
+			break
+		}
+		tok := z.Token()
+		switch tok.Type {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			sort.SliceStable(tok.Attr, func(i, j int) bool { return tok.Attr[i].Key < tok.Attr[j].Key })
+			tok.Type = html.StartTagToken
+			newline(&buf)
+			buf.WriteString(tok.String())
+		case html.EndTagToken:
+			newline(&buf)
+			buf.WriteString(tok.String())
+		case html.TextToken, html.CommentToken:
+			tok.Data = strings.Join(strings.Fields(tok.Data), " ")
+			if tok.Data == "" && tok.Type == html.TextToken {
+				continue
+			}
+			newline(&buf)
+			buf.WriteString(tok.String())
+		}
+	}
+	return buf.String()
+}
+
+func newline(buf *bytes.Buffer) {
+	if buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+}
+
+// Diff returns a line diff of want and got in which removed lines are
+// prefixed with "-", added lines with "+", and common lines with " ".
+func Diff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&sb, "  %s\n", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&sb, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&sb, "+ %s\n", b[j])
+			j++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,48 @@
+package sanitizertest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"github.com/njchilds90/htmlsanitizer/sanitizertest"
+)
+
+func TestRun_DefaultPolicy(t *testing.T) {
+	sanitizertest.Run(t, "testdata", htmlsanitizer.DefaultPolicy())
+}
+
+func TestNormalize_Equivalent(t *testing.T) {
+	a := sanitizertest.Normalize(`<A title='x' HREF="/a">t&#39;s</A><br>`)
+	b := sanitizertest.Normalize(`  <a href="/a" title="x">t's</a><br/>`)
+	if a != b {
+		t.Errorf("equivalent fragments normalized differently:\n%s\n---\n%s", a, b)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := sanitizertest.Diff("<p>\na\n</p>", "<p>\nb\n</p>")
+	want := "  <p>\n- a\n+ b\n  </p>\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if strings.Contains(sanitizertest.Diff("x", "x"), "-") {
+		t.Errorf("identical inputs should produce no removals")
+	}
+}
+
+func TestCheckSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.snapshot.json")
+	corpus := map[string]string{"a": `<p>a</p><script>x</script>`, "b": `<b>b</b>`}
+	sanitizertest.CheckSnapshot(t, path, htmlsanitizer.DefaultPolicy(), corpus)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"policy_fingerprint"`) {
+		t.Errorf("snapshot not written:\n%s", data)
+	}
+	sanitizertest.CheckSnapshot(t, path, htmlsanitizer.DefaultPolicy(), corpus)
+}
@@ -0,0 +1,197 @@
+package htmlsanitizer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Rules reported in Finding.Rule.
+const (
+	RuleScriptElement    = "script-element"    // <script> elements
+	RuleEventHandler     = "event-handler"     // on* attributes
+	RuleScriptURL        = "script-url"        // javascript:, vbscript:, and data:text/html URLs
+	RuleDangerousElement = "dangerous-element" // iframe, object, embed, base, form, meta, and similar
+	RuleObfuscatedURL    = "obfuscated-url"    // encoded, IP-addressed, or credential-bearing URLs
+	RuleHiddenText       = "hidden-text"       // text in elements hidden by attributes or inline styles
+	RuleLinkDensity      = "link-density"      // content made up mostly of links
+)
+
+// ruleWeights are the points each occurrence of a rule adds, and
+// whether the rule counts toward the XSS rather than the spam score.
+var ruleWeights = map[string]struct {
+	points float64
+	xss    bool
+}{
+	RuleScriptElement:    {40, true},
+	RuleEventHandler:     {25, true},
+	RuleScriptURL:        {40, true},
+	RuleDangerousElement: {15, true},
+	RuleObfuscatedURL:    {10, false},
+	RuleHiddenText:       {15, false},
+	RuleLinkDensity:      {30, false},
+}
+
+// dangerousTags are elements that have no place in user content and
+// mostly appear in attacks.
+var dangerousTags = map[string]bool{
+	"iframe": true, "frame": true, "frameset": true, "object": true,
+	"embed": true, "applet": true, "base": true, "form": true,
+	"meta": true, "link": true, "portal": true,
+}
+
+// Finding is one rule that matched the input.
+type Finding struct {
+	Rule   string
+	Count  int     // occurrences in the input
+	Points float64 // contribution to the score
+}
+
+// ThreatScore rates how likely an input is to be an attack or spam.
+// XSS and Spam are each capped at 100, as is Score, their sum.
+type ThreatScore struct {
+	Score    float64
+	XSS      float64
+	Spam     float64
+	Findings []Finding // in the order of the Rule constants
+}
+
+// Score inspects htmlStr before sanitizing and rates it on the
+// likelihood of cross-site scripting and spam, from 0 to 100, to flag
+// submissions for review. The rating is a heuristic: a score of 50 or
+// more means the input deserves a look, not that it is hostile. The
+// output of sanitizing is safe whatever the score.
+func Score(htmlStr string) (*ThreatScore, error) {
+	doc, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	var text, linkText int
+	var links int
+	var walk func(n *html.Node, hidden, inLink bool)
+	walk = func(n *html.Node, hidden, inLink bool) {
+		switch n.Type {
+		case html.TextNode:
+			chars := utf8.RuneCountInString(strings.TrimSpace(n.Data))
+			if chars == 0 {
+				break
+			}
+			text += chars
+			if inLink {
+				linkText += chars
+			}
+		case html.ElementNode:
+			tag := strings.ToLower(n.Data)
+			switch {
+			case tag == "script":
+				counts[RuleScriptElement]++
+			case dangerousTags[tag]:
+				counts[RuleDangerousElement]++
+			case tag == "a" && GetAttr(n, "href") != "":
+				links++
+				inLink = true
+			}
+			for _, a := range n.Attr {
+				switch {
+				case isEventHandler(a.Key):
+					counts[RuleEventHandler]++
+				case isURLAttr(a.Key) || a.Key == "formaction" || a.Key == "data":
+					scoreURL(a.Val, counts)
+				}
+			}
+			if !hidden && isHidden(n) && strings.TrimSpace(textContent(n)) != "" {
+				counts[RuleHiddenText]++
+				hidden = true
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, hidden, inLink)
+		}
+	}
+	walk(doc, false, false)
+	if links >= 3 && text > 0 && float64(linkText)/float64(text) > 0.5 {
+		counts[RuleLinkDensity] = 1
+	}
+
+	s := &ThreatScore{}
+	for _, rule := range []string{
+		RuleScriptElement, RuleEventHandler, RuleScriptURL, RuleDangerousElement,
+		RuleObfuscatedURL, RuleHiddenText, RuleLinkDensity,
+	} {
+		n := counts[rule]
+		if n == 0 {
+			continue
+		}
+		w := ruleWeights[rule]
+		f := Finding{Rule: rule, Count: n, Points: w.points * float64(n)}
+		s.Findings = append(s.Findings, f)
+		if w.xss {
+			s.XSS += f.Points
+		} else {
+			s.Spam += f.Points
+		}
+	}
+	s.XSS = min(s.XSS, 100)
+	s.Spam = min(s.Spam, 100)
+	s.Score = min(s.XSS+s.Spam, 100)
+	return s, nil
+}
+
+// scoreURL counts the rules the URL attribute value raw matches.
+func scoreURL(raw string, counts map[string]int) {
+	u, ok := parseURLValue(raw)
+	if !ok {
+		counts[RuleObfuscatedURL]++
+		return
+	}
+	switch u.Scheme {
+	case "javascript", "vbscript":
+		counts[RuleScriptURL]++
+		return
+	case "data":
+		if strings.HasPrefix(strings.ToLower(u.Opaque), "text/html") {
+			counts[RuleScriptURL]++
+			return
+		}
+	}
+	escapes, nested := countPercentEscapes(raw)
+	switch {
+	case u.Scheme != "" && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(raw)), u.Scheme+":"),
+		// A scheme hidden by entities, controls, or whitespace.
+		nested > 0,
+		len(raw) > 0 && float64(3*escapes)/float64(len(raw)) > 0.3,
+		u.User != nil,
+		isIPHost(u.Hostname()):
+		counts[RuleObfuscatedURL]++
+	}
+}
+
+// isHidden reports whether n hides its content through the hidden
+// attribute or an inline style.
+func isHidden(n *html.Node) bool {
+	if hasAttr(n, "hidden") {
+		return true
+	}
+	for _, decl := range strings.Split(GetAttr(n, "style"), ";") {
+		prop, val, _ := strings.Cut(decl, ":")
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		val = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(val), "!important")))
+		switch prop {
+		case "display":
+			if val == "none" {
+				return true
+			}
+		case "visibility":
+			if val == "hidden" || val == "collapse" {
+				return true
+			}
+		case "font-size", "opacity", "max-height", "max-width":
+			if strings.TrimLeft(strings.TrimRight(val, "abcdefghijklmnopqrstuvwxyz%"), "0.") == "" && val != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rules map[string]int
+	}{
+		{"clean", `<p>Hello <a href="https://example.com">world</a>, nice <b>day</b> today.</p>`, nil},
+		{"xss", `<script>x()</script><img src=x onerror="y()"><a href="&#106;avascript:z()">a</a><iframe src="//evil"></iframe>`,
+			map[string]int{
+				htmlsanitizer.RuleScriptElement:    1,
+				htmlsanitizer.RuleEventHandler:     1,
+				htmlsanitizer.RuleScriptURL:        1,
+				htmlsanitizer.RuleDangerousElement: 1,
+			}},
+		{"obfuscated", `<a href="http://2130706433/">a</a> <a href="https://bank.example@evil.example/">b</a> <a href=" h&#x09;ttps://x.example">c</a> <a href="/%2525">d</a><p>Some ordinary surrounding prose.</p>`,
+			map[string]int{htmlsanitizer.RuleObfuscatedURL: 4}},
+		{"spam", `<div style="display: none">buy pills</div><span style="font-size:0px">cheap</span><span style="font-size:0.9em">ok</span>` +
+			strings.Repeat(`<a href="https://spam.example/">click here now</a> `, 3),
+			map[string]int{htmlsanitizer.RuleHiddenText: 2, htmlsanitizer.RuleLinkDensity: 1}},
+	}
+	for _, tt := range tests {
+		s, err := htmlsanitizer.Score(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]int)
+		for _, f := range s.Findings {
+			got[f.Rule] = f.Count
+		}
+		if len(got) != len(tt.rules) {
+			t.Errorf("%s: findings %+v, want %v", tt.name, s.Findings, tt.rules)
+			continue
+		}
+		for rule, n := range tt.rules {
+			if got[rule] != n {
+				t.Errorf("%s: %s = %d, want %d", tt.name, rule, got[rule], n)
+			}
+		}
+	}
+}
+
+func TestScore_Capped(t *testing.T) {
+	s, err := htmlsanitizer.Score(strings.Repeat(`<script>x()</script>`, 10) + `<p hidden>spam</p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.XSS != 100 || s.Spam != 15 || s.Score != 100 {
+		t.Errorf("XSS %v, Spam %v, Score %v", s.XSS, s.Spam, s.Score)
+	}
+}
@@ -0,0 +1,50 @@
+package htmlsanitizer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// codeBlock returns the <pre><code> pair that replaces an element
+// handled with DisallowedCode; the caller adds the text.
+func codeBlock(tag string, attrs []html.Attribute) (pre, code *html.Node) {
+	pre = &html.Node{Type: html.ElementNode, Data: "pre", DataAtom: atom.Pre}
+	code = &html.Node{Type: html.ElementNode, Data: "code", DataAtom: atom.Code}
+	if lang := sourceLanguage(tag, attrs); lang != "" {
+		code.Attr = []html.Attribute{{Key: "class", Val: "language-" + lang}}
+	}
+	pre.AppendChild(code)
+	return pre, code
+}
+
+// sourceLanguage names the language of the content of a script or style
+// element from its type attribute, or returns "" if it is not known.
+func sourceLanguage(tag string, attrs []html.Attribute) string {
+	var typ string
+	for _, a := range attrs {
+		if strings.EqualFold(a.Key, "type") {
+			typ = strings.ToLower(strings.TrimSpace(a.Val))
+		}
+	}
+	if i := strings.IndexByte(typ, ';'); i >= 0 {
+		typ = strings.TrimSpace(typ[:i])
+	}
+	switch tag {
+	case "script":
+		switch {
+		case typ == "", typ == "module", strings.HasSuffix(typ, "javascript"), strings.HasSuffix(typ, "ecmascript"):
+			return "javascript"
+		case typ == "importmap", strings.HasSuffix(typ, "json"):
+			return "json"
+		case strings.HasSuffix(typ, "typescript"):
+			return "typescript"
+		}
+	case "style":
+		if typ == "" || typ == "text/css" {
+			return "css"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package htmlsanitizer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Errors returned by SignedHTML.Verify.
+var (
+	ErrBadSignature   = errors.New("htmlsanitizer: signature does not match")
+	ErrPolicyMismatch = errors.New("htmlsanitizer: content was sanitized with a different policy")
+)
+
+// SignedHTML is sanitized HTML together with the fingerprint of the
+// policy that produced it and an HMAC-SHA256 signature over both, for
+// storing clean HTML where it could be modified after it was sanitized.
+// It marshals to JSON as an object with "html", "fingerprint", and
+// "signature" fields.
+type SignedHTML struct {
+	HTML        string `json:"html"`
+	Fingerprint string `json:"fingerprint"`
+	Signature   string `json:"signature"` // hex-encoded
+}
+
+// SanitizeSigned sanitizes htmlStr with p and signs the result with
+// key. If p is nil, the default policy is used.
+func SanitizeSigned(htmlStr string, p *Policy, key []byte) (SignedHTML, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	out, err := Sanitize(htmlStr, p)
+	if err != nil {
+		return SignedHTML{}, err
+	}
+	return Sign(out, p, key), nil
+}
+
+// Sign signs cleanHTML, which must already have been sanitized with p,
+// with key. It is for content sanitized by other means, such as a
+// Sanitizer or a SanitizingWriter; Sign does not sanitize. If p is nil,
+// the default policy is used.
+func Sign(cleanHTML string, p *Policy, key []byte) SignedHTML {
+	if p == nil {
+		p = nilPolicy()
+	}
+	s := SignedHTML{HTML: cleanHTML, Fingerprint: p.Fingerprint()}
+	s.Signature = hex.EncodeToString(s.mac(key))
+	return s
+}
+
+// Verify checks that s was signed with key and has not been modified
+// since, and that it was sanitized with a policy whose fingerprint is
+// p's. It returns ErrBadSignature if the signature does not match, and
+// ErrPolicyMismatch if it does but p has changed since, in which case
+// the content is intact and can be sanitized again with p. If p is nil,
+// the default policy is used.
+func (s SignedHTML) Verify(key []byte, p *Policy) error {
+	sig, err := hex.DecodeString(s.Signature)
+	if err != nil || !hmac.Equal(sig, s.mac(key)) {
+		return ErrBadSignature
+	}
+	if p == nil {
+		p = nilPolicy()
+	}
+	if s.Fingerprint != p.Fingerprint() {
+		return ErrPolicyMismatch
+	}
+	return nil
+}
+
+// mac returns the HMAC of the fingerprint and the HTML. The fingerprint
+// is written first and ends in a newline it cannot contain, so the two
+// fields cannot be shifted into each other.
+func (s SignedHTML) mac(key []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(s.Fingerprint))
+	m.Write([]byte{'\n'})
+	m.Write([]byte(s.HTML))
+	return m.Sum(nil)
+}
@@ -0,0 +1,64 @@
+package htmlsanitizer_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitizeSigned(t *testing.T) {
+	key := []byte("secret")
+	s, err := htmlsanitizer.SanitizeSigned(`<b onclick="x()">hi</b>`, nil, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.HTML != "<b>hi</b>" {
+		t.Errorf("HTML = %q", s.HTML)
+	}
+	if err := s.Verify(key, nil); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+
+	// The signature survives storage as JSON.
+	data, _ := json.Marshal(s)
+	var stored htmlsanitizer.SignedHTML
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := stored.Verify(key, nil); err != nil {
+		t.Errorf("Verify after JSON round trip: %v", err)
+	}
+}
+
+func TestSignedHTML_Tampered(t *testing.T) {
+	key := []byte("secret")
+	s := htmlsanitizer.Sign("<b>hi</b>", nil, key)
+
+	tests := map[string]func(*htmlsanitizer.SignedHTML){
+		"html":        func(s *htmlsanitizer.SignedHTML) { s.HTML += "<script>x()</script>" },
+		"fingerprint": func(s *htmlsanitizer.SignedHTML) { s.Fingerprint = htmlsanitizer.StrictPolicy().Fingerprint() },
+		"signature":   func(s *htmlsanitizer.SignedHTML) { s.Signature = "zz" },
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			modified := s
+			tamper(&modified)
+			if err := modified.Verify(key, nil); !errors.Is(err, htmlsanitizer.ErrBadSignature) {
+				t.Errorf("err = %v, want ErrBadSignature", err)
+			}
+		})
+	}
+	if err := s.Verify([]byte("other"), nil); !errors.Is(err, htmlsanitizer.ErrBadSignature) {
+		t.Errorf("wrong key: err = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestSignedHTML_PolicyChanged(t *testing.T) {
+	key := []byte("secret")
+	s := htmlsanitizer.Sign("<b>hi</b>", nil, key)
+	if err := s.Verify(key, htmlsanitizer.StrictPolicy()); !errors.Is(err, htmlsanitizer.ErrPolicyMismatch) {
+		t.Errorf("err = %v, want ErrPolicyMismatch", err)
+	}
+}
@@ -0,0 +1,151 @@
+package htmlsanitizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sort"
+)
+
+// snapshotModules are the modules whose versions a Snapshot records:
+// this package and the parser and text packages it builds on.
+var snapshotModules = []string{
+	"github.com/njchilds90/htmlsanitizer",
+	"golang.org/x/net",
+	"golang.org/x/text",
+}
+
+// Snapshot records what a policy makes of a corpus, as a hash of the
+// output for every document, so that the output can be checked for
+// changes after upgrading this package or golang.org/x/net. Snapshots
+// are meant to be stored as JSON next to the corpus and compared with
+// a fresh one in CI:
+//
+//	want := loadSnapshot("corpus.snapshot.json")
+//	got := htmlsanitizer.TakeSnapshot(policy, corpus)
+//	if diff := want.Diff(got); !diff.Empty() {
+//		log.Fatalf("sanitized output changed for %v", diff.Changed)
+//	}
+type Snapshot struct {
+	// PolicyFingerprint is the Fingerprint of the policy, which tells
+	// a change of policy from a change of behavior.
+	PolicyFingerprint string `json:"policy_fingerprint"`
+
+	// Versions holds the versions of this package, golang.org/x/net,
+	// and golang.org/x/text by module path, as far as the build
+	// information of the binary records them.
+	Versions map[string]string `json:"versions,omitempty"`
+
+	// Outputs maps the name of each document to the SHA-256 of its
+	// sanitized output in hex, or to "error: " and the error message if
+	// sanitizing failed.
+	Outputs map[string]string `json:"outputs"`
+}
+
+// TakeSnapshot sanitizes every document of corpus, which maps document
+// names to HTML, with p and returns the Snapshot of the outputs. A nil
+// policy means the default policy.
+func TakeSnapshot(p *Policy, corpus map[string]string) *Snapshot {
+	if p == nil {
+		p = nilPolicy()
+	}
+	s := NewSanitizer(p)
+	snap := &Snapshot{
+		PolicyFingerprint: p.Fingerprint(),
+		Versions:          moduleVersions(),
+		Outputs:           make(map[string]string, len(corpus)),
+	}
+	for name, doc := range corpus {
+		out, err := s.Sanitize(doc)
+		if err != nil {
+			snap.Outputs[name] = "error: " + err.Error()
+			continue
+		}
+		sum := sha256.Sum256([]byte(out))
+		snap.Outputs[name] = hex.EncodeToString(sum[:])
+	}
+	return snap
+}
+
+// moduleVersions returns the versions of snapshotModules in the build
+// information of the running binary.
+func moduleVersions() map[string]string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	versions := make(map[string]string)
+	for _, m := range append([]*debug.Module{&info.Main}, info.Deps...) {
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		if slices.Contains(snapshotModules, m.Path) {
+			versions[m.Path] = m.Version
+		}
+	}
+	return versions
+}
+
+// SnapshotDiff lists how a later Snapshot differs from an earlier one.
+// The lists of document names are sorted.
+type SnapshotDiff struct {
+	// Changed names the documents whose output changed, Added those
+	// only the later snapshot has, and Removed those only the earlier
+	// one has.
+	Changed []string
+	Added   []string
+	Removed []string
+
+	// PolicyChanged reports whether the snapshots were taken with
+	// different policies, in which case changed output may be
+	// intended.
+	PolicyChanged bool
+
+	// Versions maps the modules whose versions differ to their earlier
+	// and later versions.
+	Versions map[string][2]string
+}
+
+// Diff compares s, the earlier snapshot, with later.
+func (s *Snapshot) Diff(later *Snapshot) SnapshotDiff {
+	d := SnapshotDiff{PolicyChanged: s.PolicyFingerprint != later.PolicyFingerprint}
+	for name, hash := range s.Outputs {
+		switch h, ok := later.Outputs[name]; {
+		case !ok:
+			d.Removed = append(d.Removed, name)
+		case h != hash:
+			d.Changed = append(d.Changed, name)
+		}
+	}
+	for name := range later.Outputs {
+		if _, ok := s.Outputs[name]; !ok {
+			d.Added = append(d.Added, name)
+		}
+	}
+	sort.Strings(d.Changed)
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+
+	modules := maps.Clone(s.Versions)
+	if modules == nil {
+		modules = make(map[string]string)
+	}
+	maps.Copy(modules, later.Versions)
+	for m := range modules {
+		if before, after := s.Versions[m], later.Versions[m]; before != after {
+			if d.Versions == nil {
+				d.Versions = make(map[string][2]string)
+			}
+			d.Versions[m] = [2]string{before, after}
+		}
+	}
+	return d
+}
+
+// Empty reports whether no document's output changed, appeared, or
+// disappeared. Policy and version changes alone do not count.
+func (d SnapshotDiff) Empty() bool {
+	return len(d.Changed) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
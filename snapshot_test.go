@@ -0,0 +1,53 @@
+package htmlsanitizer_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSnapshot_Diff(t *testing.T) {
+	corpus := map[string]string{
+		"plain":  `<p>hello</p>`,
+		"script": `<p>a</p><script>x</script>`,
+		"big":    strings.Repeat("x", 200),
+	}
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxInputSize = 100
+	before := htmlsanitizer.TakeSnapshot(p, corpus)
+	if !strings.HasPrefix(before.Outputs["big"], "error: ") {
+		t.Errorf("big = %q, want an error", before.Outputs["big"])
+	}
+	if len(before.Outputs["plain"]) != 64 {
+		t.Errorf("plain = %q, want a SHA-256", before.Outputs["plain"])
+	}
+
+	// A snapshot survives a JSON round trip unchanged.
+	data, err := json.Marshal(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored htmlsanitizer.Snapshot
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if d := stored.Diff(htmlsanitizer.TakeSnapshot(p, corpus)); !d.Empty() || d.PolicyChanged || d.Versions != nil {
+		t.Errorf("identical snapshots differ: %+v", d)
+	}
+
+	corpus["new"] = `<b>new</b>`
+	delete(corpus, "plain")
+	corpus["script"] = `<p>b</p>`
+	after := htmlsanitizer.TakeSnapshot(htmlsanitizer.StrictPolicy(), corpus)
+	after.Versions = map[string]string{"golang.org/x/net": "v9.9.9"}
+	d := before.Diff(after)
+	if !slices.Equal(d.Changed, []string{"big", "script"}) || !slices.Equal(d.Added, []string{"new"}) || !slices.Equal(d.Removed, []string{"plain"}) {
+		t.Errorf("diff = %+v", d)
+	}
+	if !d.PolicyChanged || d.Versions["golang.org/x/net"][1] != "v9.9.9" {
+		t.Errorf("diff = %+v, want policy and x/net changes", d)
+	}
+}
@@ -0,0 +1,100 @@
+// Package ssg sanitizes the HTML that Markdown renderers and static
+// site generators produce, in the render path rather than as a separate
+// step. It has no dependencies on the renderers themselves: Wrap takes
+// any function that renders to an io.Writer, such as the Convert method
+// of a goldmark.Markdown, and PostProcessor any that returns the HTML,
+// such as blackfriday.Run:
+//
+//	md := goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))
+//	render := ssg.Wrap(func(src []byte, w io.Writer) error {
+//		return md.Convert(src, w)
+//	}, htmlsanitizer.DocsPolicy(), ssg.Options{})
+//
+//	post := ssg.PostProcessor(htmlsanitizer.DocsPolicy(), ssg.Options{})
+//	out, err := post(src, blackfriday.Run(src))
+//
+// Sanitizing is the expensive part of rendering trusted content. Set
+// Options.Trusted to pass documents through unchanged when their syntax
+// tree shows that the renderer alone produced their HTML; see Options.
+package ssg
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+// RenderFunc renders the Markdown source to w.
+type RenderFunc func(source []byte, w io.Writer) error
+
+// Options configure Wrap and PostProcessor.
+type Options struct {
+	// Trusted, if set, reports whether the rendered HTML of source may
+	// be written without sanitizing. It is meant for checks on the
+	// syntax tree that are cheaper than sanitizing, such as finding no
+	// raw HTML nodes in a goldmark document:
+	//
+	//	Trusted: func(src []byte) bool {
+	//		trusted := true
+	//		ast.Walk(md.Parser().Parse(text.NewReader(src)), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+	//			switch n.Kind() {
+	//			case ast.KindHTMLBlock, ast.KindRawHTML:
+	//				trusted = false
+	//				return ast.WalkStop, nil
+	//			case ast.KindLink, ast.KindAutoLink, ast.KindImage:
+	//				// check the destination's scheme as well
+	//			}
+	//			return ast.WalkContinue, nil
+	//		})
+	//		return trusted
+	//	},
+	//
+	// Renderers escape text but not link destinations, so a check that
+	// only looks for raw HTML lets javascript: links through.
+	Trusted func(source []byte) bool
+}
+
+// Wrap returns a RenderFunc that renders with render and writes the
+// result, sanitized with p, to w. Nothing is written if render or
+// sanitizing fails. If p is nil, the default policy is used.
+func Wrap(render RenderFunc, p *htmlsanitizer.Policy, opts Options) RenderFunc {
+	post := PostProcessor(p, opts)
+	return func(source []byte, w io.Writer) error {
+		buf := bufferPool.Get().(*bytes.Buffer)
+		defer func() {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}()
+		if err := render(source, buf); err != nil {
+			return err
+		}
+		out, err := post(source, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+}
+
+// PostProcessor returns a function that sanitizes rendered, the HTML
+// rendered from source, with p, for renderers that return their output.
+// It returns rendered itself if opts.Trusted trusts source. If p is nil,
+// the default policy is used.
+func PostProcessor(p *htmlsanitizer.Policy, opts Options) func(source, rendered []byte) ([]byte, error) {
+	s := htmlsanitizer.NewSanitizer(p)
+	return func(source, rendered []byte) ([]byte, error) {
+		if opts.Trusted != nil && opts.Trusted(source) {
+			return rendered, nil
+		}
+		out, err := s.Sanitize(string(rendered))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(out), nil
+	}
+}
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
@@ -0,0 +1,67 @@
+package ssg_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"github.com/njchilds90/htmlsanitizer/ssg"
+)
+
+// render stands in for a Markdown renderer that passes raw HTML through.
+func render(source []byte, w io.Writer) error {
+	if bytes.HasPrefix(source, []byte("!")) {
+		return errors.New("bad source")
+	}
+	_, err := io.WriteString(w, "<p>"+string(source)+"</p>")
+	return err
+}
+
+func TestWrap(t *testing.T) {
+	r := ssg.Wrap(render, htmlsanitizer.DefaultPolicy(), ssg.Options{})
+	var out strings.Builder
+	if err := r([]byte(`hi <script>x</script>`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), `<p>hi </p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	out.Reset()
+	if err := r([]byte("!"), &out); err == nil || out.Len() != 0 {
+		t.Errorf("failed render: err = %v, wrote %q", err, out.String())
+	}
+}
+
+func TestWrap_Trusted(t *testing.T) {
+	opts := ssg.Options{Trusted: func(src []byte) bool { return bytes.HasPrefix(src, []byte("trusted")) }}
+	r := ssg.Wrap(render, htmlsanitizer.DefaultPolicy(), opts)
+	for src, want := range map[string]string{
+		`trusted <iframe></iframe>`: `<p>trusted <iframe></iframe></p>`,
+		`raw <script>x</script>!`:   `<p>raw !</p>`,
+	} {
+		var out strings.Builder
+		if err := r([]byte(src), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("%q: got %q, want %q", src, out.String(), want)
+		}
+	}
+}
+
+func TestPostProcessor(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxInputSize = 30
+	post := ssg.PostProcessor(p, ssg.Options{})
+	out, err := post(nil, []byte(`<p onclick="x()">a</p>`))
+	if err != nil || string(out) != `<p>a</p>` {
+		t.Errorf("got %q, %v", out, err)
+	}
+	if _, err := post(nil, []byte(strings.Repeat("x", 64))); !errors.Is(err, htmlsanitizer.ErrInputTooLarge) {
+		t.Errorf("err = %v, want ErrInputTooLarge", err)
+	}
+}
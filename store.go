@@ -0,0 +1,145 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnknownPolicy is returned by PolicyStore.Sanitize for a name the
+// store does not hold.
+var ErrUnknownPolicy = errors.New("htmlsanitizer: unknown policy")
+
+// PolicyProvider supplies the named policies of a PolicyStore.
+type PolicyProvider interface {
+	// LoadPolicies returns every policy by name. It is called once per
+	// reload and may be called from the goroutine running Watch.
+	LoadPolicies(ctx context.Context) (map[string]*Policy, error)
+}
+
+// PolicyProviderFunc adapts a function to PolicyProvider.
+type PolicyProviderFunc func(ctx context.Context) (map[string]*Policy, error)
+
+// LoadPolicies calls f.
+func (f PolicyProviderFunc) LoadPolicies(ctx context.Context) (map[string]*Policy, error) {
+	return f(ctx)
+}
+
+// FSProvider returns a PolicyProvider that reads one PolicyConfig per
+// *.json file at the root of fsys, such as an os.DirFS of a
+// configuration directory. A policy is named after its file, without
+// the extension.
+func FSProvider(fsys fs.FS) PolicyProvider {
+	return PolicyProviderFunc(func(ctx context.Context) (map[string]*Policy, error) {
+		files, err := fs.Glob(fsys, "*.json")
+		if err != nil {
+			return nil, err
+		}
+		policies := make(map[string]*Policy, len(files))
+		for _, file := range files {
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			p, err := ParsePolicy(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			policies[strings.TrimSuffix(file, path.Ext(file))] = p
+		}
+		return policies, nil
+	})
+}
+
+// PolicyStore holds compiled Sanitizers for named policies, such as one
+// per tenant, and replaces them all at once when the provider's
+// policies change. Calls in flight keep the Sanitizer they started
+// with. A PolicyStore is safe for concurrent use.
+type PolicyStore struct {
+	provider PolicyProvider
+	current  atomic.Pointer[map[string]*Sanitizer]
+}
+
+// NewPolicyStore returns a store loaded from provider. It fails if the
+// first load does.
+func NewPolicyStore(ctx context.Context, provider PolicyProvider) (*PolicyStore, error) {
+	s := &PolicyStore{provider: provider}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload loads, validates, and compiles the provider's policies and
+// swaps them in. Names are case-insensitive. If loading fails or any
+// policy is invalid, the store keeps the policies it had and the error
+// is returned.
+func (s *PolicyStore) Reload(ctx context.Context) error {
+	policies, err := s.provider.LoadPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	next := make(map[string]*Sanitizer, len(policies))
+	for name, p := range policies {
+		if p == nil {
+			return fmt.Errorf("htmlsanitizer: policy %q is nil", name)
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("policy %q: %w", name, err)
+		}
+		next[strings.ToLower(strings.TrimSpace(name))] = NewSanitizer(p)
+	}
+	s.current.Store(&next)
+	return nil
+}
+
+// Watch reloads the store every interval until ctx is done. Failed
+// reloads leave the store as it was and are passed to onError, if it
+// is non-nil. Watch blocks; run it on its own goroutine.
+func (s *PolicyStore) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.Reload(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Sanitizer returns the compiled Sanitizer for the named policy.
+func (s *PolicyStore) Sanitizer(name string) (*Sanitizer, bool) {
+	san, ok := (*s.current.Load())[strings.ToLower(strings.TrimSpace(name))]
+	return san, ok
+}
+
+// Sanitize sanitizes htmlStr with the named policy, returning
+// ErrUnknownPolicy if the store has none by that name.
+func (s *PolicyStore) Sanitize(ctx context.Context, name, htmlStr string) (string, error) {
+	san, ok := s.Sanitizer(name)
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownPolicy, name)
+	}
+	return san.SanitizeContext(ctx, htmlStr)
+}
+
+// Names returns the names of the policies in the store, sorted.
+func (s *PolicyStore) Names() []string {
+	current := *s.current.Load()
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package htmlsanitizer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestPolicyStore(t *testing.T) {
+	fsys := fstest.MapFS{
+		"acme.json":   {Data: []byte(`{"allowed_tags": ["b"], "strip_disallowed": true}`)},
+		"globex.json": {Data: []byte(`{"base": "strict"}`)},
+		"notes.txt":   {Data: []byte(`ignored`)},
+	}
+	ctx := context.Background()
+	store, err := htmlsanitizer.NewPolicyStore(ctx, htmlsanitizer.FSProvider(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(store.Names(), ","); got != "acme,globex" {
+		t.Errorf("Names = %s", got)
+	}
+	acme, _ := store.Sanitizer("ACME")
+	if got, _ := store.Sanitize(ctx, "acme", `<b>x</b><i>y</i>`); got != `<b>x</b>` {
+		t.Errorf("acme: %q", got)
+	}
+	if _, err := store.Sanitize(ctx, "initech", `x`); !errors.Is(err, htmlsanitizer.ErrUnknownPolicy) {
+		t.Errorf("unknown: err = %v", err)
+	}
+
+	// A broken file fails the whole reload and keeps what was loaded.
+	fsys["acme.json"] = &fstest.MapFile{Data: []byte(`{"allowed_tags": ["b", "script"]}`)}
+	if err := store.Reload(ctx); err == nil || !strings.Contains(err.Error(), "acme.json") {
+		t.Errorf("Reload err = %v", err)
+	}
+	if got, _ := store.Sanitize(ctx, "acme", `<b>x</b><i>y</i>`); got != `<b>x</b>` {
+		t.Errorf("after failed reload: %q", got)
+	}
+
+	fsys["acme.json"] = &fstest.MapFile{Data: []byte(`{"allowed_tags": ["i"], "strip_disallowed": true}`)}
+	if err := store.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Sanitize(ctx, "acme", `<b>x</b><i>y</i>`); got != `<i>y</i>` {
+		t.Errorf("after reload: %q", got)
+	}
+	if got, _ := acme.Sanitize(`<b>x</b><i>y</i>`); got != `<b>x</b>` {
+		t.Errorf("old Sanitizer changed: %q", got)
+	}
+}
+
+func TestPolicyStore_Watch(t *testing.T) {
+	var loads atomic.Int32
+	provider := htmlsanitizer.PolicyProviderFunc(func(ctx context.Context) (map[string]*htmlsanitizer.Policy, error) {
+		if loads.Add(1) > 1 {
+			return nil, errors.New("unavailable")
+		}
+		return map[string]*htmlsanitizer.Policy{"default": htmlsanitizer.DefaultPolicy()}, nil
+	})
+	store, err := htmlsanitizer.NewPolicyStore(context.Background(), provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		store.Watch(ctx, time.Millisecond, func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+		close(done)
+	}()
+	if err := <-errs; err == nil || err.Error() != "unavailable" {
+		t.Errorf("onError got %v", err)
+	}
+	cancel()
+	<-done
+	if _, ok := store.Sanitizer("default"); !ok {
+		t.Error("failed reload dropped the policies")
+	}
+}
@@ -0,0 +1,212 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// IncrementalSanitizer sanitizes HTML that arrives in chunks, such as
+// server-sent event streams or chunked proxy responses. Sanitized output
+// is written to the underlying writer as soon as the tokens that produce
+// it are complete; only an unterminated trailing token is buffered
+// between calls to Write.
+//
+// An IncrementalSanitizer is not safe for concurrent use.
+type IncrementalSanitizer struct {
+	w       io.Writer
+	s       *TokenSanitizer
+	pending []byte
+	out     bytes.Buffer
+	err     error
+}
+
+// NewIncrementalSanitizer returns an IncrementalSanitizer that applies
+// p and writes sanitized HTML to w. If p is nil, the default policy is used.
+func NewIncrementalSanitizer(w io.Writer, p *Policy) *IncrementalSanitizer {
+	return &IncrementalSanitizer{w: w, s: NewTokenSanitizer(p)}
+}
+
+// Write buffers b and writes out the sanitized form of every token that
+// is now complete. It always reports len(b) bytes consumed unless the
+// underlying writer fails.
+func (is *IncrementalSanitizer) Write(b []byte) (int, error) {
+	if is.err != nil {
+		return 0, is.err
+	}
+	is.pending = append(is.pending, b...)
+	if err := is.process(false); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Flush writes out any buffered trailing text without waiting for the
+// next chunk. Incomplete tags, comments, character references, and
+// raw-text elements such as script stay buffered, as does a trailing
+// "<" or "</" that may start a tag.
+func (is *IncrementalSanitizer) Flush() error {
+	if is.err != nil {
+		return is.err
+	}
+	return is.process(true)
+}
+
+// Close sanitizes everything still buffered, closes any open elements,
+// and writes the remaining output. It does not close the underlying
+// writer.
+func (is *IncrementalSanitizer) Close() error {
+	if is.err != nil {
+		return is.err
+	}
+	if err := is.process(true); err != nil {
+		return err
+	}
+	// Whatever is left can no longer be completed; sanitize it as is.
+	z := html.NewTokenizer(bytes.NewReader(is.pending))
+	is.pending = nil
+	for tt := z.Next(); tt != html.ErrorToken; tt = z.Next() {
+		if err := is.s.Token(is.s.names.token(z, tt), is.emit); err != nil {
+			return is.fail(err)
+		}
+	}
+	if err := is.s.Close(is.emit); err != nil {
+		return is.fail(err)
+	}
+	return is.writeOut()
+}
+
+// process tokenizes the pending bytes and sanitizes each token up to
+// the last point at which the stream is known to be between complete
+// tokens. With flush set, trailing text counts as complete, except for
+// a final "<" or "</" that may start a tag in the next chunk, or a
+// character reference that may continue in it.
+func (is *IncrementalSanitizer) process(flush bool) error {
+	type span struct {
+		tok html.Token
+		end int
+	}
+	var (
+		toks     []span
+		offset   int
+		commit   int
+		rawStart = -1
+	)
+	end := len(is.pending)
+	if flush {
+		end -= incompleteSuffix(is.pending)
+	}
+	z := html.NewTokenizer(bytes.NewReader(is.pending[:end]))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		start := offset
+		offset += len(z.Raw())
+		tok := is.s.names.token(z, tt)
+		toks = append(toks, span{tok: tok, end: offset})
+
+		switch {
+		case rawStart >= 0:
+			// Inside script, style, and friends nothing is final until
+			// the matching end tag has been seen.
+			if tt == html.EndTagToken {
+				rawStart = -1
+				commit = offset
+			}
+		case tt == html.StartTagToken && isRawTextTag(strings.ToLower(tok.Data)):
+			rawStart = start
+		case offset < end:
+			commit = offset
+		case tt == html.TextToken && !flush:
+			// Trailing text may continue in the next chunk.
+		case tt == html.CommentToken && !commentTerminated(is.pending[start:]):
+			// Unterminated comment.
+		default:
+			commit = offset
+		}
+	}
+
+	for _, sp := range toks {
+		if sp.end > commit {
+			break
+		}
+		if err := is.s.Token(sp.tok, is.emit); err != nil {
+			return is.fail(err)
+		}
+	}
+	is.pending = append(is.pending[:0], is.pending[commit:]...)
+	return is.writeOut()
+}
+
+func (is *IncrementalSanitizer) emit(tok html.Token) error {
+	writeToken(&is.out, tok)
+	return nil
+}
+
+func (is *IncrementalSanitizer) writeOut() error {
+	if is.out.Len() == 0 {
+		return nil
+	}
+	_, err := is.w.Write(is.out.Bytes())
+	is.out.Reset()
+	if err != nil {
+		return is.fail(err)
+	}
+	return nil
+}
+
+func (is *IncrementalSanitizer) fail(err error) error {
+	is.err = err
+	return err
+}
+
+// maxReferenceLen bounds the length of a character reference, from
+// "&" to the last character before ";", that Flush holds back.
+const maxReferenceLen = 32
+
+// incompleteSuffix returns the length of the end of b that the
+// tokenizer reads as text but that the next chunk may turn into
+// something else: a trailing "<" or "</", which may begin a tag, or a
+// character reference without its ";".
+func incompleteSuffix(b []byte) int {
+	switch {
+	case bytes.HasSuffix(b, []byte("</")):
+		return 2
+	case bytes.HasSuffix(b, []byte("<")):
+		return 1
+	}
+	for i := len(b) - 1; i >= 0 && len(b)-i <= maxReferenceLen; i-- {
+		switch c := b[i]; {
+		case c == '&':
+			return len(b) - i
+		case c == '#' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9':
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// commentTerminated reports whether raw, a comment running to the end
+// of the buffered input, has seen its closing delimiter.
+func commentTerminated(raw []byte) bool {
+	if bytes.HasPrefix(raw, []byte("<!--")) {
+		return bytes.HasSuffix(raw, []byte("-->")) || len(raw) <= len("<!--->") && bytes.HasSuffix(raw, []byte(">"))
+	}
+	return bytes.HasSuffix(raw, []byte(">"))
+}
+
+// isRawTextTag reports whether the tokenizer treats the content of tag
+// as raw text, so that it must be seen in full before it is tokenized.
+func isRawTextTag(tag string) bool {
+	switch tag {
+	case "iframe", "noembed", "noframes", "noscript", "plaintext",
+		"script", "style", "textarea", "title", "xmp":
+		return true
+	}
+	return false
+}
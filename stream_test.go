@@ -0,0 +1,99 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestIncrementalSanitizer_SplitChunks(t *testing.T) {
+	input := `<p>Hello <b class="x">wor` + `ld</b> &amp; <script>alert(1)</script><a href="javascript:x()">a</a></p>`
+	want, err := htmlsanitizer.Sanitize(input, htmlsanitizer.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Feed every possible split position, one byte at a time.
+	var sb strings.Builder
+	is := htmlsanitizer.NewIncrementalSanitizer(&sb, htmlsanitizer.DefaultPolicy())
+	for i := 0; i < len(input); i++ {
+		if _, err := is.Write([]byte{input[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := is.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestIncrementalSanitizer_FlushEverywhere(t *testing.T) {
+	input := `<p>Hi <b class="x">there</b> &amp; a < b <!-- c --></p><script>x</script><a href="/x" />done`
+	want, err := htmlsanitizer.Sanitize(input, htmlsanitizer.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= len(input); i++ {
+		var sb strings.Builder
+		is := htmlsanitizer.NewIncrementalSanitizer(&sb, htmlsanitizer.DefaultPolicy())
+		if _, err := is.Write([]byte(input[:i])); err != nil {
+			t.Fatal(err)
+		}
+		if err := is.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := is.Write([]byte(input[i:])); err != nil {
+			t.Fatal(err)
+		}
+		if err := is.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != want {
+			t.Errorf("flushed after %q: got %q, want %q", input[:i], got, want)
+		}
+	}
+}
+
+func TestIncrementalSanitizer_EmitsEarly(t *testing.T) {
+	var sb strings.Builder
+	is := htmlsanitizer.NewIncrementalSanitizer(&sb, htmlsanitizer.DefaultPolicy())
+	if _, err := is.Write([]byte(`<b>first</b><i>sec`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != `<b>first</b><i>` {
+		t.Errorf("after Write got %q", got)
+	}
+	if err := is.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != `<b>first</b><i>sec` {
+		t.Errorf("after Flush got %q", got)
+	}
+	if _, err := is.Write([]byte(`ond</i><scr`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := is.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != `<b>first</b><i>second</i>` {
+		t.Errorf("after Close got %q", got)
+	}
+}
+
+func TestIncrementalSanitizer_CommentAcrossChunks(t *testing.T) {
+	var sb strings.Builder
+	is := htmlsanitizer.NewIncrementalSanitizer(&sb, htmlsanitizer.DefaultPolicy())
+	for _, chunk := range []string{`<b>a</b><!-- x > `, `still comment --><i>b</i>`} {
+		if _, err := is.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := is.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != `<b>a</b><i>b</i>` {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,117 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// ParseError reports input that Policy.StrictParse rejects because the
+// parser would have to repair it.
+type ParseError struct {
+	// Line and Column locate the offending token, counting from 1.
+	// Column counts characters, not bytes.
+	Line, Column int
+
+	// Msg describes the problem.
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("htmlsanitizer: line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// checkWellFormed reports the first place where data relies on the
+// parser's error recovery: an end tag that does not close the current
+// element, a self-closing non-void HTML element, a repeated attribute,
+// or an element left open at the end of the input. Every non-void element must be closed
+// explicitly, including those whose end tag HTML lets authors omit.
+// isVoid reports which elements are void.
+func checkWellFormed(data []byte, isVoid func(tag string) bool) error {
+	z := html.NewTokenizer(bytes.NewReader(data))
+	var stack []string
+	foreign := 0 // number of open svg and math elements
+	offset := 0
+	fail := func(format string, args ...any) error {
+		line, col := position(data, offset)
+		return &ParseError{Line: line, Column: col, Msg: fmt.Sprintf(format, args...)}
+	}
+	seen := make(map[string]bool)
+	// duplicate returns the first attribute of the current tag that
+	// appears twice, if any.
+	duplicate := func(hasAttr bool) string {
+		clear(seen)
+		for hasAttr {
+			var key []byte
+			key, _, hasAttr = z.TagAttr()
+			if seen[string(key)] {
+				return string(key)
+			}
+			seen[string(key)] = true
+		}
+		return ""
+	}
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return err
+			}
+			if len(stack) > 0 {
+				return fail("<%s> is not closed", stack[len(stack)-1])
+			}
+			return nil
+
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if key := duplicate(hasAttr); key != "" {
+				return fail("duplicate attribute %s", key)
+			}
+			if !isVoid(tag) {
+				stack = append(stack, tag)
+				if tag == "svg" || tag == "math" {
+					foreign++
+				}
+			}
+
+		case html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if tag := string(name); foreign == 0 && !isVoid(tag) {
+				return fail("<%s/> is not a void element", tag)
+			}
+			if key := duplicate(hasAttr); key != "" {
+				return fail("duplicate attribute %s", key)
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case len(stack) == 0:
+				return fail("unexpected </%s>", tag)
+			case stack[len(stack)-1] != tag:
+				return fail("unexpected </%s>, expecting </%s>", tag, stack[len(stack)-1])
+			}
+			stack = stack[:len(stack)-1]
+			if tag == "svg" || tag == "math" {
+				foreign--
+			}
+		}
+		offset += len(z.Raw())
+	}
+}
+
+// position converts a byte offset in data to a line and column.
+func position(data []byte, offset int) (line, col int) {
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	if i := bytes.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	return line, utf8.RuneCount(before) + 1
+}
@@ -0,0 +1,47 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_StrictParse(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.StrictParse = true
+	tests := []struct {
+		in        string
+		line, col int
+		msg       string
+	}{
+		{"<p>ok</p>\n<p><b>x</i></b></p>", 2, 8, "unexpected </i>, expecting </b>"},
+		{"<p>never closed", 1, 16, "<p> is not closed"},
+		{"<div/>", 1, 1, "<div/> is not a void element"},
+		{"</p>", 1, 1, "unexpected </p>"},
+		{"<ul>\n  <li>é<li>b</ul>", 2, 13, "unexpected </ul>, expecting </li>"},
+		{"<p>x <b a=1 A=2>y</b></p>", 1, 6, "duplicate attribute a"},
+		{`<img src="/a.png" src="/b.png">`, 1, 1, "duplicate attribute src"},
+	}
+	for _, tt := range tests {
+		_, err := htmlsanitizer.Sanitize(tt.in, p)
+		var pe *htmlsanitizer.ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%q: err = %v, want *ParseError", tt.in, err)
+			continue
+		}
+		if pe.Line != tt.line || pe.Column != tt.col || pe.Msg != tt.msg {
+			t.Errorf("%q: got %d:%d %q, want %d:%d %q", tt.in, pe.Line, pe.Column, pe.Msg, tt.line, tt.col, tt.msg)
+		}
+	}
+
+	for _, in := range []string{
+		`<p>a <br> b <img src="/x.png"/></p>`,
+		`<svg><circle r="1"/></svg>`,
+		`<script>if (a < b) {}</script><p>x</p>`,
+	} {
+		if _, err := htmlsanitizer.Sanitize(in, p); err != nil {
+			t.Errorf("%q: unexpected error %v", in, err)
+		}
+	}
+}
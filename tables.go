@@ -0,0 +1,133 @@
+package htmlsanitizer
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxColspan caps the colspan counted for a single cell, as browsers
+// do.
+const maxColspan = 1000
+
+// limitTables applies Policy.MaxTableColumns, MaxTableCells, and
+// TableContainer to the tables below root, recording truncated tables
+// in rep if it is non-nil.
+func (c *compiledPolicy) limitTables(root *html.Node, rep *Report) {
+	var tables []*html.Node
+	walkElements(root, func(n *html.Node) {
+		if n.Data == "table" && n.Namespace == "" {
+			tables = append(tables, n)
+		}
+	})
+	for _, t := range tables {
+		if removed := c.limitTable(t); removed > 0 {
+			rep.addIssue(&Issue{Err: ErrTableTooLarge, Element: "table"})
+			if rep != nil {
+				rep.RemovedElements += removed
+			}
+		}
+		if c.p.TableContainer != "" {
+			c.wrapTable(t)
+		}
+	}
+}
+
+// limitTable removes the cells of t beyond MaxTableColumns in each row,
+// narrowing a colspan that crosses the limit, and the rows from the one
+// in which the cells reach MaxTableCells on. It returns the number of
+// cells and rows removed.
+func (c *compiledPolicy) limitTable(t *html.Node) int {
+	maxCols, maxCells := c.p.MaxTableColumns, c.p.MaxTableCells
+	if maxCols <= 0 && maxCells <= 0 {
+		return 0
+	}
+	removed, cells := 0, 0
+	for _, row := range tableRows(t) {
+		if maxCells > 0 && cells >= maxCells {
+			row.Parent.RemoveChild(row)
+			removed++
+			continue
+		}
+		cols := 0
+		for cell := row.FirstChild; cell != nil; {
+			next := cell.NextSibling
+			if cell.Type != html.ElementNode || cell.Data != "td" && cell.Data != "th" {
+				cell = next
+				continue
+			}
+			span := colspan(cell)
+			switch {
+			case maxCols > 0 && cols >= maxCols, maxCells > 0 && cells >= maxCells:
+				row.RemoveChild(cell)
+				removed++
+			case maxCols > 0 && cols+span > maxCols:
+				SetAttr(cell, "colspan", strconv.Itoa(maxCols-cols))
+				span = maxCols - cols
+				fallthrough
+			default:
+				cols += span
+				cells++
+			}
+			cell = next
+		}
+	}
+	return removed
+}
+
+// tableRows returns the rows of t, directly or in its row groups, in
+// order.
+func tableRows(t *html.Node) []*html.Node {
+	var rows []*html.Node
+	for n := t.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.Data {
+		case "tr":
+			rows = append(rows, n)
+		case "thead", "tbody", "tfoot":
+			for r := n.FirstChild; r != nil; r = r.NextSibling {
+				if r.Type == html.ElementNode && r.Data == "tr" {
+					rows = append(rows, r)
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// colspan returns the number of columns cell spans.
+func colspan(cell *html.Node) int {
+	n, err := strconv.Atoi(strings.TrimSpace(GetAttr(cell, "colspan")))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, maxColspan)
+}
+
+// wrapTable puts t in a TableContainer element with the
+// TableContainerClass, unless it is alone in one already, as it is when
+// sanitized output is sanitized again.
+func (c *compiledPolicy) wrapTable(t *html.Node) {
+	tag := strings.ToLower(c.p.TableContainer)
+	class := ""
+	if c.p.TableContainerClass != "" {
+		class = withPrefix(c.p.Namespace, c.p.TableContainerClass)
+	}
+	if parent := t.Parent; parent.Type == html.ElementNode && parent.Data == tag &&
+		firstElementChild(parent) == t && lastElementChild(parent) == t &&
+		(class == "" || slices.Contains(strings.Fields(GetAttr(parent, "class")), class)) {
+		return
+	}
+	wrapper := &html.Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag))}
+	if class != "" {
+		wrapper.Attr = []html.Attribute{{Key: "class", Val: class}}
+	}
+	t.Parent.InsertBefore(wrapper, t)
+	t.Parent.RemoveChild(t)
+	wrapper.AppendChild(t)
+}
@@ -0,0 +1,74 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitize_TableLimits(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxTableColumns = 3
+	p.MaxTableCells = 5
+	in := `<table><tr><td>a</td><td colspan="3">b</td><td>c</td></tr>` +
+		`<tr><td>d</td><td>e</td><td>f</td></tr><tr><td>g</td></tr></table>`
+	want := `<table><tbody><tr><td>a</td><td colspan="2">b</td></tr>` +
+		`<tr><td>d</td><td>e</td><td>f</td></tr></tbody></table>`
+	got, rep, err := htmlsanitizer.SanitizeWithReport(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if len(rep.Issues) != 1 || !errors.Is(rep.Issues[0], htmlsanitizer.ErrTableTooLarge) {
+		t.Errorf("issues = %v, want one ErrTableTooLarge", rep.Issues)
+	}
+
+	small := `<table><tbody><tr><td>a</td></tr></tbody></table>`
+	if got, _ := htmlsanitizer.Sanitize(small, p); got != small {
+		t.Errorf("small table changed: %q", got)
+	}
+}
+
+func TestSanitize_TableContainer(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.TableContainer = "div"
+	p.TableContainerClass = "table-scroll"
+	in := `<div><table><tr><td>a</td></tr></table></div>`
+	want := `<div><div class="table-scroll"><table><tbody><tr><td>a</td></tr></tbody></table></div></div>`
+	got, err := htmlsanitizer.Sanitize(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	// Sanitizing again does not wrap again.
+	if again, _ := htmlsanitizer.Sanitize(got, p); again != got {
+		t.Errorf("second pass: %q", again)
+	}
+
+	p = p.Clone()
+	p.TableContainer = "img"
+	if err := p.Validate(); err == nil {
+		t.Error("Validate accepted a void TableContainer")
+	}
+}
+
+func TestSanitize_TableColumns(t *testing.T) {
+	in := `<table><caption>Totals</caption><colgroup span="2"></colgroup>` +
+		`<colgroup><col span=" 03 "><col span="0"><col span="1001"><col span="x"></colgroup>` +
+		`<tr><td>1</td></tr></table>`
+	want := `<table><caption>Totals</caption><colgroup span="2"></colgroup>` +
+		`<colgroup><col span="3" /><col /><col /><col /></colgroup>` +
+		`<tbody><tr><td>1</td></tr></tbody></table>`
+	got, err := htmlsanitizer.Sanitize(in, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
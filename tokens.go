@@ -0,0 +1,525 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"io"
+	"slices"
+
+	"golang.org/x/net/html"
+)
+
+// TokenSanitizer applies a Policy to a stream of html.Token values
+// without building a node tree. It tracks open elements so that each
+// end tag receives the same treatment as its start tag, and closes
+// paragraphs and list items where the parser would imply their end
+// tags. A TokenSanitizer is not safe for concurrent use.
+type TokenSanitizer struct {
+	c     *compiledPolicy
+	stack []openElement
+	drops int // number of dropped elements on the stack
+	code  int // number of elements shown as code on the stack
+	names names
+
+	replaying int // > 0 while replaying tokens inserted by transformers
+}
+
+type tokenAction int
+
+const (
+	actionKeep tokenAction = iota
+	actionEscape
+	actionUnwrap
+	actionDrop
+	actionCode
+)
+
+type openElement struct {
+	tag    string
+	action tokenAction
+	after  []html.Token // inserted by transformers; replayed after the end tag
+}
+
+// NewTokenSanitizer returns a TokenSanitizer for p. If p is nil,
+// the default policy is used.
+func NewTokenSanitizer(p *Policy) *TokenSanitizer {
+	if p == nil {
+		p = nilPolicy()
+	}
+	return &TokenSanitizer{c: compilePolicy(p)}
+}
+
+// Token sanitizes tok and passes the resulting tokens, if any, to emit.
+// A single input token may produce zero, one, or several output tokens.
+func (s *TokenSanitizer) Token(tok html.Token, emit func(html.Token) error) error {
+	switch tok.Type {
+	case html.TextToken:
+		if s.drops > 0 {
+			return nil
+		}
+		for _, t := range s.c.p.TextTransformers {
+			tok.Data = t(tok.Data)
+		}
+		if s.c.linksText() && !s.linkExcluded() {
+			return s.c.emitLinkedText(tok.Data, emit)
+		}
+		return emit(tok)
+
+	case html.StartTagToken, html.SelfClosingTagToken:
+		return s.startTag(tok, emit)
+
+	case html.EndTagToken:
+		return s.endTag(s.names.lower(tok.Data), emit)
+	}
+	// Comments and doctypes are always removed.
+	return nil
+}
+
+// Close emits end tags for any elements that are still open, leaving
+// the output balanced. The TokenSanitizer may be reused afterwards.
+func (s *TokenSanitizer) Close(emit func(html.Token) error) error {
+	for len(s.stack) > 0 {
+		if err := s.pop(emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TokenSanitizer) startTag(tok html.Token, emit func(html.Token) error) error {
+	tag := s.names.lower(tok.Data)
+	if s.c.p.ModernizeTags && legacyTags[tag] != "" {
+		n := &html.Node{Type: html.ElementNode, Data: tag, Attr: slices.Clone(tok.Attr)}
+		tag = s.c.modernizeTag(n, tag)
+		tok.Data, tok.Attr = tag, n.Attr
+	}
+	// Nodes inserted by transformers were placed in the tree as they
+	// are, so only input tokens close elements implicitly.
+	if s.replaying == 0 {
+		if err := s.closeImplied(tag, emit); err != nil {
+			return err
+		}
+	}
+	// Browsers ignore the self-closing flag on HTML elements that are
+	// not void, so such an element is opened and must be closed.
+	void := s.c.isVoid(tag) || tok.Type == html.SelfClosingTagToken && s.foreign(tag)
+	if !void {
+		tok.Type = html.StartTagToken
+	}
+
+	if s.drops > 0 {
+		if !void {
+			s.push(tag, actionDrop)
+		}
+		return nil
+	}
+
+	if s.code > 0 {
+		// Only the text of an element shown as code is kept.
+		if !void {
+			s.push(tag, actionUnwrap)
+		}
+		return nil
+	}
+
+	p := s.c.p
+	action, raw := s.c.rawText[tag]
+	if raw || !s.c.tagAllowed(tag, len(s.stack)+1) || !s.c.contextAllowed(tag, s.keptTags()) {
+		if !raw {
+			action = s.c.disallowedAction(tag)
+		}
+		switch action {
+		case DisallowedStrip:
+			if !void {
+				s.push(tag, actionDrop)
+			}
+			return nil
+		case DisallowedUnwrap:
+			if !void {
+				s.push(tag, actionUnwrap)
+			}
+			return nil
+		case DisallowedCode:
+			pre, code := codeBlock(tag, tok.Attr)
+			for _, n := range []*html.Node{pre, code} {
+				if err := emit(html.Token{Type: html.StartTagToken, Data: n.Data, Attr: n.Attr}); err != nil {
+					return err
+				}
+			}
+			if void {
+				return s.closeCode(emit)
+			}
+			s.push(tag, actionCode)
+			return nil
+		}
+		n := &html.Node{Type: html.ElementNode, Data: tok.Data, Attr: tok.Attr}
+		if err := emit(html.Token{Type: html.TextToken, Data: s.c.escapedTag(n, false)}); err != nil {
+			return err
+		}
+		if !void {
+			s.push(tag, actionEscape)
+		}
+		return nil
+	}
+
+	// The element gets a parent of its own, so that transformers can
+	// insert nodes around it.
+	box := &html.Node{Type: html.DocumentNode}
+	n := &html.Node{Type: html.ElementNode, Data: tag, Attr: append([]html.Attribute(nil), tok.Attr...)}
+	box.AppendChild(n)
+	if n = transform(n, p.PreFilterTransformers); n != nil {
+		if p.ConvertLegacyAttributes {
+			s.c.convertLegacyAttrs(n, tag)
+		}
+		n.Attr = s.c.filterAttrs(tag, n.Attr, nil)
+		n.Attr = s.c.transformAttrs(tag, n.Attr)
+		n.Attr = s.c.normalizeAttrs(n.Attr)
+		n.Attr = s.c.capAttrs(n.Attr)
+		n.Attr = s.c.applyDefaults(tag, n.Attr)
+		n.Attr = s.c.clampImage(tag, n.Attr)
+		n.Attr = s.c.namespaceAttrs(n.Attr)
+		n = transform(n, p.Transformers)
+	}
+	if n != nil && box.FirstChild == n && n.NextSibling == nil {
+		return s.keep(n, tok.Type, tag, void, emit)
+	}
+	return s.startInserted(box, n, tok.Type, tag, void, emit)
+}
+
+// foreign reports whether tag, opened inside the open elements, is an
+// SVG or MathML element, on which the self-closing flag is honoured.
+// Elements that break out of foreign content, and those inside an HTML
+// integration point, are HTML elements.
+func (s *TokenSanitizer) foreign(tag string) bool {
+	if tag == "svg" || tag == "math" {
+		return true
+	}
+	if foreignBreakout[tag] {
+		return false
+	}
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		switch s.stack[i].tag {
+		case "svg", "math":
+			return true
+		case "foreignobject", "desc", "title", "mi", "mo", "mn", "ms", "mtext", "annotation-xml":
+			return false
+		}
+	}
+	return false
+}
+
+// foreignBreakout holds the start tags that end foreign content in the
+// HTML parser.
+var foreignBreakout = map[string]bool{
+	"b": true, "big": true, "blockquote": true, "body": true, "br": true, "center": true,
+	"code": true, "dd": true, "div": true, "dl": true, "dt": true, "em": true, "embed": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "head": true,
+	"hr": true, "i": true, "img": true, "li": true, "listing": true, "menu": true, "meta": true,
+	"nobr": true, "ol": true, "p": true, "pre": true, "ruby": true, "s": true, "small": true,
+	"span": true, "strong": true, "strike": true, "sub": true, "sup": true, "table": true,
+	"tt": true, "u": true, "ul": true, "var": true, "font": true,
+}
+
+// keep emits the start tag of n, an element that passed the policy.
+func (s *TokenSanitizer) keep(n *html.Node, typ html.TokenType, tag string, void bool, emit func(html.Token) error) error {
+	s.c.orderAttrs(n)
+	if void && !isVoidElement(tag) {
+		// A void element from the policy is written self-closed, as
+		// the tree-based entry points write it.
+		typ = html.SelfClosingTagToken
+	}
+	if err := emit(html.Token{Type: typ, Data: tag, Attr: n.Attr}); err != nil {
+		return err
+	}
+	if !void {
+		s.push(tag, actionKeep)
+	}
+	return nil
+}
+
+// startInserted handles an element around which transformers inserted
+// nodes, or which they removed, leaving what they inserted in box.
+// The inserted nodes are sanitized as tokens: those before n now, and
+// those after n, including the end tags of any wrappers, once n has
+// been closed.
+func (s *TokenSanitizer) startInserted(box, n *html.Node, typ html.TokenType, tag string, void bool, emit func(html.Token) error) error {
+	before, after := splitTokens(box, n)
+	if err := s.replay(before, emit); err != nil {
+		return err
+	}
+	switch {
+	case n == nil || s.drops > 0:
+		if !void {
+			s.push(tag, actionDrop)
+		}
+	case s.code > 0:
+		if !void {
+			s.push(tag, actionUnwrap)
+		}
+	default:
+		if err := s.keep(n, typ, tag, void, emit); err != nil {
+			return err
+		}
+	}
+	if void {
+		return s.replay(after, emit)
+	}
+	s.stack[len(s.stack)-1].after = after
+	return nil
+}
+
+// replay sanitizes toks in order.
+func (s *TokenSanitizer) replay(toks []html.Token, emit func(html.Token) error) error {
+	s.replaying++
+	defer func() { s.replaying-- }()
+	for _, tok := range toks {
+		if err := s.Token(tok, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTokens serializes the children of box as tokens, split around n:
+// before holds the tokens up to the start of n, after those that follow
+// its end. n itself, and its children, are left out.
+func splitTokens(box, n *html.Node) (before, after []html.Token) {
+	out := &before
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		switch {
+		case c == n:
+			out = &after
+			return
+		case c.Type == html.TextNode:
+			*out = append(*out, html.Token{Type: html.TextToken, Data: c.Data})
+			return
+		case c.Type == html.ElementNode:
+			*out = append(*out, html.Token{Type: html.StartTagToken, Data: c.Data, Attr: c.Attr})
+		}
+		for ch := c.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch)
+		}
+		if c.Type == html.ElementNode && !isVoidElement(c.Data) {
+			*out = append(*out, html.Token{Type: html.EndTagToken, Data: c.Data})
+		}
+	}
+	for c := box.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	return before, after
+}
+
+func (s *TokenSanitizer) endTag(tag string, emit func(html.Token) error) error {
+	if s.c.p.ModernizeTags && legacyTags[tag] != "" {
+		tag = legacyTags[tag]
+	}
+	// Find the nearest matching open element; stray end tags are ignored.
+	i := len(s.stack) - 1
+	for i >= 0 && s.stack[i].tag != tag {
+		i--
+	}
+	if i < 0 {
+		return nil
+	}
+	for len(s.stack) > i {
+		if err := s.pop(emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeImplied closes the elements that the start tag tag ends
+// implicitly in the HTML parser: an open paragraph before a block, and
+// an open list item or description before the next one, so that
+// <p>a<p>b does not nest the paragraphs.
+func (s *TokenSanitizer) closeImplied(tag string, emit func(html.Token) error) error {
+	switch tag {
+	case "li":
+		if err := s.closeOpen(emit, listItemBoundaries, "li"); err != nil {
+			return err
+		}
+	case "dd", "dt":
+		if err := s.closeOpen(emit, listItemBoundaries, "dd", "dt"); err != nil {
+			return err
+		}
+	default:
+		if !closesParagraph[tag] {
+			return nil
+		}
+	}
+	return s.closeOpen(emit, scopeBoundaries, "p")
+}
+
+// closeOpen closes the innermost open element named by one of tags,
+// and the elements inside it, unless one of boundaries is opened
+// closer.
+func (s *TokenSanitizer) closeOpen(emit func(html.Token) error, boundaries map[string]bool, tags ...string) error {
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		tag := s.stack[i].tag
+		if slices.Contains(tags, tag) {
+			for len(s.stack) > i {
+				if err := s.pop(emit); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if boundaries[tag] {
+			return nil
+		}
+	}
+	return nil
+}
+
+// scopeBoundaries are the elements an open paragraph is not closed
+// across: those delimiting the parser's button scope.
+var scopeBoundaries = map[string]bool{
+	"applet": true, "button": true, "caption": true, "html": true, "marquee": true,
+	"object": true, "table": true, "td": true, "template": true, "th": true,
+	"svg": true, "math": true,
+}
+
+// listItemBoundaries are the elements an open list item is not closed
+// across.
+var listItemBoundaries = map[string]bool{
+	"applet": true, "button": true, "caption": true, "html": true, "marquee": true,
+	"object": true, "table": true, "td": true, "template": true, "th": true,
+	"svg": true, "math": true, "ol": true, "ul": true, "dl": true,
+}
+
+// closesParagraph holds the start tags that close an open paragraph.
+var closesParagraph = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "center": true,
+	"details": true, "dialog": true, "dir": true, "div": true, "dl": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hgroup": true, "hr": true, "listing": true, "main": true,
+	"menu": true, "nav": true, "ol": true, "p": true, "plaintext": true, "pre": true,
+	"search": true, "section": true, "summary": true, "table": true, "ul": true, "xmp": true,
+}
+
+// keptTags returns the tags of the open elements that are kept, or nil
+// if the policy has no context rules.
+func (s *TokenSanitizer) keptTags() []string {
+	if s.c.context == nil {
+		return nil
+	}
+	var kept []string
+	for _, e := range s.stack {
+		if e.action == actionKeep {
+			kept = append(kept, e.tag)
+		}
+	}
+	return kept
+}
+
+// linkExcluded reports whether an open element excludes its text from
+// linking.
+func (s *TokenSanitizer) linkExcluded() bool {
+	if s.code > 0 {
+		return true
+	}
+	for _, e := range s.stack {
+		if s.c.linkifyExclude[e.tag] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TokenSanitizer) push(tag string, action tokenAction) {
+	s.stack = append(s.stack, openElement{tag: tag, action: action})
+	switch action {
+	case actionDrop:
+		s.drops++
+	case actionCode:
+		s.code++
+	}
+}
+
+func (s *TokenSanitizer) pop(emit func(html.Token) error) error {
+	e := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	var err error
+	switch e.action {
+	case actionKeep:
+		err = emit(html.Token{Type: html.EndTagToken, Data: e.tag})
+	case actionEscape:
+		n := &html.Node{Type: html.ElementNode, Data: e.tag}
+		err = emit(html.Token{Type: html.TextToken, Data: s.c.escapedTag(n, true)})
+	case actionDrop:
+		s.drops--
+	case actionCode:
+		s.code--
+		err = s.closeCode(emit)
+	}
+	if err != nil {
+		return err
+	}
+	return s.replay(e.after, emit)
+}
+
+// closeCode ends the block started for an element shown as code.
+func (s *TokenSanitizer) closeCode(emit func(html.Token) error) error {
+	if err := emit(html.Token{Type: html.EndTagToken, Data: "code"}); err != nil {
+		return err
+	}
+	return emit(html.Token{Type: html.EndTagToken, Data: "pre"})
+}
+
+// SanitizeTokens reads tokens from z until EOF, applies p, and passes
+// the sanitized tokens to emit. Elements still open at EOF are closed.
+// If p is nil, the default policy is used.
+//
+// It lets callers that already drive an html.Tokenizer, such as proxies
+// and feed processors, sanitize without a round-trip through strings.
+func SanitizeTokens(z *html.Tokenizer, p *Policy, emit func(html.Token) error) error {
+	s := NewTokenSanitizer(p)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return err
+			}
+			return s.Close(emit)
+		}
+		if err := s.Token(s.names.token(z, tt), emit); err != nil {
+			return err
+		}
+	}
+}
+
+// TokenWriter returns an emit function for SanitizeTokens that
+// serializes each token to w.
+func TokenWriter(w io.Writer) func(html.Token) error {
+	var buf bytes.Buffer
+	return func(tok html.Token) error {
+		buf.Reset()
+		writeToken(&buf, tok)
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+}
+
+func writeToken(buf *bytes.Buffer, tok html.Token) {
+	switch tok.Type {
+	case html.TextToken:
+		escapeText(buf, tok.Data)
+	case html.StartTagToken, html.SelfClosingTagToken:
+		buf.WriteByte('<')
+		buf.WriteString(tok.Data)
+		for _, a := range tok.Attr {
+			writeAttr(buf, a)
+		}
+		if tok.Type == html.SelfClosingTagToken || isVoidElement(tok.Data) {
+			buf.WriteString(" />")
+		} else {
+			buf.WriteByte('>')
+		}
+	case html.EndTagToken:
+		buf.WriteString("</")
+		buf.WriteString(tok.Data)
+		buf.WriteByte('>')
+	}
+}
@@ -0,0 +1,96 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"golang.org/x/net/html"
+)
+
+func sanitizeTokens(t *testing.T, input string, p *htmlsanitizer.Policy) string {
+	t.Helper()
+	var sb strings.Builder
+	z := html.NewTokenizer(strings.NewReader(input))
+	if err := htmlsanitizer.SanitizeTokens(z, p, htmlsanitizer.TokenWriter(&sb)); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestSanitizeTokens_MatchesTreeOutput(t *testing.T) {
+	inputs := []string{
+		`<p>Hello <b>world</b></p><script>alert(1)</script>`,
+		`<a href="javascript:alert(1)" onclick="x()">click</a>`,
+		`<div class="x">kept</div><img src="/a.png" alt="a">`,
+		`<div><p>a<p>b</div><ul><li>one<li>two</ul>`,
+		`<dl><dt>t<dd>d<dt>u</dl>`,
+	}
+	for _, in := range inputs {
+		want, err := htmlsanitizer.Sanitize(in, htmlsanitizer.DefaultPolicy())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sanitizeTokens(t, in, htmlsanitizer.DefaultPolicy()); got != want {
+			t.Errorf("input %q: token output %q, tree output %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeTokens_StripDisallowed(t *testing.T) {
+	got := sanitizeTokens(t, `<b>ok</b><div>gone <i>too</i></div>`, htmlsanitizer.StrictPolicy())
+	if got != `<b>ok</b>` {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSanitizeTokens_ClosesOpenElements(t *testing.T) {
+	got := sanitizeTokens(t, `<p><b>unclosed`, htmlsanitizer.DefaultPolicy())
+	if got != `<p><b>unclosed</b></p>` {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSanitizeTokens_SelfClosingNonVoid(t *testing.T) {
+	// Browsers ignore "/>" on <a> and <b>, so they must be closed.
+	in := `<p><a href="https://evil.example/" />rest <b/>bold</p>`
+	want := `<p><a href="https://evil.example/">rest <b>bold</b></a></p>`
+	if got := sanitizeTokens(t, in, htmlsanitizer.DefaultPolicy()); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	pre := htmlsanitizer.DefaultPolicy()
+	pre.PreserveFormatting = true
+	if got, err := htmlsanitizer.Sanitize(`<b/>x`, pre); err != nil || got != `<b>x</b>` {
+		t.Errorf("PreserveFormatting: got %q, %v", got, err)
+	}
+
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, "svg", "circle")
+	got := sanitizeTokens(t, `<svg><circle/><b/>x</svg>`, p)
+	if want := `<svg><circle /><b>x</b></svg>`; got != want {
+		t.Errorf("svg: got %q, want %q", got, want)
+	}
+}
+
+func TestTokenSanitizer_EmitsTokens(t *testing.T) {
+	s := htmlsanitizer.NewTokenSanitizer(htmlsanitizer.DefaultPolicy())
+	var got []html.Token
+	emit := func(tok html.Token) error {
+		got = append(got, tok)
+		return nil
+	}
+	in := html.Token{Type: html.StartTagToken, Data: "a", Attr: []html.Attribute{
+		{Key: "href", Val: "https://example.com"},
+		{Key: "onclick", Val: "evil()"},
+	}}
+	if err := s.Token(in, emit); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || len(got[0].Attr) != 1 || got[0].Attr[0].Key != "href" {
+		t.Errorf("unexpected tokens: %v", got)
+	}
+	if len(in.Attr) != 2 {
+		t.Errorf("input token attributes were modified: %v", in.Attr)
+	}
+}
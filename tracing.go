@@ -0,0 +1,65 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"io"
+)
+
+// Tracer starts a span around every call that sanitizes with a policy
+// setting it, so the sanitizer shows up in distributed traces. The
+// package has no tracing dependency; an adapter for OpenTelemetry or
+// another system implements Tracer and Span. A nil Tracer traces
+// nothing.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// End ends the span, recording what the call did.
+	End(info SpanInfo)
+}
+
+// SpanInfo describes a traced call.
+type SpanInfo struct {
+	InputBytes        int
+	OutputBytes       int
+	RemovedElements   int
+	RemovedAttributes int
+	PolicyFingerprint string
+	Err               error // the error returned, if any
+}
+
+// spanName is the name of the span started for every call.
+const spanName = "htmlsanitizer.Sanitize"
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+// traced runs sanitize inside a span of p.Tracer.
+func traced(ctx context.Context, r io.Reader, c *compiledPolicy, rep *Report) (string, error) {
+	ctx, span := c.p.Tracer.Start(ctx, spanName)
+	if rep == nil {
+		rep = &Report{}
+	}
+	cr := &countingReader{r: r}
+	out, err := sanitizeInput(ctx, cr, c, rep)
+	span.End(SpanInfo{
+		InputBytes:        cr.n,
+		OutputBytes:       len(out),
+		RemovedElements:   rep.RemovedElements,
+		RemovedAttributes: rep.RemovedAttributes,
+		PolicyFingerprint: c.policyFingerprint(),
+		Err:               err,
+	})
+	return out, err
+}
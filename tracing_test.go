@@ -0,0 +1,59 @@
+package htmlsanitizer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+type recordingTracer struct {
+	name  string
+	ctx   context.Context
+	infos []htmlsanitizer.SpanInfo
+}
+
+type recordingSpan struct{ t *recordingTracer }
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, htmlsanitizer.Span) {
+	t.name, t.ctx = name, ctx
+	return ctx, recordingSpan{t}
+}
+
+func (s recordingSpan) End(info htmlsanitizer.SpanInfo) {
+	s.t.infos = append(s.t.infos, info)
+}
+
+func TestTracer(t *testing.T) {
+	tr := &recordingTracer{}
+	p := htmlsanitizer.DefaultPolicy()
+	p.Tracer = tr
+	input := `<p onclick="x()">hi<script>y()</script></p>`
+	ctx := htmlsanitizer.WithDocumentID(context.Background(), "d1")
+	out, err := htmlsanitizer.SanitizeContext(ctx, input, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.name != "htmlsanitizer.Sanitize" || len(tr.infos) != 1 {
+		t.Fatalf("spans: %q, %d", tr.name, len(tr.infos))
+	}
+	if id, _ := htmlsanitizer.DocumentID(tr.ctx); id != "d1" {
+		t.Errorf("span context lost the document id")
+	}
+	want := htmlsanitizer.SpanInfo{
+		InputBytes:        len(input),
+		OutputBytes:       len(out),
+		RemovedElements:   1,
+		RemovedAttributes: 1,
+		PolicyFingerprint: p.Fingerprint(),
+	}
+	if tr.infos[0] != want {
+		t.Errorf("got %+v\nwant %+v", tr.infos[0], want)
+	}
+
+	p = p.Clone()
+	p.MaxOutputLength = 1
+	if _, err := htmlsanitizer.Sanitize(input, p); err == nil || tr.infos[1].Err != err {
+		t.Errorf("span error %v, call error %v", tr.infos[1].Err, err)
+	}
+}
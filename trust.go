@@ -0,0 +1,116 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"sync"
+)
+
+// TrustLevel grades the authors of content, so that one service can
+// give administrators richer markup than anonymous visitors.
+type TrustLevel int
+
+const (
+	// TrustAnonymous is for unauthenticated authors: basic formatting
+	// and links marked nofollow.
+	TrustAnonymous TrustLevel = iota
+
+	// TrustMember is for registered authors: DefaultPolicy with links
+	// marked as user-generated.
+	TrustMember
+
+	// TrustAdmin is for staff: DocsPolicy.
+	TrustAdmin
+)
+
+// TrustPolicy returns a new copy of the built-in policy for level.
+// Unknown levels get the TrustAnonymous policy.
+func TrustPolicy(level TrustLevel) *Policy {
+	switch level {
+	case TrustMember:
+		p := DefaultPolicy()
+		p.AttrDefaults = append(p.AttrDefaults, AttrDefault{Tag: "a", Attr: "rel", Value: "ugc", Force: true})
+		return p
+	case TrustAdmin:
+		return DocsPolicy()
+	}
+	p := StrictPolicy()
+	p.AllowedTags = append(p.AllowedTags, "a", "blockquote", "code")
+	p.AllowedAttributes["a"] = []string{"href"}
+	p.AttrDefaults = []AttrDefault{{Tag: "a", Attr: "rel", Value: "nofollow ugc", Force: true}}
+	p.MaxDepth = 8
+	return p
+}
+
+type trustLevelKey struct{}
+
+// WithTrustLevel returns a context carrying level, for a
+// SelectingSanitizer using TrustSelector.
+func WithTrustLevel(ctx context.Context, level TrustLevel) context.Context {
+	return context.WithValue(ctx, trustLevelKey{}, level)
+}
+
+// TrustLevelFrom returns the level stored in ctx by WithTrustLevel.
+func TrustLevelFrom(ctx context.Context) (TrustLevel, bool) {
+	level, ok := ctx.Value(trustLevelKey{}).(TrustLevel)
+	return level, ok
+}
+
+// PolicySelector chooses the policy for a call from its context. It
+// must return long-lived policies that are not modified afterwards,
+// such as ones built at startup or found with PolicyByName: a
+// SelectingSanitizer compiles each distinct policy once and keeps it.
+type PolicySelector func(ctx context.Context) *Policy
+
+// TrustSelector returns a PolicySelector that picks policies[level]
+// for the trust level in the context, falling back to TrustPolicy for
+// levels missing from policies. Contexts without a level, and unknown
+// levels, get the TrustAnonymous policy.
+func TrustSelector(policies map[TrustLevel]*Policy) PolicySelector {
+	byLevel := make(map[TrustLevel]*Policy, 3)
+	for _, level := range []TrustLevel{TrustAnonymous, TrustMember, TrustAdmin} {
+		if p, ok := policies[level]; ok && p != nil {
+			byLevel[level] = p
+		} else {
+			byLevel[level] = TrustPolicy(level)
+		}
+	}
+	return func(ctx context.Context) *Policy {
+		level, _ := TrustLevelFrom(ctx)
+		if p, ok := byLevel[level]; ok {
+			return p
+		}
+		return byLevel[TrustAnonymous]
+	}
+}
+
+// SelectingSanitizer sanitizes each call with the policy its
+// PolicySelector chooses, compiling every policy once, so that call
+// sites pass a context instead of picking policies themselves. It is
+// safe for concurrent use.
+type SelectingSanitizer struct {
+	sel      PolicySelector
+	compiled sync.Map // *Policy -> *Sanitizer
+}
+
+// NewSelectingSanitizer returns a SelectingSanitizer using sel. A nil
+// policy from sel means DefaultPolicy.
+func NewSelectingSanitizer(sel PolicySelector) *SelectingSanitizer {
+	return &SelectingSanitizer{sel: sel}
+}
+
+// Sanitize sanitizes htmlStr with the policy selected for ctx, which is
+// also passed to the policy's Logger and Tracer.
+func (s *SelectingSanitizer) Sanitize(ctx context.Context, htmlStr string) (string, error) {
+	return s.sanitizer(ctx).SanitizeContext(ctx, htmlStr)
+}
+
+// sanitizer returns the compiled Sanitizer for the policy selected for
+// ctx.
+func (s *SelectingSanitizer) sanitizer(ctx context.Context) *Sanitizer {
+	p := s.sel(ctx)
+	if v, ok := s.compiled.Load(p); ok {
+		return v.(*Sanitizer)
+	}
+	v, _ := s.compiled.LoadOrStore(p, NewSanitizer(p))
+	return v.(*Sanitizer)
+}
@@ -0,0 +1,42 @@
+package htmlsanitizer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSelectingSanitizer(t *testing.T) {
+	input := `<p><a href="https://example.com">x</a> <img src="/a.png"><dl><dt>t</dt></dl></p>`
+	staff := htmlsanitizer.DocsPolicy()
+	staff.AllowedTags = append(staff.AllowedTags, "kbd")
+	s := htmlsanitizer.NewSelectingSanitizer(htmlsanitizer.TrustSelector(map[htmlsanitizer.TrustLevel]*htmlsanitizer.Policy{
+		htmlsanitizer.TrustAdmin: staff,
+	}))
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"none", ctx, `<p><a href="https://example.com" rel="nofollow ugc">x</a> </p><p></p>`},
+		{"anonymous", htmlsanitizer.WithTrustLevel(ctx, htmlsanitizer.TrustAnonymous), `<p><a href="https://example.com" rel="nofollow ugc">x</a> </p><p></p>`},
+		{"unknown", htmlsanitizer.WithTrustLevel(ctx, 7), `<p><a href="https://example.com" rel="nofollow ugc">x</a> </p><p></p>`},
+		{"member", htmlsanitizer.WithTrustLevel(ctx, htmlsanitizer.TrustMember), `<p><a href="https://example.com" rel="ugc">x</a> <img src="/a.png" /></p>&lt;dl&gt;&lt;dt&gt;t&lt;/dt&gt;&lt;/dl&gt;<p></p>`},
+		{"admin", htmlsanitizer.WithTrustLevel(ctx, htmlsanitizer.TrustAdmin), `<p><a href="https://example.com">x</a> <img src="/a.png" /></p><dl><dt>t</dt></dl><p></p>`},
+	} {
+		for i := 0; i < 2; i++ { // the second call uses the cached Sanitizer
+			got, err := s.Sanitize(tc.ctx, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("%s: got  %q\nwant %q", tc.name, got, tc.want)
+			}
+		}
+	}
+	if level, ok := htmlsanitizer.TrustLevelFrom(htmlsanitizer.WithTrustLevel(ctx, htmlsanitizer.TrustMember)); !ok || level != htmlsanitizer.TrustMember {
+		t.Errorf("TrustLevelFrom = %v, %v", level, ok)
+	}
+}
@@ -0,0 +1,334 @@
+package htmlsanitizer
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Decision is the outcome of a URLPolicy check.
+type Decision int
+
+const (
+	// Defer leaves the decision to the built-in checks, starting with
+	// AllowedSchemes.
+	Defer Decision = iota
+
+	// Allow accepts the URL without consulting AllowedSchemes. Host and
+	// length rules configured on the Policy still apply.
+	Allow
+
+	// Deny drops the attribute.
+	Deny
+)
+
+// URLPolicy decides whether a URL may be kept. The sanitizer takes care
+// of extracting, entity-decoding, and parsing attribute values; u is the
+// parsed URL with its scheme and host lower-cased; scheme-relative URLs
+// carry the scheme from Policy.SchemeRelativeScheme, and the host is in
+// the form selected by Policy.IDNHosts. Implementations must
+// be safe for concurrent use.
+type URLPolicy interface {
+	Validate(tag, attr string, u *url.URL) Decision
+}
+
+// URLPolicyFunc adapts an ordinary function to the URLPolicy interface.
+type URLPolicyFunc func(tag, attr string, u *url.URL) Decision
+
+// Validate calls f(tag, attr, u).
+func (f URLPolicyFunc) Validate(tag, attr string, u *url.URL) Decision {
+	return f(tag, attr, u)
+}
+
+// isURLAttr reports whether the value of attribute key is a URL that
+// must be validated.
+func isURLAttr(key string) bool {
+	return key == "href" || key == "src" || key == "action" || key == "xlink:href" || key == "cite"
+}
+
+// checkURL validates raw, the value of URL attribute attr on tag, and
+// returns the value to keep. It returns false if the attribute must be
+// dropped.
+func (c *compiledPolicy) checkURL(tag, attr, raw string) (string, bool) {
+	u, ok := parseURLValue(raw)
+	if !ok {
+		return "", false
+	}
+	schemeRelative := u.Scheme == "" && u.Host != ""
+	if schemeRelative {
+		if c.p.RejectSchemeRelativeURLs {
+			return "", false
+		}
+		u.Scheme = c.p.schemeRelativeScheme()
+	}
+	if c.p.IDNHosts != IDNPreserve && u.Host != "" {
+		host := u.Host
+		if !normalizeHost(u, c.p.IDNHosts) {
+			return "", false
+		}
+		if u.Host != host || !strings.Contains(raw, host) {
+			raw = withHost(u, schemeRelative)
+		}
+	}
+	decision := Defer
+	if c.p.URLPolicy != nil {
+		decision = c.p.URLPolicy.Validate(tag, attr, u)
+	}
+	switch decision {
+	case Deny:
+		return "", false
+	case Defer:
+		if u.Scheme != "" && !c.allowedSchemes[u.Scheme] {
+			return "", false
+		}
+	}
+	if !c.hostAllowed(u) {
+		return "", false
+	}
+	if u.Scheme == "mailto" && c.p.checksMailto() {
+		if raw, ok = c.checkMailto(raw, u); !ok {
+			return "", false
+		}
+	}
+	return c.checkURLShape(raw)
+}
+
+// checkURLShape applies the length and encoding limits of the policy
+// to raw.
+func (c *compiledPolicy) checkURLShape(raw string) (string, bool) {
+	p := c.p
+	if p.MaxURLEncodedRatio > 0 || p.RejectNestedURLEncoding {
+		escapes, nested := countPercentEscapes(raw)
+		if p.RejectNestedURLEncoding && nested > 0 {
+			return "", false
+		}
+		if p.MaxURLEncodedRatio > 0 && len(raw) > 0 &&
+			float64(3*escapes)/float64(len(raw)) > p.MaxURLEncodedRatio {
+			return "", false
+		}
+	}
+	if p.MaxURLLength > 0 && len(raw) > p.MaxURLLength {
+		if !p.TruncateLongURLs {
+			return "", false
+		}
+		raw = truncateURL(raw, p.MaxURLLength)
+	}
+	return raw, true
+}
+
+// countPercentEscapes counts the %XX escapes in s, and separately the
+// escapes that encode a further escape (%25 followed by two hex digits).
+func countPercentEscapes(s string) (escapes, nested int) {
+	for i := 0; i+2 < len(s); i++ {
+		if s[i] != '%' || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			continue
+		}
+		escapes++
+		if s[i+1] == '2' && s[i+2] == '5' && i+4 < len(s) && isHex(s[i+3]) && isHex(s[i+4]) {
+			nested++
+		}
+		i += 2
+	}
+	return escapes, nested
+}
+
+func isHex(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+// truncateURL cuts s to at most n bytes without splitting a percent
+// escape or a UTF-8 sequence.
+func truncateURL(s string, n int) string {
+	if i := strings.LastIndexByte(s[:n], '%'); i >= 0 && i > n-3 {
+		n = i
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// parseURLValue normalizes an attribute value the way a browser would
+// before resolving it, and parses the result. The scheme and host are
+// lower-cased. Relative URLs parse with an empty scheme.
+func parseURLValue(raw string) (*url.URL, bool) {
+	raw = strings.TrimSpace(raw)
+	// Decode HTML entities to prevent &#106;avascript: bypasses.
+	decoded := htmlDecodeMinimal(raw)
+	decoded = strings.TrimSpace(decoded)
+
+	// Strip zero-width / control chars that can confuse parsers.
+	decoded = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, decoded)
+
+	u, err := url.Parse(leadingSlashes(decoded))
+	if err != nil {
+		return nil, false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	return u, true
+}
+
+// leadingSlashes rewrites a leading run of slashes and backslashes to
+// match what browsers see. They treat backslashes as slashes and any
+// run of two or more as the start of an authority, so /\evil.example
+// and ///evil.example are both scheme-relative links to evil.example.
+func leadingSlashes(s string) string {
+	i := 0
+	for i < len(s) && (s[i] == '/' || s[i] == '\\') {
+		i++
+	}
+	switch {
+	case i >= 2:
+		return "//" + s[i:]
+	case i == 1:
+		return "/" + s[1:]
+	}
+	return s
+}
+
+// hostAllowed applies the policy's userinfo, port, and IP restrictions
+// to u. URLs without a host pass.
+func (c *compiledPolicy) hostAllowed(u *url.URL) bool {
+	p := c.p
+	if p.RejectURLUserinfo && u.User != nil {
+		return false
+	}
+	if u.Host == "" {
+		return true
+	}
+	if port := u.Port(); port != "" && p.AllowedURLPorts != nil && !c.portAllowed(u.Scheme, port) {
+		return false
+	}
+	if p.RejectIPHosts && isIPHost(u.Hostname()) {
+		return false
+	}
+	return true
+}
+
+// defaultPorts maps schemes to the port they use when none is given.
+var defaultPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+	"ftp":   21,
+	"ws":    80,
+	"wss":   443,
+}
+
+func (c *compiledPolicy) portAllowed(scheme, port string) bool {
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return false
+	}
+	if d, ok := defaultPorts[scheme]; ok && d == n {
+		return true
+	}
+	for _, allowed := range c.p.AllowedURLPorts {
+		if allowed == n {
+			return true
+		}
+	}
+	return false
+}
+
+// isIPHost reports whether host is an IP literal. Besides the forms
+// net.ParseIP understands, browsers resolve hosts such as 2130706433,
+// 0x7f.1, and 0177.0.0.1 as IPv4 addresses, so any host made up only of
+// numeric labels counts.
+func isIPHost(host string) bool {
+	if host == "" {
+		return false
+	}
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if !isNumericLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumericLabel(s string) bool {
+	if strings.HasPrefix(s, "0x") {
+		s = s[2:]
+		for _, r := range s {
+			if !strings.ContainsRune("0123456789abcdef", r) {
+				return false
+			}
+		}
+		return true
+	}
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// htmlDecodeMinimal decodes a few common entity tricks used to smuggle
+// schemes (&#x6A; etc.) without pulling in a full entity decoder.
+// It's applied to attribute values that are potentially URLs.
+func htmlDecodeMinimal(s string) string {
+	// Use golang.org/x/net/html tokenizer trick: wrap in an attribute
+	// and let the parser decode it. This is safer than manual decoding.
+	// We only want to decode entities within the value itself, not parse
+	// arbitrary HTML structure, so we construct a minimal attribute value.
+	// For example, &#x6A;avascript: should decode to javascript:.
+	// We need to parse attribute values specifically.
+
+	// Create a dummy node to parse its attributes.
+	// The HTML parser will handle entity decoding within attribute values.
+	// We're essentially asking the parser to decode HTML entities in a string.
+	// A simple way to do this is to parse a minimal HTML fragment that contains
+	// the string as an attribute value.
+	input := `<a data-val="` + s + `"></a>`
+	doc, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		// If parsing fails, return the original string to be safe.
+		return s
+	}
+
+	var decodedVal string
+	// Traverse the parsed document to find the attribute.
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			for _, attr := range n.Attr {
+				if attr.Key == "data-val" {
+					decodedVal = attr.Val
+					return // Found the attribute, exit walk.
+				}
+			}
+		}
+		// Continue traversal if not found in this node.
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+			if decodedVal != "" {
+				return // Exit early if found in a child.
+			}
+		}
+	}
+	walk(doc)
+
+	// If we successfully decoded a value, return it. Otherwise, return original.
+	if decodedVal != "" {
+		return decodedVal
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// VerifyMode selects what Policy.Verify does when the serialized output
+// does not parse back to the tree it was rendered from.
+type VerifyMode int
+
+const (
+	// VerifyOff skips verification.
+	VerifyOff VerifyMode = iota
+
+	// VerifyError makes sanitization fail with a *MutationError.
+	VerifyError
+
+	// VerifyFix sanitizes the output again until it is stable, and fails
+	// with a *MutationError if that does not happen within a few rounds.
+	VerifyFix
+)
+
+// maxVerifyRounds bounds the number of re-sanitization passes in
+// VerifyFix mode.
+const maxVerifyRounds = 3
+
+// MutationError reports an element or attribute that appeared when the
+// sanitized output was parsed again.
+type MutationError struct {
+	// Element is the tag name of the unexpected element, or of the
+	// element carrying the unexpected attribute.
+	Element string
+
+	// Attribute is the unexpected attribute, or "" if the element
+	// itself was unexpected.
+	Attribute string
+}
+
+func (e *MutationError) Error() string {
+	if e.Attribute != "" {
+		return fmt.Sprintf("htmlsanitizer: output mutates on reparse: unexpected attribute %q on <%s>", e.Attribute, e.Element)
+	}
+	return fmt.Sprintf("htmlsanitizer: output mutates on reparse: unexpected <%s> element", e.Element)
+}
+
+// verify checks out, the serialization of root, according to p.Verify.
+func verify(root *html.Node, out string, c *compiledPolicy) (string, error) {
+	err := checkReparse(root, out, c.p.XHTML)
+	if err == nil || c.p.Verify == VerifyError {
+		return out, err
+	}
+	for i := 0; i < maxVerifyRounds; i++ {
+		if root, err = sanitizeTree(context.Background(), strings.NewReader(out), c, nil); err != nil {
+			return "", err
+		}
+		if out, err = renderRoot(root, c); err != nil {
+			return "", err
+		}
+		if err = checkReparse(root, out, c.p.XHTML); err == nil {
+			return out, nil
+		}
+	}
+	return "", err
+}
+
+// checkReparse parses out as a body fragment, or as XML if xhtml is
+// set, and reports the first element or attribute that occurs more
+// often than in root.
+func checkReparse(root *html.Node, out string, xhtml bool) error {
+	want := make(map[censusKey]int)
+	census(root, want)
+
+	var nodes []*html.Node
+	if xhtml {
+		doc, err := parseXHTML(strings.NewReader(out))
+		if err != nil {
+			return err
+		}
+		for n := doc.FirstChild; n != nil; n = n.NextSibling {
+			nodes = append(nodes, n)
+		}
+	} else {
+		context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		var err error
+		if nodes, err = html.ParseFragment(strings.NewReader(out), context); err != nil {
+			return err
+		}
+	}
+	got := make(map[censusKey]int)
+	for _, n := range nodes {
+		census(n, got)
+	}
+	for _, n := range nodes {
+		if err := findExcess(n, got, want); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// censusKey identifies an element, or with attr set an
+// element/attribute pair, in a census. A struct key keeps the count
+// from concatenating a string for every attribute.
+type censusKey struct {
+	tag, attr string
+}
+
+// census counts the elements and element/attribute pairs under n.
+func census(n *html.Node, counts map[censusKey]int) {
+	if n.Type == html.ElementNode {
+		tag := strings.ToLower(n.Data)
+		counts[censusKey{tag: tag}]++
+		for _, a := range n.Attr {
+			counts[censusKey{tag, strings.ToLower(a.Key)}]++
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		census(c, counts)
+	}
+}
+
+// findExcess returns a MutationError for the first node under n whose
+// count in got exceeds its count in want.
+func findExcess(n *html.Node, got, want map[censusKey]int) error {
+	if n.Type == html.ElementNode {
+		tag := strings.ToLower(n.Data)
+		if key := (censusKey{tag: tag}); got[key] > want[key] {
+			return &MutationError{Element: tag}
+		}
+		for _, a := range n.Attr {
+			key := censusKey{tag, strings.ToLower(a.Key)}
+			if got[key] > want[key] {
+				return &MutationError{Element: tag, Attribute: a.Key}
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := findExcess(c, got, want); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"golang.org/x/net/html"
+)
+
+// nestParagraphs is a post transformer that builds a tree the parser
+// cannot produce: a <p> inside a <p> is closed early on reparse, so the
+// serialized output turns into more paragraphs than were rendered.
+func nestParagraphs(root *html.Node) {
+	outer := &html.Node{Type: html.ElementNode, Data: "p"}
+	outer.AppendChild(&html.Node{Type: html.ElementNode, Data: "p"})
+	root.AppendChild(outer)
+}
+
+func TestSanitize_VerifyStableOutput(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Verify = htmlsanitizer.VerifyError
+	input := `<table><tr><td>a</td></tr></table><p>x <b>y</b></p><div>&lt;script&gt;</div><xmp></xmp><img src=x onerror=alert(1)></xmp>`
+	got, err := htmlsanitizer.Sanitize(input, p)
+	if err != nil {
+		t.Fatalf("stable output failed verification: %v (%q)", err, got)
+	}
+}
+
+func TestSanitize_VerifyErrorDetectsMutation(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Verify = htmlsanitizer.VerifyError
+	p.PostTransformers = []func(*html.Node){nestParagraphs}
+	_, err := htmlsanitizer.Sanitize(`<b>x</b>`, p)
+	var me *htmlsanitizer.MutationError
+	if !errors.As(err, &me) || me.Element != "p" {
+		t.Fatalf("expected MutationError for <p>, got %v", err)
+	}
+}
+
+func TestSanitize_VerifyFix(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.Verify = htmlsanitizer.VerifyFix
+	first := true
+	p.PostTransformers = []func(*html.Node){func(root *html.Node) {
+		if first {
+			first = false
+			nestParagraphs(root)
+		}
+	}}
+	got, err := htmlsanitizer.Sanitize(`<b>x</b>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `<b>x</b><p></p><p></p><p></p>` {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build go1.23
+
+package htmlsanitizer
+
+import (
+	"context"
+	"iter"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// NodeInfo describes where a node yielded by Visit sits in the
+// sanitized fragment.
+type NodeInfo struct {
+	// Depth is 1 for the top-level nodes of the fragment and grows by
+	// one per enclosing element.
+	Depth int
+
+	// Path holds the tag names of the enclosing elements, outermost
+	// first. It is reused between iterations and must be copied to be
+	// kept.
+	Path []string
+
+	// Err is set, with a nil node, on the only pair Visit yields when
+	// sanitizing fails, for example because the input exceeds one of
+	// the policy's limits.
+	Err error
+}
+
+// Visit sanitizes htmlStr with p and yields the nodes of the result in
+// document order, so that links, images, or mentions can be collected
+// without registering a Transformer for a read-only purpose:
+//
+//	for n, info := range htmlsanitizer.Visit(post, p) {
+//		if info.Err != nil {
+//			return info.Err
+//		}
+//		if n.Type == html.ElementNode && n.Data == "img" {
+//			images = append(images, htmlsanitizer.GetAttr(n, "src"))
+//		}
+//	}
+//
+// The document is sanitized when iteration starts; stopping early skips
+// the rest of the walk. The nodes belong to a tree that is discarded
+// afterwards and must not be modified. If p is nil, the default policy
+// is used.
+func Visit(htmlStr string, p *Policy) iter.Seq2[*html.Node, NodeInfo] {
+	if p == nil {
+		p = nilPolicy()
+	}
+	return visit(htmlStr, compilePolicy(p))
+}
+
+// Visit is like the package-level Visit with the policy of s.
+func (s *Sanitizer) Visit(htmlStr string) iter.Seq2[*html.Node, NodeInfo] {
+	return visit(htmlStr, s.c)
+}
+
+func visit(htmlStr string, c *compiledPolicy) iter.Seq2[*html.Node, NodeInfo] {
+	return func(yield func(*html.Node, NodeInfo) bool) {
+		root, err := sanitizeTree(context.Background(), strings.NewReader(htmlStr), c, nil)
+		if err != nil {
+			yield(nil, NodeInfo{Err: err})
+			return
+		}
+		visitChildren(root, nil, yield)
+	}
+}
+
+// visitChildren yields the descendants of n, whose enclosing elements
+// are path, and reports whether the caller wants more.
+func visitChildren(n *html.Node, path []string, yield func(*html.Node, NodeInfo) bool) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if !yield(child, NodeInfo{Depth: len(path) + 1, Path: path}) {
+			return false
+		}
+		if child.Type == html.ElementNode && !visitChildren(child, append(path, child.Data), yield) {
+			return false
+		}
+	}
+	return true
+}
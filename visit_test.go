@@ -0,0 +1,65 @@
+//go:build go1.23
+
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+	"golang.org/x/net/html"
+)
+
+func TestVisit(t *testing.T) {
+	input := `<blockquote><p><a href="/a">a</a><script>x</script></p></blockquote><a href="/b">b</a>`
+	var links []string
+	var paths [][]string
+	for n, info := range htmlsanitizer.Visit(input, nil) {
+		if info.Err != nil {
+			t.Fatal(info.Err)
+		}
+		if n.Type == html.ElementNode && n.Data == "script" {
+			t.Error("visited a stripped <script>")
+		}
+		if n.Type == html.ElementNode && n.Data == "a" {
+			links = append(links, htmlsanitizer.GetAttr(n, "href"))
+			paths = append(paths, slices.Clone(info.Path))
+		}
+	}
+	if !slices.Equal(links, []string{"/a", "/b"}) {
+		t.Errorf("links = %v", links)
+	}
+	if len(paths) != 2 || !slices.Equal(paths[0], []string{"blockquote", "p"}) || len(paths[1]) != 0 {
+		t.Errorf("paths = %v", paths)
+	}
+}
+
+func TestVisit_Stop(t *testing.T) {
+	visited := 0
+	for range htmlsanitizer.NewSanitizer(nil).Visit(`<p>a</p><p>b</p><p>c</p>`) {
+		visited++
+		if visited == 2 {
+			break
+		}
+	}
+	if visited != 2 {
+		t.Errorf("visited %d nodes after break", visited)
+	}
+}
+
+func TestVisit_Error(t *testing.T) {
+	p := htmlsanitizer.DefaultPolicy()
+	p.MaxInputSize = 10
+	pairs := 0
+	for n, info := range htmlsanitizer.Visit(strings.Repeat("<p>x</p>", 10), p) {
+		pairs++
+		if n != nil || !errors.Is(info.Err, htmlsanitizer.ErrInputTooLarge) {
+			t.Errorf("got %v, %+v", n, info)
+		}
+	}
+	if pairs != 1 {
+		t.Errorf("yielded %d pairs, want 1", pairs)
+	}
+}
@@ -0,0 +1,81 @@
+package htmlsanitizer
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// EmptyElementMode selects how Policy.EmptyElements writes allowed
+// elements that HTML does not define when they have no content.
+type EmptyElementMode int
+
+const (
+	// EmptyElementsKeep writes them with a start and an end tag, as in
+	// <x-icon></x-icon>.
+	EmptyElementsKeep EmptyElementMode = iota
+
+	// EmptyElementsSelfClose writes them self-closed, as in <x-icon />,
+	// for consumers that read the output as XML or as templates. HTML
+	// parsers ignore the slash, so content that follows such an element
+	// ends up inside it when the output is parsed as HTML.
+	EmptyElementsSelfClose
+
+	// EmptyElementsDrop removes them, and elements left empty by their
+	// removal.
+	EmptyElementsDrop
+)
+
+// isVoid reports whether tag is void, in HTML or by
+// Policy.VoidElements.
+func (c *compiledPolicy) isVoid(tag string) bool {
+	return isVoidElement(tag) || c.voidTags[tag]
+}
+
+// selfCloses reports whether n is written as a single self-closed tag.
+// c may be nil, in which case only HTML's void elements are.
+func (c *compiledPolicy) selfCloses(n *html.Node) bool {
+	switch {
+	case isVoidElement(n.Data):
+		return true
+	case c == nil || n.Namespace != "":
+		return false
+	case c.voidTags[n.Data]:
+		return true
+	}
+	return c.p.EmptyElements == EmptyElementsSelfClose && n.FirstChild == nil && isUnknownElement(n)
+}
+
+// applyVoidElements moves whatever the parser put inside the elements
+// of Policy.VoidElements to follow them, and removes empty unknown
+// elements if Policy.EmptyElements is EmptyElementsDrop.
+func (c *compiledPolicy) applyVoidElements(root *html.Node) {
+	var voids, unknown []*html.Node
+	walkElements(root, func(n *html.Node) {
+		switch {
+		case n.Namespace != "":
+		case c.voidTags[n.Data]:
+			voids = append(voids, n)
+		case c.p.EmptyElements == EmptyElementsDrop && isUnknownElement(n):
+			unknown = append(unknown, n)
+		}
+	})
+	for _, n := range voids {
+		for child := n.LastChild; child != nil; child = n.LastChild {
+			n.RemoveChild(child)
+			n.Parent.InsertBefore(child, n.NextSibling)
+		}
+	}
+	// Innermost first, so that an element holding nothing but empty
+	// ones is emptied before it is looked at.
+	for i := len(unknown) - 1; i >= 0; i-- {
+		if n := unknown[i]; n.FirstChild == nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// isUnknownElement reports whether n is an HTML element the parser has
+// no name for, such as a custom element.
+func isUnknownElement(n *html.Node) bool {
+	return n.Namespace == "" && atom.Lookup([]byte(n.Data)) == 0
+}
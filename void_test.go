@@ -0,0 +1,95 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func voidPolicy() *htmlsanitizer.Policy {
+	p := htmlsanitizer.DefaultPolicy()
+	p.AllowedTags = append(p.AllowedTags, "x-icon", "x-card")
+	p.AllowedAttributes["x-icon"] = []string{"name"}
+	p.VoidElements = []string{"X-Icon"}
+	return p
+}
+
+func TestSanitize_VoidElements(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"self-closed", `<p><x-icon name="star"/> Star</p>`, `<p><x-icon name="star" /> Star</p>`},
+		{"content follows", `<p><x-icon name="star">Star</x-icon>!</p>`, `<p><x-icon name="star" />Star!</p>`},
+		{"nested", `<x-icon><x-icon>a</x-icon>b</x-icon>`, `<x-icon /><x-icon />ab`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, preserve := range []bool{false, true} {
+				p := voidPolicy()
+				p.PreserveFormatting = preserve
+				got, err := htmlsanitizer.Sanitize(tt.in, p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if preserve {
+					// Tokens keep their order; only the end tags go.
+					if strings.Contains(got, "</x-icon>") || !strings.Contains(got, "<x-icon") {
+						t.Errorf("preserving: got %q", got)
+					}
+					continue
+				}
+				if got != tt.want {
+					t.Errorf("got  %q\nwant %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitize_VoidElementsStrictParse(t *testing.T) {
+	p := voidPolicy()
+	p.StrictParse = true
+	got, err := htmlsanitizer.Sanitize(`<p><x-icon name="a"> text</p>`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<p><x-icon name="a" /> text</p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSanitize_EmptyElements(t *testing.T) {
+	in := `<x-card></x-card><x-card><x-card></x-card></x-card><x-card>a</x-card><p></p>`
+	tests := []struct {
+		mode htmlsanitizer.EmptyElementMode
+		want string
+	}{
+		{htmlsanitizer.EmptyElementsKeep, `<x-card></x-card><x-card><x-card></x-card></x-card><x-card>a</x-card><p></p>`},
+		{htmlsanitizer.EmptyElementsSelfClose, `<x-card /><x-card><x-card /></x-card><x-card>a</x-card><p></p>`},
+		{htmlsanitizer.EmptyElementsDrop, `<x-card>a</x-card><p></p>`},
+	}
+	for _, tt := range tests {
+		p := voidPolicy()
+		p.EmptyElements = tt.mode
+		got, err := htmlsanitizer.Sanitize(in, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("mode %d: got  %q\nwant %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_VoidElements(t *testing.T) {
+	p := voidPolicy()
+	if err := p.Validate(); err != nil {
+		t.Fatalf("valid policy: %v", err)
+	}
+	p.VoidElements = []string{"textarea", "bad name"}
+	err := p.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"textarea"`) || !strings.Contains(err.Error(), `"bad name"`) {
+		t.Errorf("Validate() = %v, want errors for both names", err)
+	}
+}
@@ -0,0 +1,96 @@
+package htmlsanitizer
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultWordsPerMinute is the reading speed ReadingTime uses when
+// given a non-positive rate.
+const DefaultWordsPerMinute = 200
+
+// WordCount sanitizes htmlStr with p and counts the words in the text
+// that remains. Chinese and Japanese characters are counted one per
+// word, since those scripts do not separate words with spaces. If p is
+// nil, the default policy is used.
+func WordCount(htmlStr string, p *Policy) (int, error) {
+	if p == nil {
+		p = nilPolicy()
+	}
+	root, err := sanitizeTree(context.Background(), strings.NewReader(htmlStr), compilePolicy(p), nil)
+	if err != nil {
+		return 0, err
+	}
+	var b strings.Builder
+	writeBlockText(&b, root)
+	return countWords(b.String()), nil
+}
+
+// ReadingTime estimates how long the sanitized text of htmlStr takes to
+// read at wpm words per minute, counting words as WordCount does. If
+// wpm is not positive, DefaultWordsPerMinute is used.
+func ReadingTime(htmlStr string, p *Policy, wpm int) (time.Duration, error) {
+	words, err := WordCount(htmlStr, p)
+	if err != nil {
+		return 0, err
+	}
+	if wpm <= 0 {
+		wpm = DefaultWordsPerMinute
+	}
+	return time.Duration(words) * time.Minute / time.Duration(wpm), nil
+}
+
+// writeBlockText writes the text of n to b, separating block-level
+// elements and line breaks with spaces so their words do not run
+// together.
+func writeBlockText(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		b.WriteString(n.Data)
+		return
+	}
+	sep := n.Type == html.ElementNode && (blockTags[n.Data] || textBreakTags[n.Data])
+	if sep {
+		b.WriteByte(' ')
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeBlockText(b, c)
+	}
+	if sep {
+		b.WriteByte(' ')
+	}
+}
+
+// textBreakTags separate words in addition to blockTags.
+var textBreakTags = map[string]bool{
+	"br": true, "li": true, "dt": true, "dd": true, "tr": true, "td": true,
+	"th": true, "caption": true, "figcaption": true, "summary": true,
+}
+
+// countWords counts runs of letters and digits in s, and every Han,
+// Hiragana, and Katakana character on its own. Apostrophes and hyphens
+// inside a word do not split it.
+func countWords(s string) int {
+	words := 0
+	inWord := false
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case inWord && (r == '\'' || r == '’' || r == '-'):
+			// stays in the word
+		default:
+			inWord = false
+		}
+	}
+	return words
+}
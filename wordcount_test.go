@@ -0,0 +1,46 @@
+package htmlsanitizer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{`<p>Hello, <b>wor</b>ld!</p><p>It's a well-known fact.</p>`, 6},
+		{`<ul><li>one</li><li>two</li></ul>three`, 3},
+		{`<p>日本語のテキスト</p>`, 8},
+		{`<p>Go言語 is fun</p>`, 5},
+		{`<p>안녕하세요 세계</p>`, 2},
+		{`<script>lots of hidden words</script>`, 0},
+	}
+	for _, tt := range tests {
+		got, err := htmlsanitizer.WordCount(tt.in, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %d want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	in := "<p>" + strings.Repeat("word ", 400) + "</p>"
+	got, err := htmlsanitizer.ReadingTime(in, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 2*time.Minute {
+		t.Errorf("default rate: got %v", got)
+	}
+	if got, _ = htmlsanitizer.ReadingTime(in, nil, 800); got != 30*time.Second {
+		t.Errorf("800 wpm: got %v", got)
+	}
+}
@@ -0,0 +1,95 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrWriterClosed is returned by writes to a SanitizingWriter after
+// Close.
+var ErrWriterClosed = errors.New("htmlsanitizer: write to closed SanitizingWriter")
+
+// SanitizingWriter buffers everything written to it and writes the
+// sanitized document to the underlying writer on Close. Wrapped around
+// template execution or any other code producing HTML, it guards a
+// response against markup that reached the output unescaped, such as
+// user input rendered with text/template. As with Sanitize, the output
+// is the sanitized content of the document's body, so it suits
+// fragments and page bodies rather than whole pages with a <head>.
+//
+// Unlike IncrementalSanitizer, nothing reaches the underlying writer
+// before Close, so the whole document is parsed as one and output that
+// turns out to be unwanted can be dropped with Reset. A SanitizingWriter
+// is not safe for concurrent use.
+type SanitizingWriter struct {
+	w      io.Writer
+	c      *compiledPolicy
+	buf    bytes.Buffer
+	closed bool
+}
+
+// NewSanitizingWriter returns a SanitizingWriter that applies p and
+// writes to w. If p is nil, the default policy is used.
+func NewSanitizingWriter(w io.Writer, p *Policy) *SanitizingWriter {
+	if p == nil {
+		p = nilPolicy()
+	}
+	return &SanitizingWriter{w: w, c: compilePolicy(p)}
+}
+
+// Write buffers b.
+func (sw *SanitizingWriter) Write(b []byte) (int, error) {
+	if sw.closed {
+		return 0, ErrWriterClosed
+	}
+	return sw.buf.Write(b)
+}
+
+// WriteString buffers s.
+func (sw *SanitizingWriter) WriteString(s string) (int, error) {
+	if sw.closed {
+		return 0, ErrWriterClosed
+	}
+	return sw.buf.WriteString(s)
+}
+
+// Reset discards everything written so far.
+func (sw *SanitizingWriter) Reset() {
+	sw.buf.Reset()
+}
+
+// Close sanitizes the buffered document and writes it to the
+// underlying writer. If sanitizing fails, nothing is written. Close
+// does not close the underlying writer; calling it again does nothing.
+func (sw *SanitizingWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	sw.closed = true
+	out, err := sanitize(context.Background(), &sw.buf, sw.c, nil)
+	sw.buf = bytes.Buffer{}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(sw.w, out)
+	return err
+}
+
+// Executor is implemented by *html/template.Template and
+// *text/template.Template.
+type Executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// ExecuteSanitized executes t with data and writes the sanitized output
+// to w. If execution fails, nothing is written to w, so a half-rendered
+// page never reaches the client.
+func ExecuteSanitized(w io.Writer, t Executor, data any, p *Policy) error {
+	sw := NewSanitizingWriter(w, p)
+	if err := t.Execute(sw, data); err != nil {
+		return err
+	}
+	return sw.Close()
+}
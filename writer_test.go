@@ -0,0 +1,63 @@
+package htmlsanitizer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func TestSanitizingWriter(t *testing.T) {
+	var out strings.Builder
+	sw := htmlsanitizer.NewSanitizingWriter(&out, nil)
+	sw.WriteString(`<p>Hello <scr`)
+	sw.Write([]byte(`ipt>alert(1)</script><b>world</b>`))
+	if out.Len() != 0 {
+		t.Fatalf("wrote %q before Close", out.String())
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<p>Hello <b>world</b></p>`; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+	if _, err := sw.Write([]byte("x")); !errors.Is(err, htmlsanitizer.ErrWriterClosed) {
+		t.Errorf("Write after Close: err = %v", err)
+	}
+	if err := sw.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+
+	out.Reset()
+	sw = htmlsanitizer.NewSanitizingWriter(&out, nil)
+	sw.WriteString(`<p>draft</p>`)
+	sw.Reset()
+	sw.WriteString(`<p>final</p>`)
+	sw.Close()
+	if want := `<p>final</p>`; out.String() != want {
+		t.Errorf("after Reset: got %q, want %q", out.String(), want)
+	}
+}
+
+func TestExecuteSanitized(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(`<div class="comment">{{.Body}}</div>`))
+	var out strings.Builder
+	err := htmlsanitizer.ExecuteSanitized(&out, tmpl, map[string]string{"Body": `hi <img src=x onerror=alert(1)>`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<div class="comment">hi <img src="x" /></div>`; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	broken := template.Must(template.New("").Parse(`<p>partial</p>{{.Missing}}`))
+	if err := htmlsanitizer.ExecuteSanitized(&out, broken, struct{}{}, nil); err == nil {
+		t.Error("template error not returned")
+	}
+	if out.Len() != 0 {
+		t.Errorf("wrote %q for a failed template", out.String())
+	}
+}
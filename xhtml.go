@@ -0,0 +1,198 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Namespaces recognized in XHTML input.
+const (
+	xhtmlNamespace = "http://www.w3.org/1999/xhtml"
+	svgNamespace   = "http://www.w3.org/2000/svg"
+	mathNamespace  = "http://www.w3.org/1998/Math/MathML"
+	xlinkNamespace = "http://www.w3.org/1999/xlink"
+	xmlNamespace   = "http://www.w3.org/XML/1998/namespace"
+	epubNamespace  = "http://www.idpf.org/2007/ops"
+)
+
+// elementNamespaces maps the namespaces of XHTML elements to the
+// html.Node Namespace values the HTML parser uses for them.
+var elementNamespaces = map[string]string{
+	"":             "",
+	xhtmlNamespace: "",
+	svgNamespace:   "svg",
+	mathNamespace:  "math",
+}
+
+// elementNamespaceURIs is the inverse of elementNamespaces, for
+// rendering.
+var elementNamespaceURIs = map[string]string{
+	"":     xhtmlNamespace,
+	"svg":  svgNamespace,
+	"math": mathNamespace,
+}
+
+// attrNamespaces maps the namespaces of attributes kept from XHTML
+// input to their prefixes. Such attributes are keyed by their qualified
+// name, as in "xml:lang" or "epub:type", which is also how
+// AllowedAttributes names them.
+var attrNamespaces = map[string]string{
+	xlinkNamespace: "xlink",
+	xmlNamespace:   "xml",
+	epubNamespace:  "epub",
+}
+
+// attrPrefixURIs maps the attribute prefixes that output must declare
+// to their namespaces; "xml" is bound in every document.
+var attrPrefixURIs = map[string]string{
+	"xlink": xlinkNamespace,
+	"epub":  epubNamespace,
+}
+
+// parseXHTML parses r as XML into a document tree like the one
+// html.Parse builds: XHTML elements are in the HTML namespace, with
+// lower-case names, and SVG and MathML elements keep their names and
+// carry the "svg" and "math" namespaces. Elements in other namespaces
+// are dropped with their content, as are attributes in namespaces
+// other than xlink, xml, and epub, comments, and processing
+// instructions.
+//
+// Only the predefined XML entities and the HTML named entities are
+// expanded; document types are skipped, so entities they declare are
+// never expanded and references to them are errors. Input that is not
+// well-formed, including elements with duplicate attributes, which
+// encoding/xml accepts, fails with a *ParseError.
+func parseXHTML(r io.Reader) (*html.Node, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = true
+	d.Entity = xml.HTMLEntity
+	doc := &html.Node{Type: html.DocumentNode}
+	cur := doc
+	skip := 0                       // depth inside a dropped element
+	seen := make(map[xml.Name]bool) // attributes of the current element
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return doc, nil
+		}
+		if err != nil {
+			var serr *xml.SyntaxError
+			if errors.As(err, &serr) {
+				line, col := d.InputPos()
+				return nil, &ParseError{Line: line, Column: col, Msg: serr.Msg}
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			ns, ok := elementNamespaces[t.Name.Space]
+			if skip > 0 || !ok {
+				skip++
+				continue
+			}
+			n := &html.Node{Type: html.ElementNode, Data: t.Name.Local, Namespace: ns}
+			if ns == "" {
+				n.Data = strings.ToLower(n.Data)
+				n.DataAtom = atom.Lookup([]byte(n.Data))
+			}
+			clear(seen)
+			for _, a := range t.Attr {
+				if seen[a.Name] {
+					line, col := d.InputPos()
+					return nil, &ParseError{Line: line, Column: col, Msg: "duplicate attribute " + a.Name.Local}
+				}
+				seen[a.Name] = true
+				key := a.Name.Local
+				switch prefix, known := attrNamespaces[a.Name.Space]; {
+				case a.Name.Space == "":
+					if key != "xmlns" {
+						n.Attr = append(n.Attr, html.Attribute{Key: key, Val: a.Value})
+					}
+				case known:
+					n.Attr = append(n.Attr, html.Attribute{Key: prefix + ":" + key, Val: a.Value})
+				}
+			}
+			cur.AppendChild(n)
+			cur = n
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			cur = cur.Parent
+		case xml.CharData:
+			if skip > 0 {
+				continue
+			}
+			if last := cur.LastChild; last != nil && last.Type == html.TextNode {
+				last.Data += string(t)
+			} else {
+				cur.AppendChild(&html.Node{Type: html.TextNode, Data: string(t)})
+			}
+		}
+	}
+}
+
+// renderXHTML serializes a cleaned node and its descendants into buf
+// as XML. c is the policy that cleaned them, ns the namespace of the
+// enclosing element, and bound the attribute prefixes declared around n; elements that switch namespace
+// or use another prefix declare it, so the output keeps its namespaces
+// when it is parsed as XML.
+func renderXHTML(buf *bytes.Buffer, n *html.Node, c *compiledPolicy, ns string, bound []string) {
+	switch n.Type {
+	case html.TextNode:
+		escapeText(buf, n.Data)
+
+	case html.ElementNode:
+		buf.WriteByte('<')
+		buf.WriteString(n.Data)
+		if n.Namespace != ns {
+			buf.WriteString(` xmlns="`)
+			buf.WriteString(elementNamespaceURIs[n.Namespace])
+			buf.WriteByte('"')
+		}
+		for _, a := range n.Attr {
+			if prefix, _, ok := strings.Cut(a.Key, ":"); ok && attrPrefixURIs[prefix] != "" && !slices.Contains(bound, prefix) {
+				bound = append(bound[:len(bound):len(bound)], prefix)
+				buf.WriteString(" xmlns:" + prefix + `="` + attrPrefixURIs[prefix] + `"`)
+			}
+		}
+		for _, a := range n.Attr {
+			buf.WriteByte(' ')
+			buf.WriteString(a.Key)
+			buf.WriteString(`="`)
+			if a.Val == "" && booleanAttrs[a.Key] {
+				buf.WriteString(a.Key)
+			} else {
+				escapeAttr(buf, a.Val)
+			}
+			buf.WriteByte('"')
+		}
+		if n.FirstChild == nil && (n.Namespace != "" || c.selfCloses(n)) {
+			buf.WriteString("/>")
+			return
+		}
+		buf.WriteByte('>')
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			renderXHTML(buf, child, c, n.Namespace, bound)
+		}
+		buf.WriteString("</")
+		buf.WriteString(n.Data)
+		buf.WriteByte('>')
+
+	case html.CommentNode, html.DoctypeNode:
+		// never emitted
+
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			renderXHTML(buf, child, c, ns, bound)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package htmlsanitizer_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/njchilds90/htmlsanitizer"
+)
+
+func xhtmlPolicy() *htmlsanitizer.Policy {
+	p := htmlsanitizer.MathPolicy()
+	p.XHTML = true
+	p.AllowedTags = append(p.AllowedTags, "use")
+	p.AllowedAttributes["use"] = []string{"xlink:href"}
+	p.AllowedAttributes["details"] = []string{"open"}
+	p.AllowedAttributes["*"] = append(p.AllowedAttributes["*"], "xml:lang")
+	p.AllowedSchemes = append(p.AllowedSchemes, "")
+	return p
+}
+
+func TestSanitize_XHTML(t *testing.T) {
+	tests := []struct{ name, in, want string }{
+		{
+			"document",
+			`<?xml version="1.0"?><!DOCTYPE html><html xmlns="http://www.w3.org/1999/xhtml"><head><title>T</title></head>` +
+				`<body><p xml:lang="en">a &amp; b&nbsp;c<br/><script>x()</script></p><!-- note --></body></html>`,
+			"<p xml:lang=\"en\">a &amp; b c<br/></p>",
+		},
+		{
+			"namespaces",
+			`<div xmlns="http://www.w3.org/1999/xhtml" xmlns:xl="http://www.w3.org/1999/xlink">` +
+				`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1 1"><path d="M0"/><use xl:href="#p" onclick="x()"/></svg>` +
+				`<math xmlns="http://www.w3.org/1998/Math/MathML"><mi mathvariant="bold">x</mi></math>` +
+				`<dc:title xmlns:dc="http://purl.org/dc/elements/1.1/">dropped</dc:title></div>`,
+			`<div><svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1 1"><path d="M0"/><use xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="#p"/></svg>` +
+				`<math xmlns="http://www.w3.org/1998/Math/MathML"><mi mathvariant="bold">x</mi></math></div>`,
+		},
+		{
+			"fragment",
+			`  <P>one</P> text <details open="open"><summary>s</summary></details><p/>`,
+			`  <p>one</p> text <details open="open"><summary>s</summary></details><p></p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlsanitizer.Sanitize(tt.in, xhtmlPolicy())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+			d := xml.NewDecoder(strings.NewReader("<root>" + got + "</root>"))
+			for {
+				if _, err := d.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("output is not well-formed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitize_XHTMLErrors(t *testing.T) {
+	for _, in := range []string{
+		`<p>unclosed`,
+		`<p><b>crossed</p></b>`,
+		`<!DOCTYPE p [<!ENTITY e "boom">]><p>&e;</p>`,
+		`<p a="1" a="2"/>`,
+	} {
+		_, err := htmlsanitizer.Sanitize(in, xhtmlPolicy())
+		var perr *htmlsanitizer.ParseError
+		if !errors.As(err, &perr) || perr.Line != 1 {
+			t.Errorf("Sanitize(%q): err = %v, want a *ParseError", in, err)
+		}
+	}
+}
+
+func TestSanitize_XHTMLManyAttributes(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("<p")
+	for i := 0; i < 40000; i++ {
+		fmt.Fprintf(&b, ` a%d="x"`, i)
+	}
+	start := time.Now()
+	if _, err := htmlsanitizer.Sanitize(b.String()+">x</p>", xhtmlPolicy()); err != nil {
+		t.Fatal(err)
+	}
+	var perr *htmlsanitizer.ParseError
+	if _, err := htmlsanitizer.Sanitize(b.String()+` a39999="y">x</p>`, xhtmlPolicy()); !errors.As(err, &perr) {
+		t.Errorf("duplicate after 40000 attributes: err = %v, want a *ParseError", err)
+	}
+	// Checking for duplicates pairwise took seconds.
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("40000 attributes took %v", d)
+	}
+}
+
+func TestSanitize_XHTMLVerify(t *testing.T) {
+	p := xhtmlPolicy()
+	p.Verify = htmlsanitizer.VerifyError
+	in := `<p><svg xmlns="http://www.w3.org/2000/svg"><path d="M0"/></svg><input type="checkbox" checked="checked"/></p>`
+	if _, err := htmlsanitizer.Sanitize(in, p); err != nil {
+		t.Errorf("Verify rejected XHTML output: %v", err)
+	}
+}
+
+func TestSanitize_ForeignContentCase(t *testing.T) {
+	p := &htmlsanitizer.Policy{
+		AllowedTags: []string{"div", "p", "svg", "lineargradient", "stop", "foreignobject"},
+		AllowedAttributes: map[string][]string{
+			"svg":            {"viewBox", "preserveAspectRatio"},
+			"linearGradient": {"gradientUnits"},
+		},
+		StripDisallowed: true,
+		SortAttributes:  true,
+	}
+	in := `<div><svg viewBox="0 0 1 1" preserveAspectRatio="none"><linearGradient gradientUnits="userSpaceOnUse"><stop></stop></linearGradient><foreignObject><p>x</p></foreignObject></svg></div>`
+	want := `<div><svg preserveAspectRatio="none" viewBox="0 0 1 1"><linearGradient gradientUnits="userSpaceOnUse"><stop></stop></linearGradient><foreignObject><p>x</p></foreignObject></svg></div>`
+	got, err := htmlsanitizer.Sanitize(in, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("HTML:\ngot  %q\nwant %q", got, want)
+	}
+
+	p = p.Clone()
+	p.XHTML = true
+	in = `<div><svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1 1"><linearGradient gradientUnits="userSpaceOnUse"/><foreignObject><p xmlns="http://www.w3.org/1999/xhtml">x</p></foreignObject></svg></div>`
+	want = `<div><svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1 1"><linearGradient gradientUnits="userSpaceOnUse"/><foreignObject><p xmlns="http://www.w3.org/1999/xhtml">x</p></foreignObject></svg></div>`
+	if got, err = htmlsanitizer.Sanitize(in, p); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("XHTML:\ngot  %q\nwant %q", got, want)
+	}
+}